@@ -1,14 +1,19 @@
 package main
 
 import (
+	"context"
 	"jetspotter/internal/configuration"
 	"jetspotter/internal/jetspotter"
 	"jetspotter/internal/metrics"
 	"jetspotter/internal/notification"
+	"jetspotter/internal/upstream"
 	"jetspotter/internal/version"
 	"jetspotter/internal/web"
 	"log"
+	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
 	"time"
 )
 
@@ -19,50 +24,20 @@ func exitWithError(err error) {
 	log.Fatalf("Something went wrong: %v\n", err)
 }
 
-func sendNotifications(aircraft []jetspotter.Aircraft, config configuration.Config) error {
+// notifiers are the configured notification channels, created at startup
+var notifiers []notification.Notifier
+
+func sendNotifications(aircraft []jetspotter.Aircraft, config configuration.Config) {
 	sortedAircraft := jetspotter.SortByDistance(aircraft)
 
 	if len(aircraft) < 1 {
 		log.Println("No new matching aircraft have been spotted.")
-		return nil
-	}
-
-	// Terminal
-	notification.SendTerminalMessage(sortedAircraft, config)
-
-	// Slack
-	if config.SlackWebHookURL != "" {
-		err := notification.SendSlackMessage(sortedAircraft, config)
-		if err != nil {
-			return err
-		}
-	}
-
-	// Discord
-	if config.DiscordWebHookURL != "" {
-		err := notification.SendDiscordMessage(sortedAircraft, config)
-		if err != nil {
-			return err
-		}
-	}
-
-	// Gotify
-	if config.GotifyURL != "" && config.GotifyToken != "" {
-		err := notification.SendGotifyMessage(sortedAircraft, config)
-		if err != nil {
-			return err
-		}
-	}
-
-	// Ntfy
-	if config.NtfyTopic != "" {
-		err := notification.SendNtfyMessage(sortedAircraft, config)
-		if err != nil {
-			return err
-		}
+		return
 	}
 
-	return nil
+	// Every channel gets its own timeout and a failing channel doesn't affect the others, Dispatch logs the errors per channel
+	timeout := time.Duration(config.NotificationTimeoutSeconds) * time.Second
+	_ = notification.Dispatch(notifiers, sortedAircraft, timeout)
 }
 
 func jetspotterHandler(alreadySpottedAircraft *[]jetspotter.Aircraft, config configuration.Config, isFirstRun bool) {
@@ -71,10 +46,10 @@ func jetspotterHandler(alreadySpottedAircraft *[]jetspotter.Aircraft, config con
 		exitWithError(err)
 	}
 
-	err = sendNotifications(aircraft, config)
-	if err != nil {
-		exitWithError(err)
-	}
+	// Persist the enrichment caches so that they survive restarts
+	jetspotter.SaveCache()
+
+	sendNotifications(aircraft, config)
 
 	// If this is the first successful data fetch, signal that data is ready
 	if isFirstRun {
@@ -88,7 +63,8 @@ func jetspotterHandler(alreadySpottedAircraft *[]jetspotter.Aircraft, config con
 	}
 }
 
-func HandleJetspotter(config configuration.Config) {
+// HandleJetspotter spots aircraft every fetch interval until the context is done.
+func HandleJetspotter(ctx context.Context, config configuration.Config) {
 	if config.MaxScanRangeKilometers > config.MaxRangeKilometers {
 		log.Printf("Scanning for aircraft within %d kilometers, sending notifications for those within %d kilometers: %s",
 			config.MaxScanRangeKilometers, config.MaxRangeKilometers, config.AircraftTypes)
@@ -109,7 +85,12 @@ func HandleJetspotter(config configuration.Config) {
 		if isFirstRun {
 			isFirstRun = false
 		}
-		time.Sleep(time.Duration(config.FetchInterval) * time.Second)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Duration(config.FetchInterval) * time.Second):
+		}
 	}
 }
 
@@ -175,14 +156,83 @@ func main() {
 		exitWithError(err)
 	}
 
+	// The workers in the background are stopped when jetspotter is interrupted or terminated
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// Select the best available ADSB API at startup
 	jetspotter.SelectBestAPI()
 
+	// Configure the upstream APIs, the caches and the workers that fetch images and flight routes
+	upstream.Configure(config)
+	jetspotter.SetupCache(config)
+	jetspotter.SetupEnrichment(ctx, config)
+	err = jetspotter.SetupWeather(config)
+	if err != nil {
+		exitWithError(err)
+	}
+	err = jetspotter.SetupRegistry(config)
+	if err != nil {
+		exitWithError(err)
+	}
+	err = jetspotter.SetupRoutes(config)
+	if err != nil {
+		exitWithError(err)
+	}
+	err = jetspotter.SetupImages(config)
+	if err != nil {
+		exitWithError(err)
+	}
+	err = jetspotter.SetupGeocoder(config)
+	if err != nil {
+		exitWithError(err)
+	}
+	err = jetspotter.SetupAirspaces(config)
+	if err != nil {
+		exitWithError(err)
+	}
+	err = jetspotter.SetupTerrain(config)
+	if err != nil {
+		exitWithError(err)
+	}
+	err = jetspotter.SetupConditions(config)
+	if err != nil {
+		exitWithError(err)
+	}
+	err = jetspotter.SetupAloft(config)
+	if err != nil {
+		exitWithError(err)
+	}
+	err = jetspotter.SetupOutbox(config, notification.Endpoints(config))
+	if err != nil {
+		exitWithError(err)
+	}
+	err = jetspotter.SetupMQTT(config)
+	if err != nil {
+		exitWithError(err)
+	}
+	jetspotter.SetupRouteValidation(config)
+
+	err = notification.SetupTemplates(config)
+	if err != nil {
+		exitWithError(err)
+	}
+	jetspotter.NotificationPreview = func(channel string, aircraft []jetspotter.Aircraft) (any, error) {
+		return notification.Preview(channel, aircraft, config)
+	}
+	notifiers = notification.Notifiers(config)
+	for _, notifier := range notifiers {
+		log.Printf("Sending notifications to %s", notifier.Name())
+	}
+
 	// Start services
 	HandleMetrics(config)
 	HandleAPI(config)
 	HandleWebUI(config)
 
 	// Start the main aircraft tracking loop
-	HandleJetspotter(config)
+	HandleJetspotter(ctx, config)
+
+	log.Println("Shutting down")
+	jetspotter.SaveCache()
 }
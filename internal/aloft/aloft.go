@@ -0,0 +1,131 @@
+package aloft
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Report is the wind and temperature that an aircraft reports at its altitude
+type Report struct {
+	Time time.Time
+	// Barometric altitude in feet
+	AltitudeFeet float64
+	// Direction in degrees that the wind blows from, nil if it isn't reported
+	WindDirection *float64
+	// Wind speed in knots, nil if it isn't reported
+	WindSpeed *float64
+	// Outside air temperature in degrees Celsius, nil if it isn't reported
+	Temperature *float64
+}
+
+// Level is the average wind and temperature of an altitude band
+type Level struct {
+	// Lower and upper altitude of the band in feet
+	LowerFeet int `json:"lowerFeet"`
+	UpperFeet int `json:"upperFeet"`
+	// Direction in degrees that the wind blows from and its speed in knots, nil without wind reports
+	WindDirection *float64 `json:"windDirection"`
+	WindSpeed     *float64 `json:"windSpeed"`
+	// Outside air temperature in degrees Celsius, nil without temperature reports
+	Temperature *float64 `json:"temperature"`
+	// Number of reports of the wind and the temperature
+	WindReports        int `json:"windReports"`
+	TemperatureReports int `json:"temperatureReports"`
+}
+
+// Profile aggregates the reports of the aircraft into the winds and temperatures aloft, by altitude band
+type Profile struct {
+	mu       sync.Mutex
+	bandFeet int
+	window   time.Duration
+	reports  []Report
+}
+
+// NewProfile creates a profile with bands of bandFeet, reports older than the window are forgotten.
+func NewProfile(bandFeet int, window time.Duration) *Profile {
+	return &Profile{bandFeet: bandFeet, window: window}
+}
+
+// BandFeet returns the height of the altitude bands.
+func (p *Profile) BandFeet() int {
+	return p.bandFeet
+}
+
+// Window returns how long the reports are used for.
+func (p *Profile) Window() time.Duration {
+	return p.window
+}
+
+// Add adds a report to the profile, reports without wind and temperature are ignored.
+func (p *Profile) Add(report Report) {
+	if report.AltitudeFeet < 0 || ((report.WindDirection == nil || report.WindSpeed == nil) && report.Temperature == nil) {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reports = append(p.reports, report)
+}
+
+// Levels returns the levels that have reports within the window before now, from low to high.
+// Reports that are older are removed.
+func (p *Profile) Levels(now time.Time) []Level {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Reports are added in order, so the old ones are at the start
+	start := sort.Search(len(p.reports), func(i int) bool { return now.Sub(p.reports[i].Time) <= p.window })
+	p.reports = append(p.reports[:0], p.reports[start:]...)
+
+	type sums struct {
+		north, east, temperature float64
+		winds, temperatures      int
+	}
+	bands := make(map[int]*sums)
+	for _, report := range p.reports {
+		band := int(report.AltitudeFeet) / p.bandFeet
+		s := bands[band]
+		if s == nil {
+			s = &sums{}
+			bands[band] = s
+		}
+
+		if report.WindDirection != nil && report.WindSpeed != nil {
+			// Directions are averaged as vectors, so that 350 and 10 degrees average to north
+			radians := *report.WindDirection * math.Pi / 180
+			s.north += math.Cos(radians) * *report.WindSpeed
+			s.east += math.Sin(radians) * *report.WindSpeed
+			s.winds++
+		}
+		if report.Temperature != nil {
+			s.temperature += *report.Temperature
+			s.temperatures++
+		}
+	}
+
+	levels := make([]Level, 0, len(bands))
+	for band, s := range bands {
+		level := Level{
+			LowerFeet:          band * p.bandFeet,
+			UpperFeet:          (band + 1) * p.bandFeet,
+			WindReports:        s.winds,
+			TemperatureReports: s.temperatures,
+		}
+		if s.winds > 0 {
+			direction := math.Mod(math.Round(math.Atan2(s.east, s.north)*180/math.Pi)+360, 360)
+			// The speed is the one of the average vector, winds that vary in direction partly cancel out
+			speed := math.Round(math.Hypot(s.north, s.east) / float64(s.winds))
+			level.WindDirection, level.WindSpeed = &direction, &speed
+		}
+		if s.temperatures > 0 {
+			temperature := math.Round(s.temperature/float64(s.temperatures)*10) / 10
+			level.Temperature = &temperature
+		}
+		levels = append(levels, level)
+	}
+
+	sort.Slice(levels, func(i, j int) bool { return levels[i].LowerFeet < levels[j].LowerFeet })
+	return levels
+}
@@ -0,0 +1,37 @@
+package aloft
+
+import (
+	"testing"
+	"time"
+)
+
+func float(value float64) *float64 {
+	return &value
+}
+
+func TestLevels(t *testing.T) {
+	profile := NewProfile(2000, time.Hour)
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	// Forgotten once it is more than an hour old
+	profile.Add(Report{Time: now.Add(-2 * time.Hour), AltitudeFeet: 35000, WindDirection: float(90), WindSpeed: float(10)})
+	// The wind directions average to north across 360 degrees
+	profile.Add(Report{Time: now.Add(-time.Minute), AltitudeFeet: 35100, WindDirection: float(350), WindSpeed: float(100), Temperature: float(-54)})
+	profile.Add(Report{Time: now, AltitudeFeet: 35900, WindDirection: float(10), WindSpeed: float(100), Temperature: float(-55)})
+	profile.Add(Report{Time: now, AltitudeFeet: 4500, Temperature: float(3)})
+	// Without wind or temperature there is nothing to report
+	profile.Add(Report{Time: now, AltitudeFeet: 20000})
+
+	levels := profile.Levels(now)
+	if len(levels) != 2 {
+		t.Fatalf("expected 2 levels, got %+v", levels)
+	}
+
+	low, high := levels[0], levels[1]
+	if low.LowerFeet != 4000 || low.UpperFeet != 6000 || low.WindDirection != nil || *low.Temperature != 3 {
+		t.Errorf("unexpected low level %+v", low)
+	}
+	if high.LowerFeet != 34000 || *high.WindDirection != 0 || *high.WindSpeed != 98 || *high.Temperature != -54.5 || high.WindReports != 2 {
+		t.Errorf("unexpected high level %+v with wind %v/%v", high, *high.WindDirection, *high.WindSpeed)
+	}
+}
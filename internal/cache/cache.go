@@ -0,0 +1,173 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"jetspotter/internal/metrics"
+)
+
+// Cache is a key value store of which the entries expire after a time to live.
+// Keys that do not exist upstream can be stored as negative entries, so that they are not looked up again either.
+// The entries can optionally be persisted on disk.
+type Cache[V any] struct {
+	name        string
+	ttl         time.Duration
+	negativeTTL time.Duration
+	path        string
+
+	mu      sync.Mutex
+	entries map[string]entry[V]
+	dirty   bool
+}
+
+// entry is a single value in the cache
+type entry[V any] struct {
+	Value    V         `json:"value"`
+	Negative bool      `json:"negative,omitempty"`
+	Expires  time.Time `json:"expires"`
+}
+
+// New creates a cache. Values are kept for ttl and negative entries for negativeTTL, a duration of 0 disables caching them.
+// If path is not empty, the cache is persisted to that file.
+func New[V any](name string, ttl, negativeTTL time.Duration, path string) *Cache[V] {
+	return &Cache[V]{
+		name:        name,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		path:        path,
+		entries:     make(map[string]entry[V]),
+	}
+}
+
+// Get returns the value of a key and whether it was found in the cache.
+// For negative entries the zero value is returned together with true.
+func (c *Cache[V]) Get(key string) (value V, found bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if ok && time.Now().After(e.Expires) {
+		delete(c.entries, key)
+		c.dirty = true
+		ok = false
+	}
+
+	if !ok {
+		metrics.IncrementCacheRequests(c.name, "miss")
+		return value, false
+	}
+
+	if e.Negative {
+		metrics.IncrementCacheRequests(c.name, "negative_hit")
+	} else {
+		metrics.IncrementCacheRequests(c.name, "hit")
+	}
+	return e.Value, true
+}
+
+// Set stores the value of a key.
+func (c *Cache[V]) Set(key string, value V) {
+	c.set(key, entry[V]{Value: value}, c.ttl)
+}
+
+// SetNotFound stores a negative entry for a key that does not exist upstream.
+func (c *Cache[V]) SetNotFound(key string) {
+	c.set(key, entry[V]{Negative: true}, c.negativeTTL)
+}
+
+func (c *Cache[V]) set(key string, e entry[V], ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e.Expires = time.Now().Add(ttl)
+	c.entries[key] = e
+	c.dirty = true
+	metrics.SetCacheEntries(c.name, len(c.entries))
+}
+
+// Len returns the number of entries in the cache, including expired entries that have not been removed yet.
+func (c *Cache[V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// purge removes all expired entries, the caller must hold the lock.
+func (c *Cache[V]) purge() {
+	now := time.Now()
+	for key, e := range c.entries {
+		if now.After(e.Expires) {
+			delete(c.entries, key)
+			c.dirty = true
+		}
+	}
+	metrics.SetCacheEntries(c.name, len(c.entries))
+}
+
+// Load reads the persisted entries from disk. A missing file is not an error.
+func (c *Cache[V]) Load() error {
+	if c.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(c.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s cache: %w", c.name, err)
+	}
+
+	entries := make(map[string]entry[V])
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("failed to parse %s cache: %w", c.name, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = entries
+	c.purge()
+	c.dirty = false
+	return nil
+}
+
+// Save persists the entries to disk if anything changed since the last save.
+func (c *Cache[V]) Save() error {
+	if c.path == "" {
+		return nil
+	}
+
+	c.mu.Lock()
+	c.purge()
+	if !c.dirty {
+		c.mu.Unlock()
+		return nil
+	}
+	data, err := json.Marshal(c.entries)
+	c.dirty = false
+	c.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode %s cache: %w", c.name, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create %s cache directory: %w", c.name, err)
+	}
+
+	// Write to a temporary file first so that a crash never leaves a truncated cache behind
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s cache: %w", c.name, err)
+	}
+
+	return os.Rename(tmp, c.path)
+}
@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCacheHitAndMiss(t *testing.T) {
+	c := New[string]("test", time.Minute, time.Minute, "")
+
+	if _, found := c.Get("44c1e5"); found {
+		t.Fatal("expected an empty cache to miss")
+	}
+
+	c.Set("44c1e5", "G-10")
+	value, found := c.Get("44c1e5")
+	if !found || value != "G-10" {
+		t.Fatalf("expected 'G-10' to be cached, got '%v' (found: %v)", value, found)
+	}
+}
+
+func TestCacheNegativeEntry(t *testing.T) {
+	c := New[*string]("test", time.Minute, time.Minute, "")
+	c.SetNotFound("DEADBE")
+
+	value, found := c.Get("DEADBE")
+	if !found || value != nil {
+		t.Fatalf("expected a negative hit, got '%v' (found: %v)", value, found)
+	}
+}
+
+func TestCacheExpiry(t *testing.T) {
+	c := New[string]("test", time.Millisecond, time.Millisecond, "")
+	c.Set("44c1e5", "G-10")
+	time.Sleep(5 * time.Millisecond)
+
+	if _, found := c.Get("44c1e5"); found {
+		t.Fatal("expected the entry to be expired")
+	}
+}
+
+func TestCacheDisabled(t *testing.T) {
+	c := New[string]("test", 0, 0, "")
+	c.Set("44c1e5", "G-10")
+	c.SetNotFound("DEADBE")
+
+	if c.Len() != 0 {
+		t.Fatalf("expected nothing to be cached when the TTL is 0, got %d entries", c.Len())
+	}
+}
+
+func TestCachePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "test.json")
+
+	c := New[string]("test", time.Hour, time.Hour, path)
+	c.Set("44c1e5", "G-10")
+	c.SetNotFound("DEADBE")
+	if err := c.Save(); err != nil {
+		t.Fatalf("failed to save cache: %v", err)
+	}
+
+	loaded := New[string]("test", time.Hour, time.Hour, path)
+	if err := loaded.Load(); err != nil {
+		t.Fatalf("failed to load cache: %v", err)
+	}
+
+	if value, found := loaded.Get("44c1e5"); !found || value != "G-10" {
+		t.Fatalf("expected 'G-10' to be loaded from disk, got '%v' (found: %v)", value, found)
+	}
+
+	if _, found := loaded.Get("DEADBE"); !found {
+		t.Fatal("expected the negative entry to be loaded from disk")
+	}
+}
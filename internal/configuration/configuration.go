@@ -1,6 +1,7 @@
 package configuration
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"strconv"
@@ -43,15 +44,20 @@ type Config struct {
 	// AIRCRAFT_TYPES F16,F35
 	// To spot all military aircraft, you can use MILITARY.
 	// AIRCRAFT_TYPES MILITARY
+	// To spot aircraft that likely leave a contrail, you can use CONTRAIL.
+	// AIRCRAFT_TYPES CONTRAIL
 	AircraftTypes []string
 
-	// Webhook used to send notifications to Slack. If not set, no messages will be sent to Slack.
+	// Comma separated list of webhooks used to send notifications to Slack. If not set, no messages will be sent to Slack.
 	// SLACK_WEBHOOK_URL ""
-	SlackWebHookURL string
+	SlackWebHookURL []string
 
-	// Webhook used to send notifications to Discord. If not set, no messages will be sent to Discord.
+	// Comma separated list of webhooks used to send notifications to Discord. If not set, no messages will be sent to Discord.
 	// DISCORD_WEBHOOK_URL ""
-	DiscordWebHookURL string
+	// EXAMPLES
+	// Send notifications to two Discord channels:
+	// DISCORD_WEBHOOK_URL https://discord.com/api/webhooks/1/a,https://discord.com/api/webhooks/2/b
+	DiscordWebHookURL []string
 
 	// Discord notifications use an embed color based on the alitute of the aircraft.
 	// DISCORD_COLOR_ALTITUDE "true"
@@ -61,9 +67,382 @@ type Config struct {
 	// FETCH_INTERVAL 60
 	FetchInterval int
 
-	// Token to authenticate with the gotify server.
+	// Maximum number of seconds that the position of an aircraft is extrapolated past its last reported position.
+	// Positions are estimated using the ground speed, track, track rate and vertical rate of the aircraft.
+	// Set to 0 to disable the extrapolation.
+	// MAX_EXTRAPOLATION_SECONDS 120
+	MaxExtrapolationSeconds int
+
+	// Number of minutes that images of aircraft are cached.
+	// Set to 0 to disable the cache.
+	// IMAGE_CACHE_TTL_MINUTES 1440
+	ImageCacheTTLMinutes int
+
+	// Number of minutes that flight routes of callsigns are cached.
+	// Set to 0 to disable the cache.
+	// ROUTE_CACHE_TTL_MINUTES 360
+	RouteCacheTTLMinutes int
+
+	// Number of minutes to remember that no image or flight route exists for an aircraft.
+	// Set to 0 to disable negative caching.
+	// NEGATIVE_CACHE_TTL_MINUTES 60
+	NegativeCacheTTLMinutes int
+
+	// Directory in which the caches are persisted, so that they survive restarts.
+	// If not set, the caches are only kept in memory.
+	// CACHE_DIRECTORY ""
+	CacheDirectory string
+
+	// Timeout in seconds for every request to an upstream API.
+	// UPSTREAM_TIMEOUT_SECONDS 10
+	UpstreamTimeoutSeconds int
+
+	// Maximum number of requests per minute to the planespotters.net API, used to fetch images.
+	// Set to 0 to disable the rate limit.
+	// PLANESPOTTERS_REQUESTS_PER_MINUTE 30
+	PlanespottersRequestsPerMinute int
+
+	// Maximum number of requests per minute to the adsbdb.com API, used to fetch flight routes.
+	// Set to 0 to disable the rate limit.
+	// ADSBDB_REQUESTS_PER_MINUTE 60
+	ADSBDBRequestsPerMinute int
+
+	// Maximum number of requests per minute to the open-meteo.com API, used to fetch the weather.
+	// Set to 0 to disable the rate limit.
+	// OPEN_METEO_REQUESTS_PER_MINUTE 10
+	OpenMeteoRequestsPerMinute int
+
+	// Maximum number of requests per minute to the aviationweather.gov API, used to fetch the METARs and TAFs.
+	// The API asks clients to stay below 100 requests per minute. Set to 0 to disable the rate limit.
+	// AVIATION_WEATHER_REQUESTS_PER_MINUTE 10
+	AviationWeatherRequestsPerMinute int
+
+	// Number of workers that fetch images and flight routes in parallel.
+	// Aircraft are published immediately, their image and flight route are added during the first cycle after they have been fetched.
+	// ENRICHMENT_WORKERS 4
+	EnrichmentWorkers int
+
+	// Maximum number of seconds to wait for the image and flight route of an aircraft before sending a notification.
+	// ENRICHMENT_WAIT_SECONDS 15
+	EnrichmentWaitSeconds int
+
+	// Backend that provides the weather forecast, either "open-meteo" or "file".
+	// WEATHER_PROVIDER "open-meteo"
+	WeatherProvider string
+
+	// Path to a JSON file with the weather forecast, used when WEATHER_PROVIDER is "file".
+	// WEATHER_FILE ""
+	WeatherFile string
+
+	// Size in degrees of the grid cells for which the weather is looked up.
+	// Every aircraft gets the weather of the grid cell it is in, set to 0 to use the weather of LOCATION_LATITUDE and LOCATION_LONGITUDE for all aircraft.
+	// WEATHER_GRID_DEGREES 0.5
+	WeatherGridDegrees float64
+
+	// Comma separated list of ICAO codes of the stations whose METAR and TAF are used, in order of preference.
+	// The conditions of the first station with a recent report are added to every aircraft.
+	// METAR_STATIONS ""
+	MetarStations []string
+
+	// URL or path of a file with the METARs of the stations, {stations} in a URL is replaced by the list of stations.
+	// METAR_SOURCE "https://aviationweather.gov/api/data/metar?ids={stations}&format=raw"
+	MetarSource string
+
+	// URL or path of a file with the TAFs of the stations, the TAF is used when there is no recent METAR.
+	// Set to an empty string to disable TAFs.
+	// TAF_SOURCE "https://aviationweather.gov/api/data/taf?ids={stations}&format=raw"
+	TafSource string
+
+	// Number of minutes between fetching the METARs and TAFs.
+	// METAR_REFRESH_MINUTES 10
+	MetarRefreshMinutes int
+
+	// Maximum age in minutes of a METAR, older METARs are replaced by the TAF.
+	// METAR_MAX_AGE_MINUTES 90
+	MetarMaxAgeMinutes int
+
+	// Comma separated list of runways per station, used to report the runway that is likely in use based on the wind.
+	// Both ends of a runway are separated by a slash, stations are separated by a space, for example "EBBL:05/23 EHEH:03/21".
+	// RUNWAYS ""
+	Runways map[string][]string
+
+	// Don't send notifications for aircraft that fly above the ceiling reported by the METAR or TAF, since they can't be seen.
+	// SUPPRESS_ABOVE_CEILING false
+	SuppressAboveCeiling bool
+
+	// Path to an offline aircraft database, used to fill in the registration, type, operator and year of aircraft.
+	// Supports the aircraft.csv.gz of tar1090-db and CSV exports with a header such as BaseStation.sqb.
+	// AIRCRAFT_DATABASE ""
+	AircraftDatabase string
+
+	// Number of minutes between checks whether the aircraft database changed on disk.
+	// AIRCRAFT_DATABASE_RELOAD_MINUTES 60
+	AircraftDatabaseReloadMinutes int
+
+	// Comma separated list of providers that are asked for the flight route of a callsign, in order.
+	// Valid providers are "standing-data" and "adsbdb", for example "standing-data,adsbdb" to use the local files first.
+	// ROUTE_PROVIDERS "adsbdb"
+	RouteProviders []string
+
+	// Directory with the routes, airlines and airports CSV files of the Virtual Radar Server standing data.
+	// STANDING_DATA_DIRECTORY ""
+	StandingDataDirectory string
+
+	// Maximum distance in kilometers between an aircraft and the great-circle path of its flight route.
+	// Routes that the aircraft isn't flying along are considered wrong and aren't shown.
+	// ROUTE_CROSS_TRACK_TOLERANCE_KILOMETERS 150
+	RouteCrossTrackToleranceKilometers int
+
+	// Maximum difference in degrees between the track of an aircraft and the direction of its flight route.
+	// ROUTE_TRACK_TOLERANCE_DEGREES 60
+	RouteTrackToleranceDegrees int
+
+	// Comma separated list of providers that are asked for an image of an aircraft, in order.
+	// Valid providers are "local", "template" and "planespotters", for example "local,planespotters" to prefer your own photos.
+	// IMAGE_PROVIDERS "planespotters"
+	ImageProviders []string
+
+	// URL of an image of an aircraft, used by the template image provider.
+	// The placeholders {icao} and {registration} are replaced by the ICAO address and the registration of the aircraft.
+	// IMAGE_URL_TEMPLATE ""
+	ImageURLTemplate string
+
+	// Directory with your own photos of aircraft, used by the local image provider.
+	// Photos are named after the registration or the ICAO address of the aircraft, for example OO-SNA.jpg or 44c1e5.jpg.
+	// IMAGE_DIRECTORY ""
+	ImageDirectory string
+
+	// Maximum size in megabytes of the thumbnails that are cached on disk in the thumbnails folder of the cache directory.
+	// Cached thumbnails are served under /images, so they keep working when the image provider is down.
+	// Set to 0 to disable the thumbnail cache, it is also disabled if no cache directory is set.
+	// THUMBNAIL_CACHE_MEGABYTES 100
+	ThumbnailCacheMegabytes int
+
+	// URL on which the API of jetspotter can be reached by the notification services, for example "http://jetspotter.example.com:8085".
+	// Required to use cached thumbnails and your own photos in notifications.
+	// PUBLIC_URL ""
+	PublicURL string
+
+	// GeoNames dump with the places that are used to describe the positions of the aircraft, for example cities1000.zip
+	// from https://download.geonames.org/export/dump. Airports of the standing data directory are used as well.
+	// GEONAMES_FILE ""
+	GeoNamesFile string
+
+	// Maximum distance in kilometers between an aircraft and the place that its position is described by.
+	// PLACE_MAX_DISTANCE_KILOMETERS 50
+	PlaceMaxDistanceKilometers int
+
+	// Comma separated list of airspace files in the OpenAir format, for example with the restricted areas and military zones of your country.
+	// The airspaces that each aircraft is in are computed based on its position and altitude.
+	// AIRSPACE_FILES ""
+	AirspaceFiles []string
+
+	// Comma separated list of airspaces, only aircraft that are inside one of them are notified about.
+	// Airspaces are matched by their full name or its first word, and * can be used as a wildcard.
+	// Every airspace has to be in AIRSPACE_FILES. If not set, the filter is disabled.
+	// NOTIFY_AIRSPACES ""
+	// EXAMPLES
+	// NOTIFY_AIRSPACES EBR*,EBD26
+	NotifyAirspaces []string
+
+	// Directory with SRTM .hgt tiles or GeoTIFF files of the terrain elevation in meters, used to compute the height of
+	// the aircraft above the ground. GeoTIFF files need geographic coordinates and may be uncompressed or deflate compressed.
+	// TERRAIN_DIRECTORY ""
+	TerrainDirectory string
+
+	// Maximum height above the ground in feet that you want to spot aircraft at, for example to only spot low flying aircraft.
+	// Without terrain elevation data the altitude above sea level is used instead.
+	// Set to 0 to disable the filter.
+	// MAX_HEIGHT_ABOVE_GROUND_FEET 0
+	MaxHeightAboveGroundFeet int
+
+	// Comma separated list of conditions on the telemetry that aircraft have to meet to be notified about, all of them have to match.
+	// A condition compares a field with a number using <, <=, >, >=, = or !=. Aircraft that don't report a field never match it.
+	// Fields are altitude, height_above_ground, speed, ias, tas, mach, vertical_rate, wind_direction, wind_speed, oat, tat,
+	// selected_altitude, fms_altitude, selected_heading and qnh.
+	// If not set, the filter is disabled.
+	// NOTIFY_CONDITIONS ""
+	// EXAMPLES
+	// Aircraft descending faster than 3000 feet per minute towards an altitude below 2000 feet:
+	// NOTIFY_CONDITIONS vertical_rate<-3000,selected_altitude<2000
+	NotifyConditions []string
+
+	// Include the air data, wind, temperatures and autopilot settings that aircraft report in the notifications.
+	// NOTIFY_TELEMETRY false
+	NotifyTelemetry bool
+
+	// Height in feet of the altitude bands of the winds and temperatures aloft, which are derived from the reports of the aircraft.
+	// ALOFT_BAND_FEET 2000
+	AloftBandFeet int
+
+	// Number of minutes that the reports of the aircraft are used for the winds and temperatures aloft.
+	// ALOFT_WINDOW_MINUTES 60
+	AloftWindowMinutes int
+
+	// Maximum age in seconds of the last reported position of an aircraft, older positions are handled according to
+	// POSITION_QUALITY_ACTION before the range of the aircraft is checked.
+	// Set to 0 to disable the check.
+	// MAX_POSITION_AGE_SECONDS 0
+	MaxPositionAgeSeconds int
+
+	// Maximum error in meters of the reported position of an aircraft, as estimated from its NACp. Less accurate positions are
+	// handled according to POSITION_QUALITY_ACTION. Positions of unknown accuracy, such as the ones from MLAT, always pass.
+	// Set to 0 to disable the check.
+	// MAX_POSITION_ERROR_METERS 0
+	MaxPositionErrorMeters int
+
+	// What to do with aircraft whose position is too old or too inaccurate: "flag" keeps them in the output with their
+	// position marked as stale or inaccurate but doesn't notify about them, "drop" removes them altogether.
+	// POSITION_QUALITY_ACTION flag
+	PositionQualityAction string
+
+	// Number of seconds that each notification channel gets to send its notifications, channels that take longer are cancelled.
+	// NOTIFICATION_TIMEOUT_SECONDS 30
+	NotificationTimeoutSeconds int
+
+	// Maximum number of attempts to deliver a notification. Failed notifications are retried with an exponential backoff,
+	// after the last attempt they are moved to the dead letters, which can be inspected and replayed via the API.
+	// Notifications that haven't been delivered yet are persisted in CACHE_DIRECTORY if it is set.
+	// NOTIFICATION_MAX_ATTEMPTS 8
+	NotificationMaxAttempts int
+
+	// Number of seconds before the first retry of a notification, the delay doubles with every failed attempt.
+	// A longer delay that the channel asks for with a Retry-After header is honoured.
+	// NOTIFICATION_RETRY_SECONDS 5
+	NotificationRetrySeconds int
+
+	// Maximum number of seconds between the retries of a notification.
+	// NOTIFICATION_MAX_RETRY_SECONDS 900
+	NotificationMaxRetrySeconds int
+
+	// Directory with templates for the notifications, named after the channel, for example discord.tmpl, slack.tmpl,
+	// gotify.tmpl, ntfy.tmpl, telegram.tmpl, webhook.tmpl, email.tmpl or terminal.tmpl. They are Go text/template files
+	// that define a "header" for all aircraft in a message, a "title" and a "body" for every aircraft. Templates that a file doesn't define keep their built-in default.
+	// Discord and Slack show every "Name: value" line of the body as a field. The templates can be previewed with sample
+	// aircraft via /api/notifications/preview/<channel>.
+	// NOTIFICATION_TEMPLATE_DIRECTORY ""
+	// EXAMPLES
+	// A discord.tmpl that only changes the title of every aircraft:
+	// {{define "title"}}{{.Callsign}} {{.Type}} {{.Distance}}km {{compass .BearingFromLocation}}{{end}}
+	NotificationTemplateDirectory string
+
+	// Token of the Telegram bot that sends the notifications, as given by @BotFather.
+	// TELEGRAM_BOT_TOKEN ""
+	TelegramBotToken string
+
+	// Comma separated list of Telegram chats to send the notifications to, the bot has to be a member of them.
+	// A topic of a forum group is selected by appending its ID after a colon.
+	// TELEGRAM_CHAT_ID ""
+	// EXAMPLES
+	// A private chat, a channel and the topic with ID 42 of a forum group:
+	// TELEGRAM_CHAT_ID="123456789,@jetspotting,-1001234567890:42"
+	TelegramChatID []string
+
+	// URL of the Telegram Bot API, for example of a self-hosted Bot API server.
+	// TELEGRAM_API_URL "https://api.telegram.org"
+	TelegramAPIURL string
+
+	// URL of the MQTT broker to publish the aircraft to, tcp:// or ssl:// for TLS.
+	// The aircraft in range are published to <prefix>/aircraft/<icao>, newly spotted aircraft to <prefix>/spotted
+	// and the number of aircraft in range, the nearest and the last spotted aircraft to <prefix>/summary.
+	// MQTT_BROKER ""
+	// EXAMPLES
+	// MQTT_BROKER="tcp://homeassistant.local:1883"
+	MQTTBroker string
+
+	// Username to authenticate with the MQTT broker.
+	// MQTT_USERNAME ""
+	MQTTUsername string
+
+	// Password to authenticate with the MQTT broker.
+	// MQTT_PASSWORD ""
+	MQTTPassword string
+
+	// Client ID of jetspotter on the MQTT broker, it has to be unique when several instances use the same broker.
+	// MQTT_CLIENT_ID "jetspotter"
+	MQTTClientID string
+
+	// Prefix of the MQTT topics that jetspotter publishes to.
+	// MQTT_TOPIC_PREFIX "jetspotter"
+	MQTTTopicPrefix string
+
+	// Enable or disable Home Assistant MQTT discovery, which creates sensors for the aircraft in range,
+	// the military aircraft in range, the nearest and the last spotted aircraft.
+	// MQTT_DISCOVERY "true"
+	MQTTDiscovery bool
+
+	// Prefix of the Home Assistant MQTT discovery topics.
+	// MQTT_DISCOVERY_PREFIX "homeassistant"
+	MQTTDiscoveryPrefix string
+
+	// Comma separated list of URLs of webhooks that receive a request for every spotted aircraft.
+	// The JSON body is rendered from the webhook.tmpl template in NOTIFICATION_TEMPLATE_DIRECTORY,
+	// by default {"event": "spotted", "aircraft": {...}} with all fields of the aircraft.
+	// WEBHOOK_URL ""
+	WebhookURL []string
+
+	// HTTP method of the webhook requests.
+	// WEBHOOK_METHOD "POST"
+	WebhookMethod string
+
+	// Comma separated list of headers that are added to the webhook requests, as Name: value.
+	// WEBHOOK_HEADERS ""
+	// EXAMPLES
+	// WEBHOOK_HEADERS="Authorization: Bearer XXXX,X-Source: jetspotter"
+	WebhookHeaders map[string]string
+
+	// Secret to sign the webhook requests with. The signature header contains "sha256=" followed by the hex encoded
+	// HMAC-SHA256 of the timestamp header, a dot and the body. Requests aren't signed without a secret.
+	// WEBHOOK_SECRET ""
+	WebhookSecret string
+
+	// Header that contains the signature of the webhook requests.
+	// WEBHOOK_SIGNATURE_HEADER "X-Jetspotter-Signature"
+	WebhookSignatureHeader string
+
+	// Header that contains the Unix time at which a webhook request is sent, so that receivers can reject old requests.
+	// Retried requests carry the time of the retry.
+	// WEBHOOK_TIMESTAMP_HEADER "X-Jetspotter-Timestamp"
+	WebhookTimestampHeader string
+
+	// Host of the SMTP server to send email notifications through.
+	// SMTP_HOST ""
+	SMTPHost string
+
+	// Port of the SMTP server, usually 587 for STARTTLS and 465 for TLS.
+	// SMTP_PORT "587"
+	SMTPPort int
+
+	// Username to authenticate with the SMTP server, no authentication is used without a username.
+	// SMTP_USERNAME ""
+	SMTPUsername string
+
+	// Password to authenticate with the SMTP server.
+	// SMTP_PASSWORD ""
+	SMTPPassword string
+
+	// Security of the connection to the SMTP server: starttls, tls or none. Passwords are only sent over
+	// unencrypted connections to localhost.
+	// SMTP_SECURITY "starttls"
+	SMTPSecurity string
+
+	// Sender of the email notifications.
+	// EMAIL_FROM ""
+	// EXAMPLES
+	// EMAIL_FROM="Jetspotter <jetspotter@example.com>"
+	EmailFrom string
+
+	// Comma separated list of recipients of the email notifications, they all receive the same email.
+	// EMAIL_TO ""
+	EmailTo []string
+
+	// Number of minutes to collect the spotted aircraft before they are sent in one email, 0 to send an email right away.
+	// EMAIL_DIGEST_MINUTES "0"
+	EmailDigestMinutes int
+
+	// Comma separated list of tokens to authenticate with the gotify server, a message is sent to the application of each token.
 	// GOTIFY_TOKEN ""
-	GotifyToken string
+	GotifyToken []string
 
 	// URL of the gotify server.
 	// GOTIFY_URL ""
@@ -85,9 +464,9 @@ type Config struct {
 	// WEB_UI_PORT "8080"
 	WebUIPort string
 
-	// Topic to publish message to
+	// Comma separated list of topics to publish messages to
 	// NTFY_TOPIC ""
-	NtfyTopic string
+	NtfyTopic []string
 
 	// URL of the ntfy server.
 	// NTFY_SERVER "https://ntfy.sh"
@@ -119,6 +498,111 @@ const (
 	APIPort                = "API_PORT"
 	WebUIEnabled           = "WEB_UI_ENABLED"
 	WebUIPort              = "WEB_UI_PORT"
+
+	MaxExtrapolationSeconds = "MAX_EXTRAPOLATION_SECONDS"
+	ImageCacheTTLMinutes    = "IMAGE_CACHE_TTL_MINUTES"
+	RouteCacheTTLMinutes    = "ROUTE_CACHE_TTL_MINUTES"
+	NegativeCacheTTLMinutes = "NEGATIVE_CACHE_TTL_MINUTES"
+	CacheDirectory          = "CACHE_DIRECTORY"
+
+	UpstreamTimeoutSeconds           = "UPSTREAM_TIMEOUT_SECONDS"
+	PlanespottersRequestsPerMinute   = "PLANESPOTTERS_REQUESTS_PER_MINUTE"
+	ADSBDBRequestsPerMinute          = "ADSBDB_REQUESTS_PER_MINUTE"
+	OpenMeteoRequestsPerMinute       = "OPEN_METEO_REQUESTS_PER_MINUTE"
+	AviationWeatherRequestsPerMinute = "AVIATION_WEATHER_REQUESTS_PER_MINUTE"
+	EnrichmentWorkers                = "ENRICHMENT_WORKERS"
+	EnrichmentWaitSeconds            = "ENRICHMENT_WAIT_SECONDS"
+
+	WeatherProvider    = "WEATHER_PROVIDER"
+	WeatherFile        = "WEATHER_FILE"
+	WeatherGridDegrees = "WEATHER_GRID_DEGREES"
+
+	MetarStations        = "METAR_STATIONS"
+	MetarSource          = "METAR_SOURCE"
+	TafSource            = "TAF_SOURCE"
+	MetarRefreshMinutes  = "METAR_REFRESH_MINUTES"
+	MetarMaxAgeMinutes   = "METAR_MAX_AGE_MINUTES"
+	Runways              = "RUNWAYS"
+	SuppressAboveCeiling = "SUPPRESS_ABOVE_CEILING"
+
+	AircraftDatabase              = "AIRCRAFT_DATABASE"
+	AircraftDatabaseReloadMinutes = "AIRCRAFT_DATABASE_RELOAD_MINUTES"
+
+	RouteProviders        = "ROUTE_PROVIDERS"
+	StandingDataDirectory = "STANDING_DATA_DIRECTORY"
+
+	RouteCrossTrackToleranceKilometers = "ROUTE_CROSS_TRACK_TOLERANCE_KILOMETERS"
+	RouteTrackToleranceDegrees         = "ROUTE_TRACK_TOLERANCE_DEGREES"
+
+	ImageProviders          = "IMAGE_PROVIDERS"
+	ImageURLTemplate        = "IMAGE_URL_TEMPLATE"
+	ImageDirectory          = "IMAGE_DIRECTORY"
+	ThumbnailCacheMegabytes = "THUMBNAIL_CACHE_MEGABYTES"
+	PublicURL               = "PUBLIC_URL"
+
+	GeoNamesFile               = "GEONAMES_FILE"
+	PlaceMaxDistanceKilometers = "PLACE_MAX_DISTANCE_KILOMETERS"
+
+	AirspaceFiles   = "AIRSPACE_FILES"
+	NotifyAirspaces = "NOTIFY_AIRSPACES"
+
+	TerrainDirectory         = "TERRAIN_DIRECTORY"
+	MaxHeightAboveGroundFeet = "MAX_HEIGHT_ABOVE_GROUND_FEET"
+
+	NotifyConditions = "NOTIFY_CONDITIONS"
+	NotifyTelemetry  = "NOTIFY_TELEMETRY"
+
+	AloftBandFeet      = "ALOFT_BAND_FEET"
+	AloftWindowMinutes = "ALOFT_WINDOW_MINUTES"
+
+	MaxPositionAgeSeconds  = "MAX_POSITION_AGE_SECONDS"
+	MaxPositionErrorMeters = "MAX_POSITION_ERROR_METERS"
+	PositionQualityAction  = "POSITION_QUALITY_ACTION"
+
+	// Actions for aircraft whose position is too old or too inaccurate
+	PositionQualityFlag = "flag"
+	PositionQualityDrop = "drop"
+
+	NotificationTimeoutSeconds = "NOTIFICATION_TIMEOUT_SECONDS"
+
+	NotificationMaxAttempts     = "NOTIFICATION_MAX_ATTEMPTS"
+	NotificationRetrySeconds    = "NOTIFICATION_RETRY_SECONDS"
+	NotificationMaxRetrySeconds = "NOTIFICATION_MAX_RETRY_SECONDS"
+
+	NotificationTemplateDirectory = "NOTIFICATION_TEMPLATE_DIRECTORY"
+
+	TelegramBotToken = "TELEGRAM_BOT_TOKEN"
+	TelegramChatID   = "TELEGRAM_CHAT_ID"
+	TelegramAPIURL   = "TELEGRAM_API_URL"
+
+	MQTTBroker          = "MQTT_BROKER"
+	MQTTUsername        = "MQTT_USERNAME"
+	MQTTPassword        = "MQTT_PASSWORD"
+	MQTTClientID        = "MQTT_CLIENT_ID"
+	MQTTTopicPrefix     = "MQTT_TOPIC_PREFIX"
+	MQTTDiscovery       = "MQTT_DISCOVERY"
+	MQTTDiscoveryPrefix = "MQTT_DISCOVERY_PREFIX"
+
+	WebhookURL             = "WEBHOOK_URL"
+	WebhookMethod          = "WEBHOOK_METHOD"
+	WebhookHeaders         = "WEBHOOK_HEADERS"
+	WebhookSecret          = "WEBHOOK_SECRET"
+	WebhookSignatureHeader = "WEBHOOK_SIGNATURE_HEADER"
+	WebhookTimestampHeader = "WEBHOOK_TIMESTAMP_HEADER"
+
+	SMTPHost           = "SMTP_HOST"
+	SMTPPort           = "SMTP_PORT"
+	SMTPUsername       = "SMTP_USERNAME"
+	SMTPPassword       = "SMTP_PASSWORD"
+	SMTPSecurity       = "SMTP_SECURITY"
+	EmailFrom          = "EMAIL_FROM"
+	EmailTo            = "EMAIL_TO"
+	EmailDigestMinutes = "EMAIL_DIGEST_MINUTES"
+
+	// Ways to secure the connection to the SMTP server
+	SMTPSecurityStartTLS = "starttls"
+	SMTPSecurityTLS      = "tls"
+	SMTPSecurityNone     = "none"
 )
 
 // getEnvVariable looks up a specified environment variable, if not set the specified default is used
@@ -130,17 +614,28 @@ func getEnvVariable(key, fallback string) string {
 	return value
 }
 
+// getEnvList returns the trimmed values of a comma separated environment variable, nil if it is not set
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(getEnvVariable(key, ""), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
 // GetConfig attempts to read the configuration via environment variables and uses a default if the environment variable is not set
 func GetConfig() (config Config, err error) {
 	defaultFetchInterval := 60
 
-	config.GotifyToken = getEnvVariable(GotifyToken, "")
+	config.GotifyToken = getEnvList(GotifyToken)
 	config.GotifyURL = getEnvVariable(GotifyURL, "")
-	config.NtfyTopic = getEnvVariable(NtfyTopic, "")
+	config.NtfyTopic = getEnvList(NtfyTopic)
 	config.NtfyServer = getEnvVariable(NtfyServer, "https://ntfy.sh")
 	config.NtfyToken = getEnvVariable(NtfyToken, "")
-	config.SlackWebHookURL = getEnvVariable(SlackWebhookURL, "")
-	config.DiscordWebHookURL = getEnvVariable(DiscordWebhookURL, "")
+	config.SlackWebHookURL = getEnvList(SlackWebhookURL)
+	config.DiscordWebHookURL = getEnvList(DiscordWebhookURL)
 	config.DiscordColorAltitude = getEnvVariable(DiscordColorAltitude, "true")
 	config.MetricsPort = getEnvVariable(MetricsPort, "7070")
 	config.APIPort = getEnvVariable(APIPort, "8085")
@@ -159,6 +654,262 @@ func GetConfig() (config Config, err error) {
 		log.Printf("Fetch interval of %ds detected. You might hit rate limits, consider using the default of %ds instead.", config.FetchInterval, defaultFetchInterval)
 	}
 
+	config.MaxExtrapolationSeconds, err = strconv.Atoi(getEnvVariable(MaxExtrapolationSeconds, "120"))
+	if err != nil {
+		return Config{}, err
+	}
+
+	config.ImageCacheTTLMinutes, err = strconv.Atoi(getEnvVariable(ImageCacheTTLMinutes, "1440"))
+	if err != nil {
+		return Config{}, err
+	}
+
+	config.RouteCacheTTLMinutes, err = strconv.Atoi(getEnvVariable(RouteCacheTTLMinutes, "360"))
+	if err != nil {
+		return Config{}, err
+	}
+
+	config.NegativeCacheTTLMinutes, err = strconv.Atoi(getEnvVariable(NegativeCacheTTLMinutes, "60"))
+	if err != nil {
+		return Config{}, err
+	}
+
+	config.CacheDirectory = getEnvVariable(CacheDirectory, "")
+
+	config.UpstreamTimeoutSeconds, err = strconv.Atoi(getEnvVariable(UpstreamTimeoutSeconds, "10"))
+	if err != nil {
+		return Config{}, err
+	}
+
+	config.PlanespottersRequestsPerMinute, err = strconv.Atoi(getEnvVariable(PlanespottersRequestsPerMinute, "30"))
+	if err != nil {
+		return Config{}, err
+	}
+
+	config.ADSBDBRequestsPerMinute, err = strconv.Atoi(getEnvVariable(ADSBDBRequestsPerMinute, "60"))
+	if err != nil {
+		return Config{}, err
+	}
+
+	config.OpenMeteoRequestsPerMinute, err = strconv.Atoi(getEnvVariable(OpenMeteoRequestsPerMinute, "10"))
+	if err != nil {
+		return Config{}, err
+	}
+
+	config.AviationWeatherRequestsPerMinute, err = strconv.Atoi(getEnvVariable(AviationWeatherRequestsPerMinute, "10"))
+	if err != nil {
+		return Config{}, err
+	}
+
+	enrichmentWorkersStr := getEnvVariable(EnrichmentWorkers, "4")
+	config.EnrichmentWorkers, err = strconv.Atoi(enrichmentWorkersStr)
+	if err != nil || config.EnrichmentWorkers < 1 {
+		log.Printf("Invalid value for ENRICHMENT_WORKERS: %s, using default: 4", enrichmentWorkersStr)
+		config.EnrichmentWorkers = 4
+	}
+
+	config.EnrichmentWaitSeconds, err = strconv.Atoi(getEnvVariable(EnrichmentWaitSeconds, "15"))
+	if err != nil {
+		return Config{}, err
+	}
+
+	config.WeatherProvider = getEnvVariable(WeatherProvider, "open-meteo")
+	config.WeatherFile = getEnvVariable(WeatherFile, "")
+
+	config.WeatherGridDegrees, err = strconv.ParseFloat(getEnvVariable(WeatherGridDegrees, "0.5"), 64)
+	if err != nil {
+		return Config{}, err
+	}
+
+	for _, station := range strings.Split(strings.ToUpper(strings.ReplaceAll(getEnvVariable(MetarStations, ""), " ", "")), ",") {
+		if station != "" {
+			config.MetarStations = append(config.MetarStations, station)
+		}
+	}
+	config.MetarSource = getEnvVariable(MetarSource, "https://aviationweather.gov/api/data/metar?ids={stations}&format=raw")
+	config.TafSource = getEnvVariable(TafSource, "https://aviationweather.gov/api/data/taf?ids={stations}&format=raw")
+
+	config.MetarRefreshMinutes, err = strconv.Atoi(getEnvVariable(MetarRefreshMinutes, "10"))
+	if err != nil {
+		return Config{}, err
+	}
+
+	config.MetarMaxAgeMinutes, err = strconv.Atoi(getEnvVariable(MetarMaxAgeMinutes, "90"))
+	if err != nil {
+		return Config{}, err
+	}
+
+	config.Runways = make(map[string][]string)
+	for _, station := range strings.Fields(getEnvVariable(Runways, "")) {
+		code, runways, found := strings.Cut(station, ":")
+		if !found {
+			return Config{}, fmt.Errorf("invalid value for %s, expected STATION:RUNWAY,RUNWAY but got %q", Runways, station)
+		}
+		config.Runways[strings.ToUpper(code)] = strings.Split(strings.ToUpper(runways), ",")
+	}
+
+	config.SuppressAboveCeiling, err = strconv.ParseBool(getEnvVariable(SuppressAboveCeiling, "false"))
+	if err != nil {
+		return Config{}, err
+	}
+
+	config.AircraftDatabase = getEnvVariable(AircraftDatabase, "")
+
+	config.AircraftDatabaseReloadMinutes, err = strconv.Atoi(getEnvVariable(AircraftDatabaseReloadMinutes, "60"))
+	if err != nil {
+		return Config{}, err
+	}
+
+	config.RouteProviders = strings.Split(strings.ToLower(strings.ReplaceAll(getEnvVariable(RouteProviders, "adsbdb"), " ", "")), ",")
+	config.StandingDataDirectory = getEnvVariable(StandingDataDirectory, "")
+
+	config.RouteCrossTrackToleranceKilometers, err = strconv.Atoi(getEnvVariable(RouteCrossTrackToleranceKilometers, "150"))
+	if err != nil {
+		return Config{}, err
+	}
+
+	config.RouteTrackToleranceDegrees, err = strconv.Atoi(getEnvVariable(RouteTrackToleranceDegrees, "60"))
+	if err != nil {
+		return Config{}, err
+	}
+
+	config.ImageProviders = strings.Split(strings.ToLower(strings.ReplaceAll(getEnvVariable(ImageProviders, "planespotters"), " ", "")), ",")
+	config.ImageURLTemplate = getEnvVariable(ImageURLTemplate, "")
+	config.ImageDirectory = getEnvVariable(ImageDirectory, "")
+	config.ThumbnailCacheMegabytes, err = strconv.Atoi(getEnvVariable(ThumbnailCacheMegabytes, "100"))
+	if err != nil {
+		return Config{}, err
+	}
+	config.PublicURL = strings.TrimSuffix(getEnvVariable(PublicURL, ""), "/")
+
+	config.GeoNamesFile = getEnvVariable(GeoNamesFile, "")
+	config.PlaceMaxDistanceKilometers, err = strconv.Atoi(getEnvVariable(PlaceMaxDistanceKilometers, "50"))
+	if err != nil {
+		return Config{}, err
+	}
+
+	config.AirspaceFiles = getEnvList(AirspaceFiles)
+	for _, airspace := range getEnvList(NotifyAirspaces) {
+		config.NotifyAirspaces = append(config.NotifyAirspaces, strings.ToUpper(airspace))
+	}
+
+	config.TerrainDirectory = getEnvVariable(TerrainDirectory, "")
+	config.MaxHeightAboveGroundFeet, err = strconv.Atoi(getEnvVariable(MaxHeightAboveGroundFeet, "0"))
+	if err != nil {
+		return Config{}, err
+	}
+
+	config.NotifyConditions = getEnvList(NotifyConditions)
+	config.NotifyTelemetry, err = strconv.ParseBool(getEnvVariable(NotifyTelemetry, "false"))
+	if err != nil {
+		return Config{}, err
+	}
+
+	config.AloftBandFeet, err = strconv.Atoi(getEnvVariable(AloftBandFeet, "2000"))
+	if err != nil {
+		return Config{}, err
+	}
+	config.AloftWindowMinutes, err = strconv.Atoi(getEnvVariable(AloftWindowMinutes, "60"))
+	if err != nil {
+		return Config{}, err
+	}
+
+	config.MaxPositionAgeSeconds, err = strconv.Atoi(getEnvVariable(MaxPositionAgeSeconds, "0"))
+	if err != nil {
+		return Config{}, err
+	}
+
+	config.MaxPositionErrorMeters, err = strconv.Atoi(getEnvVariable(MaxPositionErrorMeters, "0"))
+	if err != nil {
+		return Config{}, err
+	}
+
+	config.PositionQualityAction = strings.ToLower(getEnvVariable(PositionQualityAction, PositionQualityFlag))
+	if config.PositionQualityAction != PositionQualityFlag && config.PositionQualityAction != PositionQualityDrop {
+		return Config{}, fmt.Errorf("invalid value for %s, expected %s or %s but got %q",
+			PositionQualityAction, PositionQualityFlag, PositionQualityDrop, config.PositionQualityAction)
+	}
+
+	config.NotificationTimeoutSeconds, err = strconv.Atoi(getEnvVariable(NotificationTimeoutSeconds, "30"))
+	if err != nil {
+		return Config{}, err
+	}
+
+	config.NotificationMaxAttempts, err = strconv.Atoi(getEnvVariable(NotificationMaxAttempts, "8"))
+	if err != nil {
+		return Config{}, err
+	}
+
+	config.NotificationRetrySeconds, err = strconv.Atoi(getEnvVariable(NotificationRetrySeconds, "5"))
+	if err != nil {
+		return Config{}, err
+	}
+
+	config.NotificationMaxRetrySeconds, err = strconv.Atoi(getEnvVariable(NotificationMaxRetrySeconds, "900"))
+	if err != nil {
+		return Config{}, err
+	}
+
+	config.NotificationTemplateDirectory = getEnvVariable(NotificationTemplateDirectory, "")
+
+	config.TelegramBotToken = getEnvVariable(TelegramBotToken, "")
+	config.TelegramChatID = getEnvList(TelegramChatID)
+	for _, chat := range config.TelegramChatID {
+		if _, topic, found := strings.Cut(chat, ":"); found {
+			if _, err := strconv.Atoi(topic); err != nil {
+				return Config{}, fmt.Errorf("invalid value for %s, expected CHAT or CHAT:TOPIC but got %q", TelegramChatID, chat)
+			}
+		}
+	}
+	config.TelegramAPIURL = strings.TrimSuffix(getEnvVariable(TelegramAPIURL, "https://api.telegram.org"), "/")
+
+	config.MQTTBroker = getEnvVariable(MQTTBroker, "")
+	config.MQTTUsername = getEnvVariable(MQTTUsername, "")
+	config.MQTTPassword = getEnvVariable(MQTTPassword, "")
+	config.MQTTClientID = getEnvVariable(MQTTClientID, "jetspotter")
+	config.MQTTTopicPrefix = strings.Trim(getEnvVariable(MQTTTopicPrefix, "jetspotter"), "/")
+	config.MQTTDiscovery, err = strconv.ParseBool(getEnvVariable(MQTTDiscovery, "true"))
+	if err != nil {
+		return Config{}, err
+	}
+	config.MQTTDiscoveryPrefix = strings.Trim(getEnvVariable(MQTTDiscoveryPrefix, "homeassistant"), "/")
+
+	config.WebhookURL = getEnvList(WebhookURL)
+	config.WebhookMethod = strings.ToUpper(getEnvVariable(WebhookMethod, "POST"))
+	config.WebhookHeaders = make(map[string]string)
+	for _, header := range getEnvList(WebhookHeaders) {
+		name, value, found := strings.Cut(header, ":")
+		if !found || strings.TrimSpace(name) == "" {
+			return Config{}, fmt.Errorf("invalid value for %s, expected Name: value but got %q", WebhookHeaders, header)
+		}
+		config.WebhookHeaders[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	config.WebhookSecret = getEnvVariable(WebhookSecret, "")
+	config.WebhookSignatureHeader = getEnvVariable(WebhookSignatureHeader, "X-Jetspotter-Signature")
+	config.WebhookTimestampHeader = getEnvVariable(WebhookTimestampHeader, "X-Jetspotter-Timestamp")
+
+	config.SMTPHost = getEnvVariable(SMTPHost, "")
+	config.SMTPPort, err = strconv.Atoi(getEnvVariable(SMTPPort, "587"))
+	if err != nil {
+		return Config{}, err
+	}
+	config.SMTPUsername = getEnvVariable(SMTPUsername, "")
+	config.SMTPPassword = getEnvVariable(SMTPPassword, "")
+	config.SMTPSecurity = strings.ToLower(getEnvVariable(SMTPSecurity, SMTPSecurityStartTLS))
+	if config.SMTPSecurity != SMTPSecurityStartTLS && config.SMTPSecurity != SMTPSecurityTLS && config.SMTPSecurity != SMTPSecurityNone {
+		return Config{}, fmt.Errorf("invalid value for %s, expected %s, %s or %s but got %q",
+			SMTPSecurity, SMTPSecurityStartTLS, SMTPSecurityTLS, SMTPSecurityNone, config.SMTPSecurity)
+	}
+	config.EmailFrom = getEnvVariable(EmailFrom, "")
+	config.EmailTo = getEnvList(EmailTo)
+	if config.SMTPHost != "" && len(config.EmailTo) > 0 && config.EmailFrom == "" {
+		return Config{}, fmt.Errorf("%s is required to send email notifications", EmailFrom)
+	}
+	config.EmailDigestMinutes, err = strconv.Atoi(getEnvVariable(EmailDigestMinutes, "0"))
+	if err != nil {
+		return Config{}, err
+	}
+
 	config.Location.Lat, err = strconv.ParseFloat(getEnvVariable(LocationLatitude, "51.17348"), 64)
 	if err != nil {
 		return Config{}, err
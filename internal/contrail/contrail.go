@@ -0,0 +1,80 @@
+package contrail
+
+import "math"
+
+// Constants of the Schmidt-Appleman criterion for kerosene, see Schumann (1996), On conditions for contrail formation
+// from aircraft exhausts, Meteorologische Zeitschrift 5, 4-23.
+const (
+	// Emission index of water vapour in kg per kg of fuel
+	emissionIndexWater = 1.25
+	// Specific heat of air at constant pressure in J/(kg K)
+	specificHeat = 1004
+	// Ratio of the molar masses of water vapour and dry air
+	molarMassRatio = 0.622
+	// Specific combustion heat of kerosene in J/kg
+	combustionHeat = 43.2e6
+	// Overall propulsion efficiency of the aircraft, about 0.3 for the engines of current airliners
+	PropulsionEfficiency = 0.3
+
+	kelvin = 273.15
+)
+
+// Prediction is whether a contrail forms and persists
+type Prediction struct {
+	// Threshold temperature in degrees Celsius below which a contrail forms at the humidity
+	ThresholdCelsius float64
+	// Contrails form when the air is colder than the threshold temperature
+	Likely bool
+	// Contrails persist and spread when the air is saturated with respect to ice
+	Persistent bool
+}
+
+// Predict applies the Schmidt-Appleman criterion to the ambient pressure in hPa, temperature in degrees Celsius and
+// relative humidity with respect to water in percent. Use a humidity of 0 if it isn't known, contrails that are likely
+// in dry air are likely at any humidity.
+func Predict(pressureHectopascals, temperatureCelsius, relativeHumidity float64) Prediction {
+	humidity := math.Max(0, math.Min(relativeHumidity/100, 1))
+
+	// Slope of the mixing line of the exhaust and the ambient air in a temperature and vapour pressure diagram, in Pa/K
+	slope := emissionIndexWater * specificHeat * pressureHectopascals * 100 / (molarMassRatio * combustionHeat * (1 - PropulsionEfficiency))
+
+	// Threshold temperature for saturated air, where the mixing line touches the saturation curve over water
+	logSlope := math.Log(slope - 0.053)
+	saturatedThreshold := -46.46 + 9.43*logSlope + 0.720*logSlope*logSlope + kelvin
+
+	// For drier air the mixing line has to start at a lower temperature to reach the saturation curve
+	threshold := saturatedThreshold
+	for i := 0; i < 20; i++ {
+		threshold = saturatedThreshold - (saturationOverWater(saturatedThreshold)-humidity*saturationOverWater(threshold))/slope
+	}
+
+	temperature := temperatureCelsius + kelvin
+	humidityOverIce := humidity * saturationOverWater(temperature) / saturationOverIce(temperature)
+	likely := temperature < threshold
+
+	return Prediction{
+		ThresholdCelsius: math.Round((threshold-kelvin)*10) / 10,
+		Likely:           likely,
+		Persistent:       likely && humidityOverIce >= 1,
+	}
+}
+
+// PressureAt returns the pressure in hPa at a pressure altitude in feet, according to the International Standard Atmosphere.
+func PressureAt(altitudeFeet float64) float64 {
+	meters := altitudeFeet * 0.3048
+	if meters < 11000 {
+		return 1013.25 * math.Pow(1-2.25577e-5*meters, 5.25588)
+	}
+	// Above the tropopause the temperature is constant and the pressure drops exponentially
+	return 226.32 * math.Exp(-(meters-11000)/6341.62)
+}
+
+// saturationOverWater returns the saturation vapour pressure over water in Pa at a temperature in Kelvin, after Sonntag (1990)
+func saturationOverWater(temperature float64) float64 {
+	return 100 * math.Exp(-6096.9385/temperature+16.635794-2.711193e-2*temperature+1.673952e-5*temperature*temperature+2.433502*math.Log(temperature))
+}
+
+// saturationOverIce returns the saturation vapour pressure over ice in Pa at a temperature in Kelvin, after Sonntag (1990)
+func saturationOverIce(temperature float64) float64 {
+	return 100 * math.Exp(-6024.5282/temperature+24.7219+1.0613868e-2*temperature-1.3198825e-5*temperature*temperature-0.49382577*math.Log(temperature))
+}
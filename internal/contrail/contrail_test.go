@@ -0,0 +1,42 @@
+package contrail
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPredict(t *testing.T) {
+	tests := []struct {
+		pressure, temperature, humidity float64
+		threshold                       float64
+		likely, persistent              bool
+	}{
+		// Around 34000ft the threshold is about -51°C in dry air and -42°C in saturated air
+		{250, -55, 0, -51.1, true, false},
+		{250, -48, 0, -51.1, false, false},
+		{250, -48, 100, -42.0, true, true},
+		// Ice supersaturated air makes contrails persist, while drier air makes them disappear quickly
+		{250, -55, 70, -47.9, true, true},
+		{250, -55, 40, -49.8, true, false},
+		// Lower down contrails form in warmer air
+		{500, -30, 100, -34.2, false, false},
+		{500, -36, 100, -34.2, true, true},
+	}
+
+	for _, test := range tests {
+		prediction := Predict(test.pressure, test.temperature, test.humidity)
+		if math.Abs(prediction.ThresholdCelsius-test.threshold) > 0.5 || prediction.Likely != test.likely || prediction.Persistent != test.persistent {
+			t.Errorf("expected threshold %.1f, likely %v and persistent %v at %.0f hPa, %.0f°C and %.0f%%, got %+v",
+				test.threshold, test.likely, test.persistent, test.pressure, test.temperature, test.humidity, prediction)
+		}
+	}
+}
+
+func TestPressureAt(t *testing.T) {
+	tests := map[float64]float64{0: 1013.25, 18000: 506, 34000: 250, 39000: 197}
+	for altitude, expected := range tests {
+		if pressure := PressureAt(altitude); math.Abs(pressure-expected) > 2 {
+			t.Errorf("expected %.0f hPa at %.0fft, got %.1f", expected, altitude, pressure)
+		}
+	}
+}
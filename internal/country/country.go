@@ -0,0 +1,91 @@
+package country
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Country is a country in which aircraft are registered
+type Country struct {
+	Name string
+	// ISO 3166-1 alpha-2 code, empty if the country has none
+	ISO string
+}
+
+// Flag returns the emoji flag of the country, or an empty string if it has no ISO code.
+func (c Country) Flag() string {
+	return Flag(c.ISO)
+}
+
+// Flag returns the emoji flag of a two letter ISO 3166-1 country code.
+func Flag(iso string) string {
+	if len(iso) != 2 {
+		return ""
+	}
+
+	var flag strings.Builder
+	for _, letter := range strings.ToUpper(iso) {
+		if letter < 'A' || letter > 'Z' {
+			return ""
+		}
+		// Regional indicator symbols start at U+1F1E6 for the letter A
+		flag.WriteRune(0x1F1E6 + letter - 'A')
+	}
+	return flag.String()
+}
+
+// FromICAO returns the country to which the block of the 24-bit ICAO address was allocated.
+// Addresses that are not assigned by ICAO, such as TIS-B tracks starting with a tilde, have no country.
+func FromICAO(hex string) (Country, bool) {
+	if strings.HasPrefix(hex, "~") {
+		return Country{}, false
+	}
+
+	address, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return Country{}, false
+	}
+
+	// Blocks are sorted by their start, find the last block that starts before the address
+	i := sort.Search(len(allocations), func(i int) bool {
+		return allocations[i].start > uint32(address)
+	})
+
+	// Smaller blocks may be nested in larger ones, so the closest start isn't always the right block
+	for j := i - 1; j >= 0; j-- {
+		block := allocations[j]
+		if uint32(address) <= block.end {
+			return block.country, block.country.Name != ""
+		}
+	}
+
+	return Country{}, false
+}
+
+// FromRegistration returns the country of the longest nationality mark that the registration starts with.
+func FromRegistration(registration string) (Country, bool) {
+	registration = strings.ToUpper(registration)
+	for length := min(len(registration), longestPrefix); length > 0; length-- {
+		if country, found := registrationPrefixes[registration[:length]]; found {
+			return country, true
+		}
+	}
+	return Country{}, false
+}
+
+// Lookup returns the country of an aircraft based on its ICAO address, or on its registration if the address
+// isn't allocated to a country. Military aircraft often don't have a civil registration, but their address is.
+func Lookup(hex, registration string) (Country, bool) {
+	if country, found := FromICAO(hex); found {
+		return country, true
+	}
+	return FromRegistration(registration)
+}
+
+var longestPrefix = func() (longest int) {
+	for prefix := range registrationPrefixes {
+		longest = max(longest, len(prefix))
+	}
+	return longest
+}()
@@ -0,0 +1,85 @@
+package country
+
+import "testing"
+
+func TestFromICAO(t *testing.T) {
+	tests := []struct {
+		hex  string
+		name string
+		iso  string
+	}{
+		{"44c1e5", "Belgium", "BE"},
+		{"AE1234", "United States", "US"},
+		{"484f6d", "Netherlands", "NL"},
+		{"501c00", "Croatia", "HR"},
+		{"899000", "Taiwan", "TW"},
+	}
+
+	for _, test := range tests {
+		country, found := FromICAO(test.hex)
+		if !found || country.Name != test.name || country.ISO != test.iso {
+			t.Errorf("expected %s to be %s (%s), got %+v", test.hex, test.name, test.iso, country)
+		}
+	}
+
+	for _, hex := range []string{"~44c1e5", "f00000", "zzzzzz", "000001"} {
+		if country, found := FromICAO(hex); found {
+			t.Errorf("expected no country for %s, got %+v", hex, country)
+		}
+	}
+}
+
+func TestFromRegistrationUsesLongestPrefix(t *testing.T) {
+	tests := map[string]string{
+		"CC-BGA":  "Chile",
+		"C-FGKI":  "Canada",
+		"B-HNR":   "Hong Kong",
+		"B-6543":  "China",
+		"FA-101":  "Belgium",
+		"F-GKXA":  "France",
+		"A9C-FA":  "Bahrain",
+		"N12345":  "United States",
+		"9XR-WP":  "Rwanda",
+		"ph-bxa":  "Netherlands",
+		"ZS-SNA":  "South Africa",
+		"Z-WPE":   "Zimbabwe",
+		"RDPL-34": "Laos",
+	}
+
+	for registration, expected := range tests {
+		// Run every lookup a few times, the result must not depend on map iteration order
+		for i := 0; i < 10; i++ {
+			country, found := FromRegistration(registration)
+			if !found || country.Name != expected {
+				t.Fatalf("expected %s to be registered in %s, got %+v", registration, expected, country)
+			}
+		}
+	}
+
+	if _, found := FromRegistration("QQ-ABC"); found {
+		t.Fatal("expected no country for an unknown prefix")
+	}
+}
+
+func TestLookupFallsBackToRegistration(t *testing.T) {
+	country, found := Lookup("~123456", "OO-SNA")
+	if !found || country.ISO != "BE" {
+		t.Fatalf("expected Belgium from the registration, got %+v", country)
+	}
+
+	// The address takes precedence over the registration
+	country, _ = Lookup("ae1234", "FA-101")
+	if country.ISO != "US" {
+		t.Fatalf("expected the United States from the address, got %+v", country)
+	}
+}
+
+func TestFlag(t *testing.T) {
+	if flag := Flag("be"); flag != "🇧🇪" {
+		t.Fatalf("expected the Belgian flag, got %q", flag)
+	}
+
+	if flag := (Country{Name: "Unknown"}).Flag(); flag != "" {
+		t.Fatalf("expected no flag without an ISO code, got %q", flag)
+	}
+}
@@ -0,0 +1,312 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"jetspotter/internal/outbox"
+)
+
+// SMTP is the kind of the outbox messages that are delivered as emails
+const SMTP = "smtp"
+
+// Ways to secure the connection to the SMTP server
+const (
+	// STARTTLS upgrades a plain connection to TLS, usually on port 587
+	STARTTLS = "starttls"
+	// TLS connects with TLS right away, usually on port 465
+	TLS = "tls"
+	// None doesn't encrypt the connection, only for servers on a trusted network
+	None = "none"
+)
+
+// MaxImageBytes is the maximum size of an image that is embedded in an email
+const MaxImageBytes = 2 << 20
+
+// Image is an image that the HTML body refers to as cid:<ContentID>. Only the source is stored in the outbox,
+// the image is loaded from it when the email is sent.
+type Image struct {
+	ContentID string `json:"contentID"`
+	// URL of the image, or the path under which jetspotter serves it
+	Source      string `json:"source"`
+	ContentType string `json:"-"`
+	Data        []byte `json:"-"`
+}
+
+// Message is an email with a plain text and an HTML body, and the images that are shown inline in the HTML body
+type Message struct {
+	From    string   `json:"from"`
+	To      []string `json:"to"`
+	Subject string   `json:"subject"`
+	Text    string   `json:"text"`
+	HTML    string   `json:"html"`
+	Images  []Image  `json:"images,omitempty"`
+}
+
+// Bytes encodes the message as MIME. The plain text and the HTML body are alternatives,
+// the HTML body is related to the inline images.
+func (m Message) Bytes() ([]byte, error) {
+	var buffer bytes.Buffer
+	header := textproto.MIMEHeader{}
+	header.Set("From", m.From)
+	header.Set("To", strings.Join(m.To, ", "))
+	header.Set("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	header.Set("Date", time.Now().Format(time.RFC1123Z))
+	header.Set("Message-ID", messageID(m.From))
+	header.Set("MIME-Version", "1.0")
+
+	alternative := multipart.NewWriter(&buffer)
+	header.Set("Content-Type", "multipart/alternative; boundary="+alternative.Boundary())
+	writeHeader(&buffer, header)
+
+	if err := writeText(alternative, "text/plain; charset=utf-8", m.Text); err != nil {
+		return nil, err
+	}
+
+	var related bytes.Buffer
+	relatedWriter := multipart.NewWriter(&related)
+	if err := writeText(relatedWriter, "text/html; charset=utf-8", m.HTML); err != nil {
+		return nil, err
+	}
+	for _, image := range m.Images {
+		part, err := relatedWriter.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {image.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-ID":                {"<" + image.ContentID + ">"},
+			"Content-Disposition":       {"inline"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeBase64(part, image.Data); err != nil {
+			return nil, err
+		}
+	}
+	if err := relatedWriter.Close(); err != nil {
+		return nil, err
+	}
+
+	part, err := alternative.CreatePart(textproto.MIMEHeader{"Content-Type": {"multipart/related; boundary=" + relatedWriter.Boundary()}})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write(related.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := alternative.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func writeHeader(w io.Writer, header textproto.MIMEHeader) {
+	for _, key := range []string{"From", "To", "Subject", "Date", "Message-ID", "MIME-Version", "Content-Type"} {
+		fmt.Fprintf(w, "%s: %s\r\n", key, header.Get(key))
+	}
+	fmt.Fprint(w, "\r\n")
+}
+
+func writeText(w *multipart.Writer, contentType, text string) error {
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+	encoder := quotedprintable.NewWriter(part)
+	if _, err := encoder.Write([]byte(text)); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// writeBase64 writes the data in lines of 76 characters, the maximum that MIME allows
+func writeBase64(w io.Writer, data []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		if _, err := io.WriteString(w, encoded[:76]+"\r\n"); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err := io.WriteString(w, encoded+"\r\n")
+	return err
+}
+
+func messageID(from string) string {
+	random := make([]byte, 12)
+	_, _ = rand.Read(random)
+	domain := "jetspotter"
+	if _, host, found := strings.Cut(from, "@"); found {
+		domain = strings.TrimSuffix(host, ">")
+	}
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(random), domain)
+}
+
+// Server is the SMTP server that the emails are sent through
+type Server struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	// Security of the connection, STARTTLS, TLS or None
+	Security string
+	// Optional configuration of TLS, for example with the certificate authority of a private server
+	TLSConfig *tls.Config
+}
+
+// Send sends the encoded message from the sender to the recipients
+func (s Server) Send(ctx context.Context, from string, to []string, data []byte) error {
+	address := net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+	tlsConfig := &tls.Config{ServerName: s.Host}
+	if s.TLSConfig != nil {
+		tlsConfig = s.TLSConfig.Clone()
+		if tlsConfig.ServerName == "" {
+			tlsConfig.ServerName = s.Host
+		}
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	if s.Security == TLS {
+		conn = tls.Client(conn, tlsConfig)
+	}
+	// The SMTP client doesn't support contexts, so the deadline of the context applies to the connection
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if s.Security == STARTTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("SMTP server %s doesn't support STARTTLS", address)
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if s.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.Username, s.Password, s.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	for _, recipient := range to {
+		if err := client.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// Envelope is the payload of an outbox message. The message is encoded when it is sent, so that the outbox
+// doesn't store the images. The server is configured when the outbox is set up, so that its credentials
+// aren't stored with the messages either.
+type Envelope struct {
+	From    string   `json:"from"`
+	To      []string `json:"to"`
+	Message Message  `json:"message"`
+}
+
+// ImageLoader loads the data of an image from its source
+type ImageLoader func(ctx context.Context, source string) ([]byte, error)
+
+// Encode loads the images of the message and encodes it as MIME. Images that can't be loaded are left out,
+// the HTML body refers to their source instead if it is a URL.
+func (e Envelope) Encode(ctx context.Context, load ImageLoader) ([]byte, error) {
+	message := e.Message
+	message.Images = nil
+	for _, image := range e.Message.Images {
+		data, err := loadImage(ctx, load, image.Source)
+		if err != nil {
+			log.Printf("Failed to embed image %s in the email: %v", image.Source, err)
+			if strings.HasPrefix(image.Source, "https://") || strings.HasPrefix(image.Source, "http://") {
+				message.HTML = strings.ReplaceAll(message.HTML, `"cid:`+image.ContentID+`"`, `"`+html.EscapeString(image.Source)+`"`)
+			}
+			continue
+		}
+		image.Data = data
+		image.ContentType = http.DetectContentType(data)
+		message.Images = append(message.Images, image)
+	}
+	return message.Bytes()
+}
+
+func loadImage(ctx context.Context, load ImageLoader, source string) ([]byte, error) {
+	data, err := load(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxImageBytes {
+		return nil, fmt.Errorf("image is larger than %d bytes", MaxImageBytes)
+	}
+	if contentType := http.DetectContentType(data); !strings.HasPrefix(contentType, "image/") {
+		return nil, fmt.Errorf("expected an image, got %s", contentType)
+	}
+	return data, nil
+}
+
+// Deliverer delivers the envelopes of outbox messages through the server, the images are loaded with the loader.
+// Permanent SMTP errors, such as an unknown recipient, aren't retried.
+func Deliverer(server Server, load ImageLoader) outbox.Deliverer {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		var envelope Envelope
+		if err := json.Unmarshal(payload, &envelope); err != nil {
+			return outbox.Permanent(err)
+		}
+		data, err := envelope.Encode(ctx, load)
+		if err != nil {
+			return outbox.Permanent(err)
+		}
+
+		err = server.Send(ctx, envelope.From, envelope.To, data)
+		var smtpErr *textproto.Error
+		if errors.As(err, &smtpErr) && smtpErr.Code >= 500 {
+			return outbox.Permanent(err)
+		}
+		return err
+	}
+}
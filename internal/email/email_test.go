@@ -0,0 +1,336 @@
+package email
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"jetspotter/internal/outbox"
+)
+
+// fakeServer is an SMTP server that accepts the emails of one user, over STARTTLS or TLS
+type fakeServer struct {
+	listener net.Listener
+	tls      *tls.Config
+	implicit bool
+
+	mu       sync.Mutex
+	received []received
+	starttls bool
+	auth     string
+}
+
+type received struct {
+	from string
+	to   []string
+	data string
+}
+
+// testCertificate creates a self-signed certificate for 127.0.0.1 and a pool that trusts it
+func testCertificate(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(certificate)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, pool
+}
+
+// startServer starts the fake server, with implicit TLS or with STARTTLS, and returns the server to send through
+func startServer(t *testing.T, implicit bool) (*fakeServer, Server) {
+	certificate, pool := testCertificate(t)
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{certificate}}
+
+	var listener net.Listener
+	var err error
+	if implicit {
+		listener, err = tls.Listen("tcp", "127.0.0.1:0", tlsConfig)
+	} else {
+		listener, err = net.Listen("tcp", "127.0.0.1:0")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		listener.Close()
+	})
+
+	s := &fakeServer{listener: listener, tls: tlsConfig, implicit: implicit}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	security := STARTTLS
+	if implicit {
+		security = TLS
+	}
+	return s, Server{Host: host, Port: portNumber, Username: "jetspotter", Password: "secret", Security: security, TLSConfig: &tls.Config{RootCAs: pool}}
+}
+
+func (s *fakeServer) serve(conn net.Conn) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	secure := s.implicit
+	var current received
+
+	_ = text.PrintfLine("220 localhost ESMTP fake")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		command, argument, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(command) {
+		case "EHLO":
+			extensions := []string{"250-localhost", "250-8BITMIME"}
+			if !secure {
+				extensions = append(extensions, "250-STARTTLS")
+			}
+			extensions = append(extensions, "250 AUTH PLAIN")
+			_ = text.PrintfLine("%s", strings.Join(extensions, "\r\n"))
+		case "STARTTLS":
+			_ = text.PrintfLine("220 Ready to start TLS")
+			tlsConn := tls.Server(conn, s.tls)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn, secure = tlsConn, true
+			text = textproto.NewConn(conn)
+			s.mu.Lock()
+			s.starttls = true
+			s.mu.Unlock()
+		case "AUTH":
+			credentials, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(argument, "PLAIN "))
+			s.mu.Lock()
+			s.auth = string(credentials)
+			s.mu.Unlock()
+			if string(credentials) != "\x00jetspotter\x00secret" {
+				_ = text.PrintfLine("535 Authentication failed")
+				continue
+			}
+			_ = text.PrintfLine("235 Authenticated")
+		case "MAIL":
+			current = received{from: argument}
+			_ = text.PrintfLine("250 OK")
+		case "RCPT":
+			if strings.Contains(argument, "unknown") {
+				_ = text.PrintfLine("550 No such user")
+				continue
+			}
+			current.to = append(current.to, argument)
+			_ = text.PrintfLine("250 OK")
+		case "DATA":
+			_ = text.PrintfLine("354 Go ahead")
+			data, err := io.ReadAll(text.DotReader())
+			if err != nil {
+				return
+			}
+			current.data = string(data)
+			s.mu.Lock()
+			s.received = append(s.received, current)
+			s.mu.Unlock()
+			_ = text.PrintfLine("250 Queued")
+		case "QUIT":
+			_ = text.PrintfLine("221 Bye")
+			return
+		default:
+			_ = text.PrintfLine("502 Not implemented")
+		}
+	}
+}
+
+func testMessage() Message {
+	return Message{
+		From:    "Jetspotter <jetspotter@example.com>",
+		To:      []string{"spotter@example.com"},
+		Subject: "✈️ RCH456 spotted",
+		Text:    "Callsign: RCH456",
+		HTML:    `<p>Callsign: RCH456</p><img src="cid:ae07e0">`,
+		Images:  []Image{{ContentID: "ae07e0", ContentType: "image/jpeg", Data: []byte("\xff\xd8\xff\xe0 thumbnail")}},
+	}
+}
+
+func TestSendOverStartTLSAndTLS(t *testing.T) {
+	for _, implicit := range []bool{false, true} {
+		fake, server := startServer(t, implicit)
+		data, err := testMessage().Bytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err = server.Send(ctx, "jetspotter@example.com", []string{"spotter@example.com", "team@example.com"}, data)
+		cancel()
+		if err != nil {
+			t.Fatalf("failed to send with %s: %v", server.Security, err)
+		}
+
+		fake.mu.Lock()
+		if len(fake.received) != 1 || len(fake.received[0].to) != 2 || fake.auth != "\x00jetspotter\x00secret" || fake.starttls == implicit {
+			t.Errorf("unexpected delivery with %s: %+v", server.Security, fake.received)
+		}
+		fake.mu.Unlock()
+	}
+}
+
+func TestMessageIsMultipartWithInlineImage(t *testing.T) {
+	data, err := testMessage().Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	message, err := mail.ReadMessage(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(message.Header.Get("Subject"))
+	if subject != "✈️ RCH456 spotted" {
+		t.Errorf("unexpected subject %q", subject)
+	}
+
+	// The parts are the plain text and the HTML with its image, the last part is preferred by mail clients
+	mediaType, params, _ := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	if mediaType != "multipart/alternative" {
+		t.Fatalf("expected alternatives, got %s", mediaType)
+	}
+	var types []string
+	alternatives := multipart.NewReader(message.Body, params["boundary"])
+	for {
+		part, err := alternatives.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		mediaType, params, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		types = append(types, mediaType)
+		if mediaType != "multipart/related" {
+			continue
+		}
+
+		related := multipart.NewReader(part, params["boundary"])
+		for {
+			inner, err := related.NextPart()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			types = append(types, inner.Header.Get("Content-Type"))
+			if inner.Header.Get("Content-ID") == "<ae07e0>" {
+				image, _ := io.ReadAll(base64.NewDecoder(base64.StdEncoding, inner))
+				if string(image) != "\xff\xd8\xff\xe0 thumbnail" {
+					t.Errorf("unexpected inline image %q", image)
+				}
+			}
+		}
+	}
+
+	expected := "text/plain,multipart/related,text/html; charset=utf-8,image/jpeg"
+	if strings.Join(types, ",") != expected {
+		t.Errorf("expected parts %s, got %s", expected, strings.Join(types, ","))
+	}
+}
+
+func TestImagesAreLoadedWhenTheEmailIsEncoded(t *testing.T) {
+	message := testMessage()
+	message.HTML = "<img src=\"cid:ae07e0\">\n<img src=\"cid:4ca7b5\">"
+	message.Images = []Image{
+		{ContentID: "ae07e0", Source: "/images/cache/ae07e0.jpg"},
+		{ContentID: "4ca7b5", Source: "https://t.plnspttrs.net/4ca7b5.jpg?size=large&v=1"},
+	}
+
+	// The outbox only stores where the images are
+	payload, _ := json.Marshal(Envelope{From: "jetspotter@example.com", To: []string{"spotter@example.com"}, Message: message})
+	if strings.Contains(string(payload), "thumbnail") {
+		t.Errorf("expected the payload not to contain the images, got %s", payload)
+	}
+
+	var envelope Envelope
+	if err := json.Unmarshal(payload, &envelope); err != nil {
+		t.Fatal(err)
+	}
+	data, err := envelope.Encode(context.Background(), func(ctx context.Context, source string) ([]byte, error) {
+		if source == "/images/cache/ae07e0.jpg" {
+			return []byte("\xff\xd8\xff\xe0 thumbnail"), nil
+		}
+		return nil, errors.New("not found")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Images that can't be loaded are linked instead, quoted-printable encodes = as =3D
+	for _, expected := range []string{"Content-ID: <ae07e0>", "Content-Type: image/jpeg", `src=3D"https://t.plnspttrs.net/4ca7b5.jpg?size=3Dlarge&amp;v=3D1"`} {
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("expected the email to contain %q", expected)
+		}
+	}
+	if strings.Contains(string(data), "Content-ID: <4ca7b5>") {
+		t.Errorf("expected the image that can't be loaded to be left out")
+	}
+}
+
+func TestUnknownRecipientsAreNotRetried(t *testing.T) {
+	_, server := startServer(t, false)
+	payload, _ := json.Marshal(Envelope{From: "jetspotter@example.com", To: []string{"unknown@example.com"}, Message: Message{Subject: "test", Text: "test"}})
+
+	o := outbox.New("", 5, time.Second, time.Minute, 5*time.Second)
+	o.Handle(SMTP, Deliverer(server, func(ctx context.Context, source string) ([]byte, error) {
+		return nil, errors.New("no images")
+	}))
+	if err := o.Send(context.Background(), "Email", SMTP, json.RawMessage(payload)); err == nil {
+		t.Fatalf("expected the unknown recipient to fail")
+	}
+	if len(o.Pending()) != 0 || len(o.DeadLetters()) != 1 {
+		t.Errorf("expected the email to be a dead letter right away")
+	}
+}
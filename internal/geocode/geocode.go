@@ -0,0 +1,171 @@
+package geocode
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Kind of a place
+const (
+	City    = "city"
+	Airport = "airport"
+)
+
+// Place is a populated place or an airport
+type Place struct {
+	Name       string
+	Kind       string
+	CountryISO string
+	Latitude   float64
+	Longitude  float64
+	Population int
+	// Elevation in feet, 0 if it isn't known
+	Elevation int
+}
+
+// Size of the cells of the spatial index in degrees
+const cellDegrees = 0.25
+
+const earthRadiusKilometers = 6371.0
+
+type cell struct {
+	lat, lon int
+}
+
+// Index is a spatial index of places, the places are grouped in cells of a quarter of a degree
+type Index struct {
+	cells map[cell][]Place
+	count int
+}
+
+// NewIndex creates an empty index.
+func NewIndex() *Index {
+	return &Index{cells: make(map[cell][]Place)}
+}
+
+// Add adds a place to the index.
+func (idx *Index) Add(place Place) {
+	c := cellOf(place.Latitude, place.Longitude)
+	idx.cells[c] = append(idx.cells[c], place)
+	idx.count++
+}
+
+// Len returns the number of places in the index.
+func (idx *Index) Len() int {
+	return idx.count
+}
+
+// Nearest returns the place of the kind that is closest to the position, if it is within maxKilometers.
+func (idx *Index) Nearest(lat, lon, maxKilometers float64, kind string) (nearest Place, kilometers float64, found bool) {
+	center := cellOf(lat, lon)
+	latCells := int(math.Ceil(maxKilometers / 111 / cellDegrees))
+	// Cells get narrower towards the poles, so more cells have to be searched along the longitude
+	lonCells := latCells
+	if cos := math.Cos(toRadians(lat)); cos > 0.01 {
+		lonCells = min(int(math.Ceil(maxKilometers/(111*cos)/cellDegrees)), int(360/cellDegrees))
+	}
+
+	kilometers = maxKilometers
+	for dlat := -latCells; dlat <= latCells; dlat++ {
+		for dlon := -lonCells; dlon <= lonCells; dlon++ {
+			for _, place := range idx.cells[cell{center.lat + dlat, wrap(center.lon + dlon)}] {
+				if place.Kind != kind {
+					continue
+				}
+				if d := distance(lat, lon, place.Latitude, place.Longitude); d <= kilometers {
+					nearest, kilometers, found = place, d, true
+				}
+			}
+		}
+	}
+
+	return nearest, kilometers, found
+}
+
+// Geocoder describes positions relative to the nearby places
+type Geocoder struct {
+	Places *Index
+	// Maximum distance in kilometers to the place that a position is described by
+	MaxKilometers float64
+}
+
+// Distances below which a position is described as over the place itself
+const (
+	overCityKilometers    = 3
+	overAirportKilometers = 2
+	maxAirportKilometers  = 30
+)
+
+// Describe returns a description of the position, for example "over Leopoldsburg, 4 km NE of Kleine-Brogel AB".
+// The nearest city and airport are used, an empty string is returned if there are none nearby.
+func (g *Geocoder) Describe(lat, lon float64) string {
+	if g == nil || g.Places == nil {
+		return ""
+	}
+
+	airport, airportKilometers, airportFound := g.Places.Nearest(lat, lon, min(g.MaxKilometers, maxAirportKilometers), Airport)
+	if airportFound && airportKilometers < overAirportKilometers {
+		return "over " + airport.Name
+	}
+
+	var parts []string
+	if city, kilometers, found := g.Places.Nearest(lat, lon, g.MaxKilometers, City); found {
+		parts = append(parts, relativeTo(city, kilometers, overCityKilometers, lat, lon))
+	}
+	if airportFound {
+		parts = append(parts, relativeTo(airport, airportKilometers, overAirportKilometers, lat, lon))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// relativeTo describes a position relative to a place, for example "4 km NE of Kleine-Brogel AB"
+func relativeTo(place Place, kilometers, overKilometers, lat, lon float64) string {
+	if kilometers < overKilometers {
+		return "over " + place.Name
+	}
+
+	direction := compass(bearing(place.Latitude, place.Longitude, lat, lon))
+	return fmt.Sprintf("%d km %s of %s", int(math.Round(kilometers)), direction, place.Name)
+}
+
+// compass returns the eight-point compass direction of a bearing
+func compass(bearing float64) string {
+	directions := []string{"N", "NE", "E", "SE", "S", "SW", "W", "NW"}
+	return directions[int(math.Round(math.Mod(bearing+360, 360)/45))%8]
+}
+
+func cellOf(lat, lon float64) cell {
+	return cell{int(math.Floor(lat / cellDegrees)), wrap(int(math.Floor(lon / cellDegrees)))}
+}
+
+// wrap keeps the longitude of a cell within -180 and 180 degrees
+func wrap(lon int) int {
+	cells := int(360 / cellDegrees)
+	return ((lon+cells/2)%cells+cells)%cells - cells/2
+}
+
+// distance returns the great-circle distance between two positions in kilometers
+func distance(lat1, lon1, lat2, lon2 float64) float64 {
+	lat1, lat2 = toRadians(lat1), toRadians(lat2)
+	dLat := lat2 - lat1
+	dLon := toRadians(lon2 - lon1)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKilometers * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// bearing returns the initial bearing in degrees from the first to the second position
+func bearing(lat1, lon1, lat2, lon2 float64) float64 {
+	lat1, lat2 = toRadians(lat1), toRadians(lat2)
+	dLon := toRadians(lon2 - lon1)
+
+	y := math.Sin(dLon) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dLon)
+	return math.Mod(math.Atan2(y, x)*180/math.Pi+360, 360)
+}
+
+func toRadians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
@@ -0,0 +1,103 @@
+package geocode
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func loadTestdata(t *testing.T) *Index {
+	idx := NewIndex()
+	if err := LoadGeoNames("testdata/BE.txt", idx); err != nil {
+		t.Fatal(err)
+	}
+	return idx
+}
+
+func TestLoadGeoNames(t *testing.T) {
+	idx := loadTestdata(t)
+
+	// Sections of places and hotels are skipped
+	if idx.Len() != 6 {
+		t.Fatalf("expected 6 places, got %d", idx.Len())
+	}
+
+	airport, _, found := idx.Nearest(51.17, 5.47, 10, Airport)
+	if !found || airport.Name != "Kleine Brogel AB" || airport.CountryISO != "BE" {
+		t.Errorf("expected Kleine Brogel AB, got %+v", airport)
+	}
+
+	if _, _, found := idx.Nearest(51.17, 5.47, 10, "hotel"); found {
+		t.Error("expected no places of an unknown kind")
+	}
+}
+
+func TestLoadZippedGeoNames(t *testing.T) {
+	data, err := os.ReadFile("testdata/BE.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "BE.zip")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive := zip.NewWriter(file)
+	readme, _ := archive.Create("readme.txt")
+	readme.Write([]byte("The data format is tab-delimited text in utf8 encoding."))
+	dump, _ := archive.Create("BE.txt")
+	dump.Write(data)
+	archive.Close()
+	file.Close()
+
+	idx := NewIndex()
+	if err := LoadGeoNames(path, idx); err != nil {
+		t.Fatal(err)
+	}
+	if idx.Len() != 6 {
+		t.Fatalf("expected 6 places, got %d", idx.Len())
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	geocoder := &Geocoder{Places: loadTestdata(t), MaxKilometers: 50}
+
+	tests := []struct {
+		lat, lon float64
+		expected string
+	}{
+		{51.12, 5.26, "over Leopoldsburg, 16 km W of Kleine Brogel AB"},
+		{51.20, 5.52, "9 km NE of Peer, 5 km NE of Kleine Brogel AB"},
+		{51.168, 5.468, "over Kleine Brogel AB"},
+		{50.80, 4.20, "12 km SW of Brussels, 23 km SW of Brussels Airport"},
+		{48.85, 2.35, ""},
+	}
+
+	for _, test := range tests {
+		if description := geocoder.Describe(test.lat, test.lon); description != test.expected {
+			t.Errorf("expected %q for %.2f, %.2f, got %q", test.expected, test.lat, test.lon, description)
+		}
+	}
+}
+
+func TestNearestAcrossTheAntimeridian(t *testing.T) {
+	idx := NewIndex()
+	idx.Add(Place{Name: "Suva", Kind: City, Latitude: -18.14, Longitude: 178.44})
+	idx.Add(Place{Name: "Taveuni", Kind: City, Latitude: -16.85, Longitude: -179.97})
+
+	place, _, found := idx.Nearest(-16.9, 179.9, 50, City)
+	if !found || place.Name != "Taveuni" {
+		t.Errorf("expected Taveuni on the other side of the antimeridian, got %+v", place)
+	}
+}
+
+func TestCompass(t *testing.T) {
+	tests := map[float64]string{0: "N", 22: "N", 23: "NE", 180: "S", 290: "W", 338: "N", 359.9: "N"}
+	for bearing, expected := range tests {
+		if direction := compass(bearing); direction != expected {
+			t.Errorf("expected %s for %.1f, got %s", expected, bearing, direction)
+		}
+	}
+}
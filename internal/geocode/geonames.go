@@ -0,0 +1,195 @@
+package geocode
+
+import (
+	"archive/zip"
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Columns of the tab separated GeoNames dumps, see https://download.geonames.org/export/dump/readme.txt
+const (
+	geoNamesName         = 1
+	geoNamesLatitude     = 4
+	geoNamesLongitude    = 5
+	geoNamesFeatureClass = 6
+	geoNamesFeatureCode  = 7
+	geoNamesCountry      = 8
+	geoNamesPopulation   = 14
+	geoNamesColumns      = 19
+)
+
+// Populated places that don't exist anymore or are part of another place
+var skippedFeatureCodes = map[string]bool{
+	"PPLH": true, // historical populated place
+	"PPLQ": true, // abandoned populated place
+	"PPLW": true, // destroyed populated place
+	"PPLX": true, // section of populated place
+}
+
+// Airports and air bases, which are spot features
+var airportFeatureCodes = map[string]bool{
+	"AIRB": true, // airbase
+	"AIRF": true, // airfield
+	"AIRP": true, // airport
+}
+
+// LoadGeoNames adds the populated places and airports of a GeoNames dump to the index, for example cities1000.txt
+// or BE.txt. Dumps compressed as zip, like they are downloaded, or gzip are read as well.
+func LoadGeoNames(path string, idx *Index) error {
+	reader, closer, err := open(path)
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+
+	scanner := bufio.NewScanner(reader)
+	// The alternate names of large cities make some lines very long
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		record := strings.Split(scanner.Text(), "\t")
+		if len(record) < geoNamesColumns {
+			if strings.TrimSpace(scanner.Text()) == "" {
+				continue
+			}
+			return fmt.Errorf("failed to parse %s: line %d has %d columns instead of %d", path, line, len(record), geoNamesColumns)
+		}
+
+		place, ok := geoNamesPlace(record)
+		if !ok {
+			continue
+		}
+		idx.Add(place)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return nil
+}
+
+func geoNamesPlace(record []string) (Place, bool) {
+	class, code := record[geoNamesFeatureClass], record[geoNamesFeatureCode]
+
+	var kind string
+	switch {
+	case class == "P" && !skippedFeatureCodes[code]:
+		kind = City
+	case class == "S" && airportFeatureCodes[code]:
+		kind = Airport
+	default:
+		return Place{}, false
+	}
+
+	lat, err := strconv.ParseFloat(record[geoNamesLatitude], 64)
+	if err != nil {
+		return Place{}, false
+	}
+	lon, err := strconv.ParseFloat(record[geoNamesLongitude], 64)
+	if err != nil {
+		return Place{}, false
+	}
+	population, _ := strconv.Atoi(record[geoNamesPopulation])
+
+	name := record[geoNamesName]
+	if kind == Airport {
+		name = AbbreviateAirport(name)
+	}
+
+	return Place{
+		Name:       name,
+		Kind:       kind,
+		CountryISO: record[geoNamesCountry],
+		Latitude:   lat,
+		Longitude:  lon,
+		Population: population,
+	}, true
+}
+
+// AbbreviateAirport shortens the name of an air base the way it is usually written, for example "Kleine Brogel AB".
+func AbbreviateAirport(name string) string {
+	for _, suffix := range []string{" Air Base", " Airbase", " Air Force Base"} {
+		if trimmed, found := strings.CutSuffix(name, suffix); found {
+			if suffix == " Air Force Base" {
+				return trimmed + " AFB"
+			}
+			return trimmed + " AB"
+		}
+	}
+	return name
+}
+
+// open returns a reader of the dump, which is decompressed if needed
+func open(path string) (io.Reader, io.Closer, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reader := bufio.NewReader(file)
+	magic, _ := reader.Peek(4)
+	switch {
+	case len(magic) >= 2 && magic[0] == 0x1f && magic[1] == 0x8b:
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			file.Close()
+			return nil, nil, fmt.Errorf("failed to decompress %s: %w", path, err)
+		}
+		return gz, closers{gz, file}, nil
+	case len(magic) == 4 && string(magic) == "PK\x03\x04":
+		return openZip(file)
+	default:
+		return reader, file, nil
+	}
+}
+
+// openZip returns a reader of the first text file in the zip archive
+func openZip(file *os.File) (io.Reader, io.Closer, error) {
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	archive, err := zip.NewReader(file, info.Size())
+	if err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("failed to decompress %s: %w", file.Name(), err)
+	}
+
+	for _, f := range archive.File {
+		// The zip files of GeoNames contain a readme next to the dump
+		if !strings.HasSuffix(f.Name, ".txt") || strings.EqualFold(f.Name, "readme.txt") {
+			continue
+		}
+		reader, err := f.Open()
+		if err != nil {
+			file.Close()
+			return nil, nil, err
+		}
+		return reader, closers{reader, file}, nil
+	}
+
+	file.Close()
+	return nil, nil, fmt.Errorf("no GeoNames dump found in %s", file.Name())
+}
+
+// closers closes all of its closers in order
+type closers []io.Closer
+
+func (c closers) Close() error {
+	var err error
+	for _, closer := range c {
+		if e := closer.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
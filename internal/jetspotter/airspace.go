@@ -0,0 +1,99 @@
+package jetspotter
+
+import (
+	"fmt"
+	"log"
+	"path"
+	"strings"
+
+	"jetspotter/internal/configuration"
+	"jetspotter/internal/openair"
+)
+
+// airspaces are the airspaces of which the aircraft are checked to be inside, none until SetupAirspaces is called
+var airspaces []openair.Airspace
+
+// SetupAirspaces loads the airspaces of the OpenAir files. Every airspace to notify about has to match
+// one of the loaded airspaces, otherwise no aircraft would ever be notified about.
+func SetupAirspaces(config configuration.Config) error {
+	if len(config.AirspaceFiles) == 0 {
+		airspaces = nil
+		if len(config.NotifyAirspaces) > 0 {
+			return fmt.Errorf("%s must be set to use %s", configuration.AirspaceFiles, configuration.NotifyAirspaces)
+		}
+		return nil
+	}
+
+	loaded, err := openair.Load(config.AirspaceFiles...)
+	if err != nil {
+		return err
+	}
+	log.Printf("Loaded %d airspaces from %s", len(loaded), strings.Join(config.AirspaceFiles, ", "))
+
+	for _, pattern := range config.NotifyAirspaces {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid airspace %q in %s: %w", pattern, configuration.NotifyAirspaces, err)
+		}
+		found := false
+		for _, airspace := range loaded {
+			found = found || matchesAirspace(airspace.Name, pattern)
+		}
+		if !found {
+			return fmt.Errorf("airspace %q in %s isn't in %s", pattern, configuration.NotifyAirspaces, configuration.AirspaceFiles)
+		}
+	}
+
+	airspaces = loaded
+	return nil
+}
+
+// airspacesAt returns the names of the airspaces that contain the position.
+// Limits above the ground are taken as above sea level if the elevation of the ground isn't known.
+func airspacesAt(lat, lon, altitude float64, ground *float64) (names []string) {
+	var groundFeet float64
+	if ground != nil {
+		groundFeet = *ground
+	}
+
+	for _, airspace := range airspaces {
+		if airspace.ContainsAboveGround(lat, lon, altitude, groundFeet) {
+			names = append(names, airspace.Name)
+		}
+	}
+	return names
+}
+
+// isInAirspace checks whether the aircraft is in one of the airspaces that match the patterns.
+// Patterns are matched against the full name of an airspace and against its first word, like EBR05 for "EBR05 KLEINE-BROGEL".
+func isInAirspace(aircraft Aircraft, patterns []string) bool {
+	for _, name := range aircraft.Airspaces {
+		for _, pattern := range patterns {
+			if matchesAirspace(name, pattern) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchesAirspace checks whether the full name or the first word of an airspace matches the pattern
+func matchesAirspace(name, pattern string) bool {
+	name = strings.ToUpper(name)
+	firstWord, _, _ := strings.Cut(name, " ")
+	if matched, _ := path.Match(pattern, name); matched {
+		return true
+	}
+	matched, _ := path.Match(pattern, firstWord)
+	return matched
+}
+
+// filterAircraftByAirspaces returns the aircraft that are in one of the airspaces that match the patterns.
+func filterAircraftByAirspaces(aircraft []Aircraft, patterns []string) []Aircraft {
+	var filteredAircraft []Aircraft
+	for _, ac := range aircraft {
+		if isInAirspace(ac, patterns) {
+			filteredAircraft = append(filteredAircraft, ac)
+		}
+	}
+	return filteredAircraft
+}
@@ -0,0 +1,71 @@
+package jetspotter
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"jetspotter/internal/configuration"
+
+	"github.com/jftuga/geodist"
+)
+
+func TestAircraftAirspacesAreFiltered(t *testing.T) {
+	err := SetupAirspaces(configuration.Config{AirspaceFiles: []string{"../openair/testdata/belgium.txt"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { airspaces = nil }()
+
+	location := geodist.Coord{Lat: 51.17, Lon: 5.47}
+
+	// Over Kleine-Brogel, low enough to be in the control zone as well
+	low := Aircraft{ICAO: "44c1e5", Latitude: 51.17, Longitude: 5.47, Altitude: 2000}
+	UpdateEstimate(&low, location, time.Now(), 0)
+	updateSurroundings(&low)
+	if !reflect.DeepEqual(low.Airspaces, []string{"EBR05 KLEINE-BROGEL", "EBBL CTR"}) {
+		t.Errorf("unexpected airspaces %v", low.Airspaces)
+	}
+
+	// Above the restricted area
+	high := Aircraft{ICAO: "484506", Latitude: 51.17, Longitude: 5.47, Altitude: 12000}
+	UpdateEstimate(&high, location, time.Now(), 0)
+	updateSurroundings(&high)
+	if len(high.Airspaces) != 0 {
+		t.Errorf("expected no airspaces above FL95, got %v", high.Airspaces)
+	}
+
+	tests := []struct {
+		patterns []string
+		expected int
+	}{
+		{[]string{"EBR*"}, 1},
+		{[]string{"EBBL CTR"}, 1},
+		{[]string{"EBD26"}, 0},
+	}
+	for _, test := range tests {
+		if filtered := filterAircraftByAirspaces([]Aircraft{low, high}, test.patterns); len(filtered) != test.expected {
+			t.Errorf("expected %d aircraft in %v, got %d", test.expected, test.patterns, len(filtered))
+		}
+	}
+}
+
+func TestNotifyAirspacesHaveToBeLoaded(t *testing.T) {
+	defer func() { airspaces = nil }()
+	files := []string{"../openair/testdata/belgium.txt"}
+
+	tests := []struct {
+		config configuration.Config
+		valid  bool
+	}{
+		{configuration.Config{AirspaceFiles: files, NotifyAirspaces: []string{"EBR*", "EBBL CTR"}}, true},
+		{configuration.Config{NotifyAirspaces: []string{"EBR05"}}, false},
+		{configuration.Config{AirspaceFiles: files, NotifyAirspaces: []string{"EBR06"}}, false},
+		{configuration.Config{AirspaceFiles: files, NotifyAirspaces: []string{"EBR["}}, false},
+	}
+	for _, test := range tests {
+		if err := SetupAirspaces(test.config); (err == nil) != test.valid {
+			t.Errorf("expected %v to be valid: %v, got %v", test.config.NotifyAirspaces, test.valid, err)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package jetspotter
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"jetspotter/internal/aloft"
+	"jetspotter/internal/configuration"
+	"jetspotter/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// aloftProfile is the profile of the winds and temperatures aloft that the aircraft report
+var aloftProfile = aloft.NewProfile(2000, time.Hour)
+
+// SetupAloft configures the altitude bands and the window of the winds and temperatures aloft.
+func SetupAloft(config configuration.Config) error {
+	if config.AloftBandFeet <= 0 || config.AloftWindowMinutes <= 0 {
+		return fmt.Errorf("invalid %s or %s, expected positive numbers", configuration.AloftBandFeet, configuration.AloftWindowMinutes)
+	}
+
+	aloftProfile = aloft.NewProfile(config.AloftBandFeet, time.Duration(config.AloftWindowMinutes)*time.Minute)
+	return nil
+}
+
+// recordAloft adds the wind and temperature reports of the airborne aircraft to the profile and exports its levels
+func recordAloft(aircraft []Aircraft, now time.Time) {
+	for _, ac := range aircraft {
+		if ac.OnGround {
+			continue
+		}
+		aloftProfile.Add(aloft.Report{
+			Time:          now,
+			AltitudeFeet:  ac.Altitude,
+			WindDirection: toFloat(ac.WindDirection),
+			WindSpeed:     toFloat(ac.WindSpeed),
+			Temperature:   toFloat(ac.OutsideAirTemperature),
+		})
+	}
+
+	metrics.ResetAloft()
+	for _, level := range aloftProfile.Levels(now) {
+		band := strconv.Itoa(level.LowerFeet)
+		if level.WindDirection != nil {
+			metrics.SetAloftWind(band, *level.WindDirection, *level.WindSpeed, level.WindReports)
+		}
+		if level.Temperature != nil {
+			metrics.SetAloftTemperature(band, *level.Temperature, level.TemperatureReports)
+		}
+	}
+}
+
+func toFloat(value *int) *float64 {
+	if value == nil {
+		return nil
+	}
+	f := float64(*value)
+	return &f
+}
+
+// handleAloftAPI returns the winds and temperatures aloft by altitude band
+func handleAloftAPI(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"bandFeet":      aloftProfile.BandFeet(),
+		"windowMinutes": int(aloftProfile.Window().Minutes()),
+		"levels":        aloftProfile.Levels(time.Now()),
+	})
+}
@@ -0,0 +1,38 @@
+package jetspotter
+
+import (
+	"testing"
+	"time"
+
+	"jetspotter/internal/aloft"
+	"jetspotter/internal/configuration"
+)
+
+func TestAircraftReportsAreRecordedAloft(t *testing.T) {
+	err := SetupAloft(configuration.Config{AloftBandFeet: 5000, AloftWindowMinutes: 30})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { aloftProfile = aloft.NewProfile(2000, time.Hour) }()
+
+	direction, speed, temperature, taxiing := 270, 45, -31, 15
+	recordAloft([]Aircraft{
+		{Altitude: 24000, WindDirection: &direction, WindSpeed: &speed, OutsideAirTemperature: &temperature},
+		// Aircraft on the ground don't report the wind aloft
+		{OnGround: true, WindDirection: &direction, WindSpeed: &taxiing},
+		// Nothing to record without wind or temperature
+		{Altitude: 12000},
+	}, time.Now())
+
+	levels := aloftProfile.Levels(time.Now())
+	if len(levels) != 1 {
+		t.Fatalf("expected 1 level, got %+v", levels)
+	}
+	if levels[0].LowerFeet != 20000 || *levels[0].WindDirection != 270 || *levels[0].WindSpeed != 45 || *levels[0].Temperature != -31 {
+		t.Errorf("unexpected level %+v", levels[0])
+	}
+
+	if err := SetupAloft(configuration.Config{AloftBandFeet: 0, AloftWindowMinutes: 30}); err == nil {
+		t.Error("expected an error for bands without height")
+	}
+}
@@ -4,9 +4,11 @@ import (
 	"log"
 	"net/http"
 	"sync"
+	"time"
 
 	"jetspotter/internal/auth"
 	"jetspotter/internal/configuration"
+	"jetspotter/internal/openair"
 
 	"github.com/gin-gonic/gin"
 )
@@ -39,9 +41,26 @@ func SetupAPI(listenPort string, config configuration.Config) {
 	// API routes
 	router.GET("/api/aircraft", handleAircraftAPI)
 
+	// Airspaces as GeoJSON, so that the web UI can draw them
+	router.GET("/api/airspaces", handleAirspacesAPI)
+
+	// Winds and temperatures aloft that are derived from the reports of the aircraft
+	router.GET("/api/weather/aloft", handleAloftAPI)
+
+	// Cached thumbnails and your own photos
+	router.GET("/images/:source/:name", handleImage)
+
 	// Config API endpoint requires authentication
 	router.GET("/api/config", basicAuth.Middleware(), handleConfigAPI)
 
+	// Notifications that are waiting to be delivered and dead letters, which contain the webhooks and require authentication
+	router.GET("/api/outbox", basicAuth.Middleware(), handleOutboxAPI)
+	router.POST("/api/outbox/replay", basicAuth.Middleware(), handleReplayAPI)
+	router.POST("/api/outbox/replay/:id", basicAuth.Middleware(), handleReplayAPI)
+
+	// Notifications of a channel rendered with its templates, for sample aircraft or with ?live=true for the spotted ones
+	router.GET("/api/notifications/preview/:channel", basicAuth.Middleware(), handlePreviewAPI)
+
 	// Start HTTP server
 	go func() {
 		if err := router.Run(":" + listenPort); err != nil {
@@ -51,10 +70,32 @@ func SetupAPI(listenPort string, config configuration.Config) {
 }
 
 // handleAircraftAPI returns all currently spotted aircraft as JSON
+// The positions of the aircraft are extrapolated to the time of the request.
 func handleAircraftAPI(c *gin.Context) {
 	SpottedAircraft.Lock()
-	defer SpottedAircraft.Unlock()
-	c.JSON(http.StatusOK, SpottedAircraft.Aircraft)
+	aircraft := make([]Aircraft, len(SpottedAircraft.Aircraft))
+	copy(aircraft, SpottedAircraft.Aircraft)
+	SpottedAircraft.Unlock()
+
+	UpdateEstimates(aircraft, Config.Location, time.Now(), time.Duration(Config.MaxExtrapolationSeconds)*time.Second)
+	c.JSON(http.StatusOK, aircraft)
+}
+
+// handleAirspacesAPI returns the loaded airspaces as a GeoJSON feature collection
+func handleAirspacesAPI(c *gin.Context) {
+	c.JSON(http.StatusOK, openair.GeoJSON(airspaces))
+}
+
+// handleImage serves a cached thumbnail or one of your own photos
+func handleImage(c *gin.Context) {
+	path, found := imageFile(c.Param("source"), c.Param("name"))
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Image not found"})
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.File(path)
 }
 
 // handleConfigAPI returns the application configuration as JSON
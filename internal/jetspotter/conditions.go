@@ -0,0 +1,137 @@
+package jetspotter
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"jetspotter/internal/configuration"
+	"jetspotter/internal/metar"
+)
+
+// stationConditions are the conditions at a station according to its METAR or TAF
+type stationConditions struct {
+	report metar.Report
+	// METAR or TAF
+	kind string
+}
+
+// Reported conditions of the configured stations, refreshed every METAR_REFRESH_MINUTES.
+var reportedConditions = struct {
+	sync.Mutex
+	fetched time.Time
+	metars  map[string]metar.Report
+	tafs    map[string]metar.TAF
+}{}
+
+// refreshConditions fetches the METARs and TAFs of the configured stations if they are outdated.
+// When fetching fails, the previously fetched reports are kept.
+func refreshConditions(ctx context.Context, config configuration.Config, now time.Time) {
+	if len(config.MetarStations) == 0 {
+		return
+	}
+
+	reportedConditions.Lock()
+	defer reportedConditions.Unlock()
+
+	if now.Sub(reportedConditions.fetched) < time.Duration(config.MetarRefreshMinutes)*time.Minute {
+		return
+	}
+	reportedConditions.fetched = now
+
+	text, err := metar.Fetch(ctx, config.MetarSource, config.MetarStations)
+	if err != nil {
+		log.Printf("Error getting METARs: %v", err)
+	} else {
+		reportedConditions.metars = parseMetars(text, now)
+	}
+
+	if config.TafSource == "" {
+		return
+	}
+
+	text, err = metar.Fetch(ctx, config.TafSource, config.MetarStations)
+	if err != nil {
+		log.Printf("Error getting TAFs: %v", err)
+	} else {
+		reportedConditions.tafs = parseTafs(text, now)
+	}
+}
+
+// parseMetars returns the most recent METAR of every station in the text
+func parseMetars(text string, now time.Time) map[string]metar.Report {
+	metars := make(map[string]metar.Report)
+	for _, raw := range metar.SplitReports(text) {
+		report, err := metar.Parse(raw, now)
+		if err != nil {
+			log.Printf("Error parsing METAR: %v", err)
+			continue
+		}
+		if existing, ok := metars[report.Station]; !ok || report.Time.After(existing.Time) {
+			metars[report.Station] = report
+		}
+	}
+	return metars
+}
+
+// parseTafs returns the most recent TAF of every station in the text
+func parseTafs(text string, now time.Time) map[string]metar.TAF {
+	tafs := make(map[string]metar.TAF)
+	for _, raw := range metar.SplitReports(text) {
+		taf, err := metar.ParseTAF(raw, now)
+		if err != nil {
+			log.Printf("Error parsing TAF: %v", err)
+			continue
+		}
+		if existing, ok := tafs[taf.Station]; !ok || taf.Issued.After(existing.Issued) {
+			tafs[taf.Station] = taf
+		}
+	}
+	return tafs
+}
+
+// currentConditions returns the conditions of the first configured station that has a recent METAR, or a TAF that is valid now.
+func currentConditions(config configuration.Config, now time.Time) (conditions stationConditions, found bool) {
+	reportedConditions.Lock()
+	defer reportedConditions.Unlock()
+
+	maxAge := time.Duration(config.MetarMaxAgeMinutes) * time.Minute
+	for _, station := range config.MetarStations {
+		if report, ok := reportedConditions.metars[station]; ok && now.Sub(report.Time) <= maxAge {
+			return stationConditions{report: report, kind: "METAR"}, true
+		}
+
+		if taf, ok := reportedConditions.tafs[station]; ok {
+			if report, ok := taf.At(now); ok {
+				return stationConditions{report: report, kind: "TAF"}, true
+			}
+		}
+	}
+
+	return stationConditions{}, false
+}
+
+// applyConditions adds the conditions reported at the station to the aircraft
+func applyConditions(ac *Aircraft, conditions stationConditions, config configuration.Config) {
+	report := conditions.report
+	ac.Conditions = report.Summary()
+	ac.ConditionsSource = report.Station + " " + conditions.kind
+	ac.Ceiling = report.Ceiling()
+	ac.Runway = metar.LikelyRunway(config.Runways[report.Station], report.WindDirection, report.WindSpeed)
+}
+
+// filterAircraftBelowCeiling removes the aircraft that fly above the reported ceiling, they are hidden by the clouds.
+// Aircraft are kept if no ceiling is known.
+func filterAircraftBelowCeiling(aircraft []Aircraft) []Aircraft {
+	var filteredAircraft []Aircraft
+
+	for _, ac := range aircraft {
+		if ac.Ceiling > 0 && ac.Altitude > float64(ac.Ceiling) {
+			continue
+		}
+		filteredAircraft = append(filteredAircraft, ac)
+	}
+
+	return filteredAircraft
+}
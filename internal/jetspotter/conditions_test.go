@@ -0,0 +1,68 @@
+package jetspotter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"jetspotter/internal/configuration"
+)
+
+func TestConditionsFromFile(t *testing.T) {
+	now := time.Now().UTC()
+	dir := t.TempDir()
+
+	// EBBL only has an outdated METAR, so its TAF is used
+	metars := fmt.Sprintf("EBBL %s 24012KT 9999 FEW030 14/11 Q1012\nEHEH %s 24012KT 8000 -RA BKN012 14/11 Q1012\n",
+		now.Add(-3*time.Hour).Format("021504Z"), now.Format("021504Z"))
+	tafs := fmt.Sprintf("TAF EBBL %s %s/%s 24010KT 6000 BKN020\n",
+		now.Format("021504Z"), now.Add(-time.Hour).Format("0215"), now.Add(12*time.Hour).Format("0215"))
+	os.WriteFile(filepath.Join(dir, "metar.txt"), []byte(metars), 0o644)
+	os.WriteFile(filepath.Join(dir, "taf.txt"), []byte(tafs), 0o644)
+
+	config := configuration.Config{
+		MetarStations:      []string{"EBBL", "EHEH"},
+		MetarSource:        filepath.Join(dir, "metar.txt"),
+		TafSource:          filepath.Join(dir, "taf.txt"),
+		MetarMaxAgeMinutes: 90,
+		Runways:            map[string][]string{"EBBL": {"05/23"}},
+	}
+	defer func() { reportedConditions.fetched = time.Time{} }()
+
+	refreshConditions(context.Background(), config, now)
+	conditions, found := currentConditions(config, now)
+	if !found {
+		t.Fatal("expected conditions to be found")
+	}
+
+	ac := Aircraft{Altitude: 3000}
+	applyConditions(&ac, conditions, config)
+
+	if ac.ConditionsSource != "EBBL TAF" || ac.Conditions != "cloud base 2,000 ft, vis 6 km" || ac.Ceiling != 2000 || ac.Runway != "23" {
+		t.Fatalf("unexpected conditions %q from %q with ceiling %d and runway %q", ac.Conditions, ac.ConditionsSource, ac.Ceiling, ac.Runway)
+	}
+
+	// Without a TAF the METAR of the next station is used
+	config.MetarStations = []string{"EHEH"}
+	conditions, _ = currentConditions(config, now)
+	applyConditions(&ac, conditions, config)
+	if ac.ConditionsSource != "EHEH METAR" || ac.Conditions != "cloud base 1,200 ft, vis 8 km, -RA" || ac.Runway != "" {
+		t.Fatalf("unexpected conditions %q from %q", ac.Conditions, ac.ConditionsSource)
+	}
+}
+
+func TestFilterAircraftBelowCeiling(t *testing.T) {
+	aircraft := []Aircraft{
+		{Callsign: "LOW", Altitude: 1000, Ceiling: 1500},
+		{Callsign: "HIGH", Altitude: 5000, Ceiling: 1500},
+		{Callsign: "UNKNOWN", Altitude: 5000},
+	}
+
+	filtered := filterAircraftBelowCeiling(aircraft)
+	if len(filtered) != 2 || filtered[0].Callsign != "LOW" || filtered[1].Callsign != "UNKNOWN" {
+		t.Fatalf("expected the aircraft above the ceiling to be filtered, got %v", filtered)
+	}
+}
@@ -0,0 +1,48 @@
+package jetspotter
+
+import (
+	"jetspotter/internal/aloft"
+	"jetspotter/internal/contrail"
+)
+
+// applyContrail predicts whether the aircraft leaves a contrail with the Schmidt-Appleman criterion.
+// The temperature that the aircraft reports is used first, then the one that other aircraft reported at its altitude
+// and then the forecast. Without a forecast of the humidity, only contrails that form in dry air are predicted.
+func applyContrail(ac *Aircraft, aloftLevels []aloft.Level) {
+	ac.ContrailLikely, ac.ContrailPersistent = false, false
+	if ac.OnGround {
+		return
+	}
+
+	pressure := contrail.PressureAt(ac.Altitude)
+	forecast, forecastFound := ac.forecast.At(pressure)
+
+	temperature, found := optional(ac.OutsideAirTemperature)
+	if !found {
+		temperature, found = aloftTemperature(aloftLevels, ac.Altitude)
+	}
+	if !found && forecastFound {
+		temperature, found = forecast.TemperatureCelsius, true
+	}
+	if !found {
+		return
+	}
+
+	var humidity float64
+	if forecastFound {
+		humidity = forecast.RelativeHumidity
+	}
+
+	prediction := contrail.Predict(pressure, temperature, humidity)
+	ac.ContrailLikely, ac.ContrailPersistent = prediction.Likely, prediction.Persistent
+}
+
+// aloftTemperature returns the temperature that aircraft reported in the altitude band of the altitude
+func aloftTemperature(levels []aloft.Level, altitude float64) (float64, bool) {
+	for _, level := range levels {
+		if level.Temperature != nil && altitude >= float64(level.LowerFeet) && altitude < float64(level.UpperFeet) {
+			return *level.Temperature, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,44 @@
+package jetspotter
+
+import (
+	"testing"
+
+	"jetspotter/internal/aloft"
+	"jetspotter/internal/weather"
+)
+
+func TestContrailIsPredicted(t *testing.T) {
+	cold, warm := -57, -40
+	band := -56.0
+	aloftLevels := []aloft.Level{{LowerFeet: 36000, UpperFeet: 38000, Temperature: &band}}
+	humid := weather.Conditions{Levels: []weather.PressureLevel{
+		{PressureHectopascals: 250, TemperatureCelsius: -52, RelativeHumidity: 90},
+		{PressureHectopascals: 200, TemperatureCelsius: -57, RelativeHumidity: 90},
+	}}
+
+	tests := []struct {
+		name               string
+		aircraft           Aircraft
+		likely, persistent bool
+	}{
+		{"reported temperature", Aircraft{Altitude: 36000, OutsideAirTemperature: &cold}, true, false},
+		{"too warm", Aircraft{Altitude: 36000, OutsideAirTemperature: &warm}, false, false},
+		{"reported by other aircraft", Aircraft{Altitude: 37000}, true, false},
+		{"forecast", Aircraft{Altitude: 36000, forecast: humid}, true, true},
+		{"unknown temperature", Aircraft{Altitude: 30000}, false, false},
+		{"on the ground", Aircraft{OnGround: true, OutsideAirTemperature: &cold}, false, false},
+	}
+
+	for _, test := range tests {
+		applyContrail(&test.aircraft, aloftLevels)
+		if test.aircraft.ContrailLikely != test.likely || test.aircraft.ContrailPersistent != test.persistent {
+			t.Errorf("%s: expected likely %v and persistent %v, got %v and %v", test.name, test.likely, test.persistent,
+				test.aircraft.ContrailLikely, test.aircraft.ContrailPersistent)
+		}
+	}
+
+	filtered := filterAircraftByTypes([]Aircraft{{Type: "B738", ContrailLikely: true}, {Type: "A320"}}, []string{"CONTRAIL"})
+	if len(filtered) != 1 || filtered[0].Type != "B738" {
+		t.Errorf("expected only the aircraft with a contrail, got %+v", filtered)
+	}
+}
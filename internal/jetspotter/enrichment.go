@@ -0,0 +1,330 @@
+package jetspotter
+
+import (
+	"context"
+	"log"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"jetspotter/internal/cache"
+	"jetspotter/internal/configuration"
+	"jetspotter/internal/planespotter"
+)
+
+// Caches for the information that is fetched from upstream APIs to enrich the aircraft.
+// Caching is disabled until SetupCache is called.
+var (
+	imageCache       = cache.New[*planespotter.Image]("images", 0, 0, "")
+	routeCache       = cache.New[*FlightRoute]("routes", 0, 0, "")
+	failedThumbnails = cache.New[bool]("thumbnails", 0, 0, "")
+)
+
+// enrichment is the worker pool that fetches images and flight routes in the background.
+// Nothing is fetched until SetupEnrichment is called.
+var enrichment *enricher
+
+// SetupCache configures the enrichment caches and loads the persisted entries from disk.
+func SetupCache(config configuration.Config) {
+	imagePath, routePath := "", ""
+	if config.CacheDirectory != "" {
+		imagePath = filepath.Join(config.CacheDirectory, "images.json")
+		routePath = filepath.Join(config.CacheDirectory, "routes.json")
+	}
+
+	negativeTTL := time.Duration(config.NegativeCacheTTLMinutes) * time.Minute
+	imageCache = cache.New[*planespotter.Image]("images",
+		time.Duration(config.ImageCacheTTLMinutes)*time.Minute, negativeTTL, imagePath)
+	routeCache = cache.New[*FlightRoute]("routes",
+		time.Duration(config.RouteCacheTTLMinutes)*time.Minute, negativeTTL, routePath)
+	failedThumbnails = cache.New[bool]("thumbnails", 0, negativeTTL, "")
+
+	if err := imageCache.Load(); err != nil {
+		log.Printf("Error loading image cache: %v", err)
+	}
+	if err := routeCache.Load(); err != nil {
+		log.Printf("Error loading flight route cache: %v", err)
+	}
+}
+
+// SaveCache persists the enrichment caches to disk.
+func SaveCache() {
+	if err := imageCache.Save(); err != nil {
+		log.Printf("Error saving image cache: %v", err)
+	}
+	if err := routeCache.Save(); err != nil {
+		log.Printf("Error saving flight route cache: %v", err)
+	}
+}
+
+// enrichmentJobTimeout is how long a single fetch may take, including the waits for the rate limits of the upstreams
+const enrichmentJobTimeout = time.Minute
+
+// SetupEnrichment starts the workers that fetch images and flight routes in parallel.
+// The workers stop when the context is done.
+func SetupEnrichment(ctx context.Context, config configuration.Config) {
+	enrichment.stop()
+	enrichment = newEnricher(ctx, config.EnrichmentWorkers, enrichmentJobTimeout)
+}
+
+// enrichmentJob is a single fetch that is executed by the worker pool
+type enrichmentJob struct {
+	key   string
+	fetch func(ctx context.Context)
+	done  chan struct{}
+}
+
+// enricher is a bounded pool of workers that fetch information about aircraft from upstream APIs.
+// The results are stored in the caches, so that they are used the next time the aircraft is converted.
+type enricher struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	timeout time.Duration
+	jobs    chan *enrichmentJob
+
+	mu       sync.Mutex
+	inflight map[string]*enrichmentJob
+}
+
+// newEnricher starts a pool with the given number of workers, which stop when the context is done.
+// Every fetch is cancelled after timeout.
+func newEnricher(ctx context.Context, workers int, timeout time.Duration) *enricher {
+	ctx, cancel := context.WithCancel(ctx)
+	e := &enricher{
+		ctx:      ctx,
+		cancel:   cancel,
+		timeout:  timeout,
+		jobs:     make(chan *enrichmentJob, 256),
+		inflight: make(map[string]*enrichmentJob),
+	}
+
+	for i := 0; i < workers; i++ {
+		go e.work()
+	}
+
+	return e
+}
+
+func (e *enricher) work() {
+	for {
+		select {
+		case <-e.ctx.Done():
+			return
+		case job := <-e.jobs:
+			e.run(job)
+		}
+	}
+}
+
+// run executes a job with its own deadline and marks it as done
+func (e *enricher) run(job *enrichmentJob) {
+	ctx, cancel := context.WithTimeout(e.ctx, e.timeout)
+	defer cancel()
+	job.fetch(ctx)
+
+	e.mu.Lock()
+	delete(e.inflight, job.key)
+	e.mu.Unlock()
+	close(job.done)
+}
+
+// stop cancels the running fetches and stops the workers, the jobs that are still queued are dropped.
+func (e *enricher) stop() {
+	if e == nil {
+		return
+	}
+	e.cancel()
+}
+
+// inflightJob returns a channel that is closed once the fetch of the key is done, or nil if the key isn't being fetched.
+func (e *enricher) inflightJob(key string) <-chan struct{} {
+	if e == nil {
+		return nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if job, ok := e.inflight[key]; ok {
+		return job.done
+	}
+	return nil
+}
+
+// submit queues a fetch unless the same key is already being fetched.
+// It returns a channel that is closed once the fetch is done, or nil if the queue is full.
+func (e *enricher) submit(key string, fetch func(ctx context.Context)) <-chan struct{} {
+	if e == nil || e.ctx.Err() != nil {
+		return nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if job, ok := e.inflight[key]; ok {
+		return job.done
+	}
+
+	job := &enrichmentJob{key: key, fetch: fetch, done: make(chan struct{})}
+	select {
+	case e.jobs <- job:
+		e.inflight[key] = job
+		return job.done
+	default:
+		// The queue is full, the aircraft will be picked up again during the next cycle
+		return nil
+	}
+}
+
+func imageKey(ICAO string) string {
+	return strings.ToLower(ICAO)
+}
+
+func routeKey(callsign string) string {
+	return strings.ToUpper(callsign)
+}
+
+// hasFlightRoute returns true if a flight route can be looked up for the callsign
+func hasFlightRoute(callsign string) bool {
+	return callsign != "UNKNOWN" && len(callsign) > 3
+}
+
+// fetchImage fetches the image of an aircraft and stores it in the cache.
+func fetchImage(ctx context.Context, ICAO, registration string) {
+	image, err := imageProvider.Image(ctx, ICAO, registration)
+	if err != nil {
+		log.Printf("Error getting image of %s: %v", ICAO, err)
+		return
+	}
+
+	if image == nil {
+		imageCache.SetNotFound(imageKey(ICAO))
+		return
+	}
+
+	imageCache.Set(imageKey(ICAO), image)
+}
+
+// fetchFlightRoute fetches the flight route of a callsign and stores it in the cache.
+func fetchFlightRoute(ctx context.Context, callsign string) {
+	route, err := routeProvider.FlightRoute(ctx, callsign)
+	if err != nil {
+		// Only log errors that aren't rate limit related
+		if !strings.Contains(err.Error(), "API rate limit exceeded") {
+			log.Printf("Error getting flight route information for %s: %v", callsign, err)
+		}
+		return
+	}
+
+	if route == nil {
+		routeCache.SetNotFound(routeKey(callsign))
+		return
+	}
+
+	routeCache.Set(routeKey(callsign), route)
+}
+
+// applyEnrichment adds the cached image and flight route to the aircraft.
+// Whatever is not cached yet is fetched in the background and added during a later cycle.
+func applyEnrichment(ac *Aircraft, extraInfo bool) {
+	applyImageEnrichment(ac)
+	if extraInfo {
+		applyRouteEnrichment(ac)
+	}
+}
+
+// applyImageEnrichment adds the cached image to the aircraft, the image or its thumbnail is fetched in the background
+// if it is not cached yet.
+func applyImageEnrichment(ac *Aircraft) {
+	image, found := imageCache.Get(imageKey(ac.ICAO))
+	if !found {
+		ICAO, registration := ac.ICAO, ac.Registration
+		enrichment.submit("image:"+imageKey(ICAO), func(ctx context.Context) {
+			fetchImage(ctx, ICAO, registration)
+		})
+		return
+	}
+
+	if image != nil && applyImage(ac, image) {
+		ICAO, URL := ac.ICAO, image.ThumbnailLarge.Src
+		enrichment.submit("thumbnail:"+imageKey(ICAO), func(ctx context.Context) {
+			fetchThumbnail(ctx, ICAO, URL)
+		})
+	}
+}
+
+// applyRouteEnrichment adds the cached flight route to the aircraft if it matches the aircraft,
+// the flight route is fetched in the background if it is not cached yet.
+func applyRouteEnrichment(ac *Aircraft) {
+	if !hasFlightRoute(ac.Callsign) {
+		return
+	}
+
+	route, found := routeCache.Get(routeKey(ac.Callsign))
+	if !found {
+		callsign := ac.Callsign
+		enrichment.submit("route:"+routeKey(callsign), func(ctx context.Context) {
+			fetchFlightRoute(ctx, callsign)
+		})
+		return
+	}
+
+	if route == nil {
+		return
+	}
+
+	// Validate that the flight route matches this aircraft
+	if isValidFlightRouteForAircraft(route, *ac) {
+		ac.Airline = mergeAirline(route.Airline, ac.Airline)
+		ac.Origin = route.Origin
+		ac.Destination = route.Destination
+		ac.Stops = route.Stops
+		updateRouteProgress(ac)
+	} else {
+		// Flight route doesn't match this aircraft, log a message for debugging
+		log.Printf("Flight route for callsign %s doesn't match aircraft (ICAO: %s, Reg: %s)",
+			ac.Callsign, ac.ICAO, ac.Registration)
+	}
+}
+
+// waitForEnrichment waits until the images and flight routes of the aircraft that are still being fetched are done,
+// or until the context is done, whatever comes first. Only the information that was missing is added to the aircraft.
+func waitForEnrichment(ctx context.Context, aircraft []Aircraft, extraInfo bool) {
+	type fetch struct {
+		index int
+		route bool
+		done  <-chan struct{}
+	}
+
+	var pending []fetch
+	for i, ac := range aircraft {
+		for _, key := range []string{"image:" + imageKey(ac.ICAO), "thumbnail:" + imageKey(ac.ICAO)} {
+			if done := enrichment.inflightJob(key); done != nil {
+				pending = append(pending, fetch{index: i, done: done})
+			}
+		}
+		if extraInfo && hasFlightRoute(ac.Callsign) {
+			if done := enrichment.inflightJob("route:" + routeKey(ac.Callsign)); done != nil {
+				pending = append(pending, fetch{index: i, route: true, done: done})
+			}
+		}
+	}
+
+	for _, f := range pending {
+		select {
+		case <-f.done:
+		case <-ctx.Done():
+			log.Printf("Timed out waiting for images and flight routes, sending notifications without them")
+			return
+		}
+	}
+
+	for _, f := range pending {
+		if f.route {
+			applyRouteEnrichment(&aircraft[f.index])
+		} else {
+			applyImageEnrichment(&aircraft[f.index])
+		}
+	}
+}
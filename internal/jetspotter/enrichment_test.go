@@ -0,0 +1,90 @@
+package jetspotter
+
+import (
+	"context"
+	"jetspotter/internal/cache"
+	"jetspotter/internal/planespotter"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEnricherDeduplicatesInflightJobs(t *testing.T) {
+	e := newEnricher(context.Background(), 2, time.Second)
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	fetch := func(ctx context.Context) {
+		calls.Add(1)
+		<-release
+	}
+
+	first := e.submit("route:KLM123", fetch)
+	second := e.submit("route:KLM123", fetch)
+	if first == nil || first != second {
+		t.Fatal("expected the same job to be returned for the same key")
+	}
+
+	close(release)
+	select {
+	case <-first:
+	case <-time.After(time.Second):
+		t.Fatal("expected job to finish")
+	}
+
+	if calls.Load() != 1 {
+		t.Fatalf("expected a single fetch, got %d", calls.Load())
+	}
+}
+
+func TestSubmitWithoutEnricher(t *testing.T) {
+	var e *enricher
+	if done := e.submit("image:abc123", func(ctx context.Context) {}); done != nil {
+		t.Fatal("expected nothing to be fetched without an enricher")
+	}
+}
+
+func TestEnricherCancelsFetches(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	e := newEnricher(ctx, 1, 50*time.Millisecond)
+
+	// A fetch that doesn't finish by itself is cancelled at its deadline
+	done := e.submit("image:abc123", func(ctx context.Context) {
+		<-ctx.Done()
+	})
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the fetch to be cancelled after its timeout")
+	}
+
+	cancel()
+	if done := e.submit("image:def456", func(ctx context.Context) {}); done != nil {
+		t.Fatal("expected nothing to be fetched once the enricher is stopped")
+	}
+}
+
+func TestWaitForEnrichmentAddsTheImagesThatWereBeingFetched(t *testing.T) {
+	previous, previousCache := enrichment, imageCache
+	defer func() { enrichment, imageCache = previous, previousCache }()
+	enrichment = newEnricher(context.Background(), 1, time.Second)
+	imageCache = cache.New[*planespotter.Image]("images", time.Hour, time.Hour, "")
+
+	release := make(chan struct{})
+	enrichment.submit("image:abc123", func(ctx context.Context) {
+		<-release
+		imageCache.Set("abc123", &planespotter.Image{Link: "https://www.planespotters.net/photo/1", ThumbnailLarge: planespotter.Thumbnail{Src: "https://t.plnspttrs.net/1.jpg"}})
+	})
+
+	aircraft := []Aircraft{{ICAO: "ABC123"}, {ICAO: "DEF456"}}
+	// The fetch finishes while waitForEnrichment is waiting for it
+	time.AfterFunc(50*time.Millisecond, func() { close(release) })
+	waitForEnrichment(context.Background(), aircraft, false)
+
+	if aircraft[0].ImageThumbnailURL != "https://t.plnspttrs.net/1.jpg" {
+		t.Errorf("expected the image to be added once it was fetched, got %+v", aircraft[0])
+	}
+	if aircraft[1].ImageThumbnailURL != "" {
+		t.Errorf("expected nothing to be added to an aircraft without fetches, got %+v", aircraft[1])
+	}
+}
@@ -0,0 +1,198 @@
+package jetspotter
+
+import (
+	"math"
+	"time"
+
+	"github.com/jftuga/geodist"
+)
+
+const earthRadiusKilometers = 6371.0
+
+// motion contains the kinematics of an aircraft at the time its position was last reported.
+// It is used to extrapolate the position of the aircraft between polls.
+type motion struct {
+	// Last reported position of the aircraft
+	position geodist.Coord
+	// Last reported barometric altitude in feet
+	altitude float64
+	// Ground speed in knots
+	groundSpeed float64
+	// Track in degrees
+	track float64
+	// Rate of change of the track in degrees per second
+	trackRate float64
+	// Barometric vertical rate in feet per minute
+	verticalRate float64
+	// Time at which the position was reported
+	positionTime time.Time
+}
+
+// newMotion returns the kinematics of the raw aircraft, its position was reported seenPos seconds before now.
+func newMotion(aircraft AircraftRaw, altitude float64, now time.Time) motion {
+	return motion{
+		position:     geodist.Coord{Lat: aircraft.Lat, Lon: aircraft.Lon},
+		altitude:     altitude,
+		groundSpeed:  aircraft.GS,
+		track:        aircraft.Track,
+		trackRate:    aircraft.TrackRate,
+		verticalRate: float64(valueOf(verticalRate(aircraft))),
+		positionTime: now.Add(-time.Duration(aircraft.SeenPos * float64(time.Second))),
+	}
+}
+
+// extrapolatePosition dead-reckons a position forward in time using the ground speed in knots,
+// the track in degrees and the track rate in degrees per second.
+// Turning aircraft are integrated in steps of one second so that they follow their turn.
+func extrapolatePosition(position geodist.Coord, groundSpeed, track, trackRate, seconds float64) geodist.Coord {
+	if seconds <= 0 || groundSpeed <= 0 {
+		return position
+	}
+
+	kilometersPerSecond := groundSpeed * 1.852 / 3600
+
+	if trackRate == 0 {
+		return destinationPoint(position, track, kilometersPerSecond*seconds)
+	}
+
+	for elapsed := 0.0; elapsed < seconds; elapsed++ {
+		step := math.Min(1, seconds-elapsed)
+		position = destinationPoint(position, track+trackRate*step/2, kilometersPerSecond*step)
+		track = math.Mod(track+trackRate*step+360, 360)
+	}
+
+	return position
+}
+
+// destinationPoint returns the point reached when travelling the given distance in kilometers
+// along a great circle starting at source with the given initial bearing in degrees.
+func destinationPoint(source geodist.Coord, bearing, kilometers float64) geodist.Coord {
+	angularDistance := kilometers / earthRadiusKilometers
+	lat1 := toRadians(source.Lat)
+	lon1 := toRadians(source.Lon)
+	theta := toRadians(bearing)
+
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(angularDistance) +
+		math.Cos(lat1)*math.Sin(angularDistance)*math.Cos(theta))
+	lon2 := lon1 + math.Atan2(math.Sin(theta)*math.Sin(angularDistance)*math.Cos(lat1),
+		math.Cos(angularDistance)-math.Sin(lat1)*math.Sin(lat2))
+
+	return geodist.Coord{
+		Lat: toDegrees(lat2),
+		Lon: math.Mod(toDegrees(lon2)+540, 360) - 180,
+	}
+}
+
+// closestPointOfApproach returns the distance in kilometers and the number of seconds until the aircraft
+// is closest to the location, assuming it keeps flying in a straight line at its current ground speed.
+// Aircraft that are moving away from the location have their closest point of approach now.
+func closestPointOfApproach(location, position geodist.Coord, groundSpeed, track float64) (kilometers float64, seconds int) {
+	// Use a local flat projection centered on the location, good enough within the scan range
+	x := toRadians(position.Lon-location.Lon) * math.Cos(toRadians(location.Lat)) * earthRadiusKilometers
+	y := toRadians(position.Lat-location.Lat) * earthRadiusKilometers
+
+	speed := groundSpeed * 1.852 / 3600
+	vx := speed * math.Sin(toRadians(track))
+	vy := speed * math.Cos(toRadians(track))
+
+	v2 := vx*vx + vy*vy
+	if v2 == 0 {
+		return math.Hypot(x, y), 0
+	}
+
+	t := -(x*vx + y*vy) / v2
+	if t <= 0 {
+		return math.Hypot(x, y), 0
+	}
+
+	return math.Hypot(x+vx*t, y+vy*t), int(math.Round(t))
+}
+
+// isInbound checks if an aircraft at position flying the given track is heading towards the location.
+func isInbound(location, position geodist.Coord, track, margin float64) bool {
+	// Calculate bearing from aircraft to location (where the aircraft should be pointing if heading to target)
+	bearingFromAircraft := CalculateBearing(position, location)
+
+	// Calculate the absolute difference between the ideal bearing and actual aircraft heading
+	diff := math.Abs(bearingFromAircraft - track)
+
+	// If the difference is greater than 180 degrees, take the shorter angle
+	if diff > 180 {
+		diff = 360 - diff
+	}
+
+	// If the difference is within the margin, the aircraft is heading toward the target
+	return diff <= margin
+}
+
+// UpdateEstimate extrapolates the aircraft to the given time and recalculates every value that depends on its position,
+// such as the distance, bearings, inbound status and closest point of approach.
+// The extrapolation never goes further than maxHorizon past the last reported position, a maxHorizon of 0 disables it.
+func UpdateEstimate(ac *Aircraft, location geodist.Coord, now time.Time, maxHorizon time.Duration) {
+	m := ac.motion
+	if m.positionTime.IsZero() {
+		m = motion{
+			position:     geodist.Coord{Lat: ac.Latitude, Lon: ac.Longitude},
+			altitude:     ac.Altitude,
+			groundSpeed:  float64(ac.Speed),
+			track:        ac.Heading,
+			positionTime: now,
+		}
+	}
+
+	age := now.Sub(m.positionTime)
+	if age < 0 {
+		age = 0
+	}
+	ac.PositionAge = math.Round(age.Seconds()*10) / 10
+
+	horizon := age
+	if horizon > maxHorizon {
+		horizon = maxHorizon
+	}
+	seconds := horizon.Seconds()
+
+	estimate := m.position
+	altitude := m.altitude
+	track := m.track
+	if !ac.OnGround {
+		estimate = extrapolatePosition(m.position, m.groundSpeed, m.track, m.trackRate, seconds)
+		altitude = math.Max(0, m.altitude+m.verticalRate*seconds/60)
+		track = math.Mod(m.track+m.trackRate*seconds+360, 360)
+	}
+
+	ac.EstimatedLatitude = estimate.Lat
+	ac.EstimatedLongitude = estimate.Lon
+	ac.EstimatedAltitude = math.Round(altitude)
+	ac.Distance = CalculateDistance(location, estimate)
+	ac.BearingFromLocation = CalculateBearing(location, estimate)
+	ac.BearingFromAircraft = CalculateBearing(estimate, location)
+
+	// If the aircraft is on the ground, it cannot be inbound
+	if ac.OnGround {
+		ac.Inbound = false
+		ac.CPADistance = ac.Distance
+		ac.CPASeconds = 0
+		return
+	}
+
+	ac.Inbound = isInbound(location, estimate, track, 30)
+	cpaKilometers, cpaSeconds := closestPointOfApproach(location, estimate, m.groundSpeed, track)
+	ac.CPADistance = int(cpaKilometers)
+	ac.CPASeconds = cpaSeconds
+}
+
+// updateSurroundings looks up the nearby places, the terrain and the airspaces at the estimated position of the aircraft.
+// They are looked up once per fetch, the API only updates the estimate itself.
+func updateSurroundings(ac *Aircraft) {
+	ac.Place = geocoder.Describe(ac.EstimatedLatitude, ac.EstimatedLongitude)
+	updateTerrain(ac, ac.EstimatedLatitude, ac.EstimatedLongitude)
+	ac.Airspaces = airspacesAt(ac.EstimatedLatitude, ac.EstimatedLongitude, ac.EstimatedAltitude, ac.GroundElevation)
+}
+
+// UpdateEstimates extrapolates a list of aircraft to the given time, see UpdateEstimate.
+func UpdateEstimates(aircraft []Aircraft, location geodist.Coord, now time.Time, maxHorizon time.Duration) {
+	for i := range aircraft {
+		UpdateEstimate(&aircraft[i], location, now, maxHorizon)
+	}
+}
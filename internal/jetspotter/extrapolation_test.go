@@ -0,0 +1,107 @@
+package jetspotter
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/jftuga/geodist"
+)
+
+func TestExtrapolatePositionStraight(t *testing.T) {
+	start := geodist.Coord{Lat: 51.0, Lon: 5.0}
+
+	// 360 knots due north for 60 seconds is 6 nautical miles, or 0.1 degree of latitude
+	actual := extrapolatePosition(start, 360, 0, 0, 60)
+
+	if math.Abs(actual.Lat-51.1) > 0.001 || math.Abs(actual.Lon-5.0) > 0.0001 {
+		t.Fatalf("expected position near 51.1,5.0 but got %v,%v", actual.Lat, actual.Lon)
+	}
+}
+
+func TestExtrapolatePositionTurning(t *testing.T) {
+	start := geodist.Coord{Lat: 51.0, Lon: 5.0}
+
+	// A full standard rate turn takes 120 seconds and ends where it started
+	actual := extrapolatePosition(start, 200, 90, 3, 120)
+
+	distance := CalculateDistance(start, actual)
+	if distance != 0 {
+		t.Fatalf("expected aircraft to end up at the start of its turn, but it is %dkm away", distance)
+	}
+}
+
+func TestUpdateEstimateIsBoundedByHorizon(t *testing.T) {
+	now := time.Now()
+	location := geodist.Coord{Lat: 51.0, Lon: 5.0}
+
+	ac := Aircraft{}
+	ac.motion = motion{
+		position:     geodist.Coord{Lat: 51.0, Lon: 5.0},
+		altitude:     10000,
+		groundSpeed:  360,
+		track:        0,
+		verticalRate: -1200,
+		positionTime: now.Add(-10 * time.Minute),
+	}
+
+	UpdateEstimate(&ac, location, now, 60*time.Second)
+
+	if ac.PositionAge != 600 {
+		t.Fatalf("expected position age of 600 seconds, got %v", ac.PositionAge)
+	}
+
+	if math.Abs(ac.EstimatedLatitude-51.1) > 0.001 {
+		t.Fatalf("expected extrapolation to stop after 60 seconds, got latitude %v", ac.EstimatedLatitude)
+	}
+
+	if ac.EstimatedAltitude != 8800 {
+		t.Fatalf("expected estimated altitude of 8800ft, got %v", ac.EstimatedAltitude)
+	}
+
+	if ac.Distance != 11 {
+		t.Fatalf("expected distance to be based on the estimated position, got %dkm", ac.Distance)
+	}
+}
+
+func TestUpdateEstimateWithoutExtrapolation(t *testing.T) {
+	now := time.Now()
+	location := geodist.Coord{Lat: 51.0, Lon: 5.0}
+
+	ac := Aircraft{}
+	ac.motion = motion{
+		position:     geodist.Coord{Lat: 51.2, Lon: 5.0},
+		groundSpeed:  360,
+		track:        0,
+		positionTime: now.Add(-30 * time.Second),
+	}
+
+	UpdateEstimate(&ac, location, now, 0)
+
+	if ac.EstimatedLatitude != 51.2 || ac.EstimatedLongitude != 5.0 {
+		t.Fatalf("expected the reported position to be used, got %v,%v", ac.EstimatedLatitude, ac.EstimatedLongitude)
+	}
+}
+
+func TestClosestPointOfApproach(t *testing.T) {
+	location := geodist.Coord{Lat: 51.0, Lon: 5.0}
+
+	// Aircraft 36km west of a point 0.1 degree north of the location, flying east, passes north of the location
+	position := destinationPoint(geodist.Coord{Lat: 51.1, Lon: 5.0}, 270, 36)
+	kilometers, seconds := closestPointOfApproach(location, position, 360, 90)
+
+	if math.Abs(kilometers-11.1) > 0.2 {
+		t.Fatalf("expected closest point of approach at about 11.1km, got %v", kilometers)
+	}
+
+	// 36 kilometers at 360 knots takes about 194 seconds
+	if math.Abs(float64(seconds-194)) > 3 {
+		t.Fatalf("expected closest point of approach in about 194 seconds, got %v", seconds)
+	}
+
+	// Moving away from the location
+	kilometers, seconds = closestPointOfApproach(location, geodist.Coord{Lat: 51.1, Lon: 5.0}, 360, 0)
+	if seconds != 0 || math.Abs(kilometers-11.1) > 0.2 {
+		t.Fatalf("expected closest point of approach to be now at 11.1km, got %vkm in %vs", kilometers, seconds)
+	}
+}
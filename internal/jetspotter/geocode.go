@@ -0,0 +1,46 @@
+package jetspotter
+
+import (
+	"log"
+
+	"jetspotter/internal/configuration"
+	"jetspotter/internal/geocode"
+)
+
+// geocoder describes the positions of the aircraft relative to nearby places.
+// Positions aren't described until SetupGeocoder is called with a GeoNames dump.
+var geocoder *geocode.Geocoder
+
+// SetupGeocoder loads the places of the GeoNames dump, and the airports of the standing data if it is configured.
+func SetupGeocoder(config configuration.Config) error {
+	if config.GeoNamesFile == "" {
+		geocoder = nil
+		return nil
+	}
+
+	places := geocode.NewIndex()
+	if err := geocode.LoadGeoNames(config.GeoNamesFile, places); err != nil {
+		return err
+	}
+	log.Printf("Loaded %d places from %s", places.Len(), config.GeoNamesFile)
+
+	if config.StandingDataDirectory != "" {
+		db, err := loadStandingData(config.StandingDataDirectory)
+		if err != nil {
+			return err
+		}
+		for _, airport := range db.Airports() {
+			places.Add(geocode.Place{
+				Name:       geocode.AbbreviateAirport(airport.Name),
+				Kind:       geocode.Airport,
+				CountryISO: airport.CountryISO,
+				Latitude:   airport.Latitude,
+				Longitude:  airport.Longitude,
+				Elevation:  airport.AltitudeFeet,
+			})
+		}
+	}
+
+	geocoder = &geocode.Geocoder{Places: places, MaxKilometers: float64(config.PlaceMaxDistanceKilometers)}
+	return nil
+}
@@ -0,0 +1,46 @@
+package jetspotter
+
+import (
+	"testing"
+	"time"
+
+	"jetspotter/internal/configuration"
+
+	"github.com/jftuga/geodist"
+)
+
+func TestAircraftPlaceIsDescribed(t *testing.T) {
+	err := SetupGeocoder(configuration.Config{
+		GeoNamesFile:               "../geocode/testdata/BE.txt",
+		StandingDataDirectory:      "../standingdata/testdata",
+		PlaceMaxDistanceKilometers: 50,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { geocoder = nil }()
+
+	// Close to Leopoldsburg, the estimated position is used
+	ac := Aircraft{Latitude: 51.12, Longitude: 5.26}
+	UpdateEstimate(&ac, geodist.Coord{Lat: 51.17, Lon: 5.47}, time.Now(), 0)
+	updateSurroundings(&ac)
+	if ac.Place != "over Leopoldsburg, 16 km W of Kleine Brogel AB" {
+		t.Errorf("unexpected place %q", ac.Place)
+	}
+
+	// The place is looked up once per fetch, updating the estimate for the API doesn't look it up again
+	moved := ac
+	moved.Latitude, moved.Longitude = 51.48, -0.40
+	UpdateEstimate(&moved, geodist.Coord{Lat: 51.17, Lon: 5.47}, time.Now(), 0)
+	if moved.Place != ac.Place {
+		t.Errorf("expected the place not to be looked up again, got %q", moved.Place)
+	}
+
+	// Airports of the standing data are used as well
+	ac = Aircraft{Latitude: 51.48, Longitude: -0.40}
+	UpdateEstimate(&ac, geodist.Coord{Lat: 51.17, Lon: 5.47}, time.Now(), 0)
+	updateSurroundings(&ac)
+	if ac.Place != "4 km E of Heathrow" {
+		t.Errorf("unexpected place %q", ac.Place)
+	}
+}
@@ -0,0 +1,340 @@
+package jetspotter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"jetspotter/internal/configuration"
+	"jetspotter/internal/planespotter"
+	"jetspotter/internal/thumbnail"
+	"jetspotter/internal/upstream"
+)
+
+// ImageProvider finds an image of an aircraft.
+// A nil image without an error means that the provider has no image of the aircraft.
+type ImageProvider interface {
+	Image(ctx context.Context, ICAO, registration string) (*planespotter.Image, error)
+}
+
+// imageProvider finds the images of the aircraft, planespotters.net is used unless configured otherwise.
+var imageProvider ImageProvider = planespotterImages{}
+
+// thumbnails caches the thumbnails of the images on disk, nil if the thumbnail cache is disabled.
+var thumbnails *thumbnail.Cache
+
+// localImageDirectory is the directory with your own photos that are served under /images/local
+var localImageDirectory string
+
+// publicURL is the URL on which the API can be reached by the notification services
+var publicURL string
+
+// Paths under which the API serves images
+const (
+	cachedImagesPath = "/images/cache/"
+	localImagesPath  = "/images/local/"
+)
+
+// Maximum size of a single thumbnail that is downloaded
+const maxThumbnailBytes = 10 << 20
+
+// extensions of the photos in the local image directory
+var imageExtensions = []string{".jpg", ".jpeg", ".png", ".webp", ".gif"}
+
+// SetupImages configures the chain of image providers that are asked in order and the thumbnail cache.
+func SetupImages(config configuration.Config) error {
+	var chain imageChain
+	for _, name := range config.ImageProviders {
+		switch name {
+		case "planespotters":
+			chain = append(chain, planespotterImages{})
+		case "template":
+			if config.ImageURLTemplate == "" {
+				return fmt.Errorf("%s must be set to use the template image provider", configuration.ImageURLTemplate)
+			}
+			chain = append(chain, templateImages{template: config.ImageURLTemplate})
+		case "local":
+			if config.ImageDirectory == "" {
+				return fmt.Errorf("%s must be set to use the local image provider", configuration.ImageDirectory)
+			}
+			if _, err := os.ReadDir(config.ImageDirectory); err != nil {
+				return err
+			}
+			localImageDirectory = config.ImageDirectory
+			chain = append(chain, localImages{dir: config.ImageDirectory})
+		default:
+			return fmt.Errorf("unknown image provider %q", name)
+		}
+	}
+
+	imageProvider = chain
+	publicURL = config.PublicURL
+
+	if config.CacheDirectory == "" || config.ThumbnailCacheMegabytes <= 0 {
+		return nil
+	}
+
+	cache, err := thumbnail.New(filepath.Join(config.CacheDirectory, "thumbnails"), int64(config.ThumbnailCacheMegabytes)<<20)
+	if err != nil {
+		return err
+	}
+	thumbnails = cache
+	return nil
+}
+
+// imageChain asks every provider in order until one of them has an image of the aircraft
+type imageChain []ImageProvider
+
+func (c imageChain) Image(ctx context.Context, ICAO, registration string) (*planespotter.Image, error) {
+	var errs []error
+	for _, provider := range c {
+		image, err := provider.Image(ctx, ICAO, registration)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if image != nil {
+			return image, nil
+		}
+	}
+
+	// Only report that there is no image if every provider could be asked
+	return nil, errors.Join(errs...)
+}
+
+// planespotterImages looks up images with the planespotters.net API
+type planespotterImages struct{}
+
+func (planespotterImages) Image(ctx context.Context, ICAO, registration string) (*planespotter.Image, error) {
+	return planespotter.LookupImage(ctx, ICAO, registration)
+}
+
+// templateImages uses the image at a URL that is built from the ICAO address and the registration of the aircraft.
+// A HEAD request is sent to check whether the image exists.
+type templateImages struct {
+	template string
+}
+
+func (t templateImages) Image(ctx context.Context, ICAO, registration string) (*planespotter.Image, error) {
+	if strings.Contains(t.template, "{registration}") && registration == "" {
+		return nil, nil
+	}
+
+	URL := strings.NewReplacer(
+		"{icao}", url.PathEscape(strings.ToLower(ICAO)),
+		"{registration}", url.PathEscape(registration),
+	).Replace(t.template)
+
+	req, err := http.NewRequest(http.MethodHead, URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	res, err := upstream.Images.Do(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed for %s: %w", URL, err)
+	}
+	res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusGone:
+		return nil, nil
+	case res.StatusCode < 200 || res.StatusCode > 299:
+		return nil, fmt.Errorf("unexpected status %d for %s", res.StatusCode, URL)
+	}
+
+	return &planespotter.Image{
+		ID:             URL,
+		Thumbnail:      planespotter.Thumbnail{Src: URL},
+		ThumbnailLarge: planespotter.Thumbnail{Src: URL},
+		Link:           URL,
+	}, nil
+}
+
+// localImages finds your own photos in a directory, named after the registration or the ICAO address of the aircraft
+type localImages struct {
+	dir string
+}
+
+func (l localImages) Image(ctx context.Context, ICAO, registration string) (*planespotter.Image, error) {
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Photos named after the registration are preferred, with or without the hyphen
+	var names []string
+	if registration != "" {
+		names = append(names, strings.ToLower(registration), strings.ToLower(strings.ReplaceAll(registration, "-", "")))
+	}
+	names = append(names, strings.ToLower(ICAO))
+
+	for _, name := range names {
+		for _, entry := range entries {
+			if !entry.Type().IsRegular() || !isImageFile(entry.Name()) {
+				continue
+			}
+
+			if strings.ToLower(strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))) == name {
+				path := localImagesPath + url.PathEscape(entry.Name())
+				return &planespotter.Image{
+					ID:             "local:" + entry.Name(),
+					Thumbnail:      planespotter.Thumbnail{Src: path},
+					ThumbnailLarge: planespotter.Thumbnail{Src: path},
+					Link:           path,
+				}, nil
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+func isImageFile(name string) bool {
+	if strings.HasPrefix(name, ".") {
+		return false
+	}
+
+	extension := strings.ToLower(filepath.Ext(name))
+	for _, e := range imageExtensions {
+		if extension == e {
+			return true
+		}
+	}
+	return false
+}
+
+// applyImage adds the image to the aircraft. Images that are served by jetspotter itself are only used in
+// notifications if the public URL is known. It returns whether the thumbnail still has to be cached.
+func applyImage(ac *Aircraft, image *planespotter.Image) bool {
+	src := image.ThumbnailLarge.Src
+	ac.ImageThumbnailURL = absoluteImageURL(src)
+	ac.ImageURL = absoluteImageURL(image.Link)
+	ac.Photographer = image.Photographer
+	ac.ImageThumbnailPath = ""
+
+	if strings.HasPrefix(src, "/") {
+		ac.ImageThumbnailPath = src
+		return false
+	}
+
+	if thumbnails == nil || src == "" {
+		return false
+	}
+
+	name, found := thumbnails.Get(imageKey(ac.ICAO))
+	if !found {
+		_, failed := failedThumbnails.Get(imageKey(ac.ICAO))
+		return !failed
+	}
+
+	ac.ImageThumbnailPath = cachedImagesPath + name
+	if publicURL != "" {
+		ac.ImageThumbnailURL = publicURL + ac.ImageThumbnailPath
+	}
+	return false
+}
+
+// absoluteImageURL returns the URL of an image that may be served by jetspotter itself,
+// or an empty string if the image is served by jetspotter but the public URL isn't known.
+func absoluteImageURL(URL string) string {
+	if !strings.HasPrefix(URL, "/") {
+		return URL
+	}
+	if publicURL == "" {
+		return ""
+	}
+	return publicURL + URL
+}
+
+// fetchThumbnail downloads the thumbnail of an aircraft and stores it in the thumbnail cache.
+// Thumbnails that can't be downloaded aren't tried again until the negative cache entry expires.
+func fetchThumbnail(ctx context.Context, ICAO, URL string) {
+	if err := downloadThumbnail(ctx, ICAO, URL); err != nil {
+		log.Printf("Error caching thumbnail of %s: %v", ICAO, err)
+		failedThumbnails.SetNotFound(imageKey(ICAO))
+	}
+}
+
+func downloadThumbnail(ctx context.Context, ICAO, URL string) error {
+	res, err := upstream.Images.Get(ctx, URL, nil)
+	if err != nil {
+		return fmt.Errorf("request failed for %s: %w", URL, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d for %s", res.StatusCode, URL)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(res.Body, maxThumbnailBytes))
+	if err != nil {
+		return err
+	}
+
+	_, err = thumbnails.Store(imageKey(ICAO), data)
+	return err
+}
+
+// imageFile returns the path on disk of an image that is served under /images/<source>/<name>.
+func imageFile(source, name string) (string, bool) {
+	switch source {
+	case "cache":
+		if thumbnails == nil {
+			return "", false
+		}
+		return thumbnails.Path(name)
+	case "local":
+		// Only serve the photos in the directory itself
+		if localImageDirectory == "" || name != filepath.Base(name) || !isImageFile(name) {
+			return "", false
+		}
+		path := filepath.Join(localImageDirectory, name)
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			return "", false
+		}
+		return path, true
+	default:
+		return "", false
+	}
+}
+
+// LoadImage loads the image at the URL, or from disk if it is served by jetspotter itself under the path
+func LoadImage(ctx context.Context, source string) ([]byte, error) {
+	if strings.HasPrefix(source, "/images/") {
+		kind, name, _ := strings.Cut(strings.TrimPrefix(source, "/images/"), "/")
+		name, err := url.PathUnescape(name)
+		if err != nil {
+			return nil, err
+		}
+		path, found := imageFile(kind, name)
+		if !found {
+			return nil, fmt.Errorf("image %s not found", source)
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return io.ReadAll(io.LimitReader(file, maxThumbnailBytes))
+	}
+
+	res, err := upstream.Images.Get(ctx, source, nil)
+	if err != nil {
+		return nil, fmt.Errorf("request failed for %s: %w", source, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d for %s", res.StatusCode, source)
+	}
+	return io.ReadAll(io.LimitReader(res.Body, maxThumbnailBytes))
+}
@@ -0,0 +1,122 @@
+package jetspotter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"jetspotter/internal/configuration"
+	"jetspotter/internal/planespotter"
+)
+
+func resetImages() {
+	imageProvider = planespotterImages{}
+	thumbnails = nil
+	localImageDirectory = ""
+	publicURL = ""
+}
+
+func TestImageChainPrefersLocalPhotos(t *testing.T) {
+	photos := t.TempDir()
+	os.WriteFile(filepath.Join(photos, "OOSNA.JPG"), []byte("\xff\xd8\xff\xe0"), 0o644)
+	os.WriteFile(filepath.Join(photos, "44c1e5.png"), []byte("\x89PNG"), 0o644)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead || r.URL.Path != "/photos/ph-bxa.jpg" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	err := SetupImages(configuration.Config{
+		ImageProviders:   []string{"local", "template"},
+		ImageDirectory:   photos,
+		ImageURLTemplate: server.URL + "/photos/{registration}.jpg",
+		PublicURL:        "https://jetspotter.example.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resetImages()
+
+	tests := []struct {
+		ICAO, registration, thumbnail string
+	}{
+		{"44c1e5", "OO-SNA", "https://jetspotter.example.com/images/local/OOSNA.JPG"},
+		{"44c1e5", "", "https://jetspotter.example.com/images/local/44c1e5.png"},
+		{"484506", "ph-bxa", server.URL + "/photos/ph-bxa.jpg"},
+	}
+
+	for _, test := range tests {
+		image, err := imageProvider.Image(context.Background(), test.ICAO, test.registration)
+		if err != nil || image == nil {
+			t.Fatalf("expected an image of %s, got %v", test.registration, err)
+		}
+
+		var ac Aircraft
+		applyImage(&ac, image)
+		if ac.ImageThumbnailURL != test.thumbnail {
+			t.Errorf("expected thumbnail %s for %s, got %s", test.thumbnail, test.registration, ac.ImageThumbnailURL)
+		}
+	}
+
+	image, err := imageProvider.Image(context.Background(), "484507", "PH-BXB")
+	if image != nil || err != nil {
+		t.Errorf("expected no image for an aircraft without photos, got %+v and %v", image, err)
+	}
+
+	if path, found := imageFile("local", "OOSNA.JPG"); !found || path != filepath.Join(photos, "OOSNA.JPG") {
+		t.Errorf("expected the local photo to be served, got %q", path)
+	}
+	for _, name := range []string{"../OOSNA.JPG", "missing.jpg", ".hidden.jpg"} {
+		if _, found := imageFile("local", name); found {
+			t.Errorf("expected %s not to be served", name)
+		}
+	}
+}
+
+func TestThumbnailsAreCachedOnDisk(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("\xff\xd8\xff\xe0 thumbnail"))
+	}))
+	defer server.Close()
+
+	err := SetupImages(configuration.Config{
+		ImageProviders:          []string{"planespotters"},
+		CacheDirectory:          t.TempDir(),
+		ThumbnailCacheMegabytes: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resetImages()
+
+	image := &planespotter.Image{ThumbnailLarge: planespotter.Thumbnail{Src: server.URL + "/44c1e5.jpg"}, Link: server.URL}
+	ac := Aircraft{ICAO: "44C1E5"}
+	if !applyImage(&ac, image) {
+		t.Fatal("expected the thumbnail to be downloaded")
+	}
+
+	fetchThumbnail(context.Background(), ac.ICAO, image.ThumbnailLarge.Src)
+
+	if applyImage(&ac, image) {
+		t.Fatal("expected the thumbnail to be cached")
+	}
+	if ac.ImageThumbnailPath != "/images/cache/44c1e5.jpg" || ac.ImageThumbnailURL != server.URL+"/44c1e5.jpg" {
+		t.Errorf("expected the cached thumbnail to be served without replacing the upstream URL, got %s and %s",
+			ac.ImageThumbnailPath, ac.ImageThumbnailURL)
+	}
+
+	if _, found := imageFile("cache", "44c1e5.jpg"); !found {
+		t.Error("expected the cached thumbnail to be served")
+	}
+
+	// Notifications read the cached thumbnail from disk, even if the upstream is gone
+	server.Close()
+	if data, err := LoadImage(context.Background(), ac.ImageThumbnailPath); err != nil || string(data) != "\xff\xd8\xff\xe0 thumbnail" {
+		t.Errorf("expected the cached thumbnail to be loaded, got %q (%v)", data, err)
+	}
+}
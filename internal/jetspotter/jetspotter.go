@@ -1,6 +1,7 @@
 package jetspotter
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -14,9 +15,9 @@ import (
 	"time"
 
 	"jetspotter/internal/configuration"
+	"jetspotter/internal/country"
 	"jetspotter/internal/metrics"
-	"jetspotter/internal/planespotter"
-	"jetspotter/internal/weather"
+	"jetspotter/internal/upstream"
 
 	"github.com/jftuga/geodist"
 )
@@ -96,18 +97,13 @@ func convertKilometersToNauticalMiles(kilometers float64) int {
 }
 
 // getFlightRoute returns the extra information about an aircraft.
-func getFlightRoute(callsign string) (route *FlightRoute, err error) {
+func getFlightRoute(ctx context.Context, callsign string) (route *FlightRoute, err error) {
 	endpoint, err := url.JoinPath(baseInfoURL, "callsign", callsign)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build URL: %w", err)
 	}
 
-	req, err := http.NewRequest("GET", endpoint, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	res, err := http.DefaultClient.Do(req)
+	res, err := upstream.ADSBDB.Get(ctx, endpoint, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute request: %w", err)
 	}
@@ -152,12 +148,7 @@ func getAllAircrafRawInRange(location geodist.Coord, maxRangeKilometers int) (ai
 		return nil, err
 	}
 
-	req, err := http.NewRequest("GET", endpoint, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	res, err := http.DefaultClient.Do(req)
+	res, err := upstream.ADSB.Get(context.Background(), endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -242,16 +233,33 @@ func HandleAircraft(alreadySpottedAircraft *[]Aircraft, config configuration.Con
 		return nil, err
 	}
 
+	// Every aircraft in range contributes its wind and temperature reports to the winds aloft
+	recordAloft(allAircraftInRange, time.Now())
+
+	// Positions that are too old or too inaccurate are flagged or dropped before their range is checked
+	allAircraftInRange = checkPositionQuality(allAircraftInRange, config)
+
 	// Filter the aircraft by the notification range (MaxRangeKilometers)
 	var aircraftInNotificationRange []Aircraft
 	for _, ac := range allAircraftInRange {
-		// Check if the aircraft is within the notification range
-		distance := CalculateDistance(config.Location, geodist.Coord{Lat: ac.Latitude, Lon: ac.Longitude})
-		if distance <= config.MaxRangeKilometers {
+		// Flagged positions are kept in the output, but their range can't be trusted
+		if ac.PositionStale || ac.PositionInaccurate {
+			continue
+		}
+		// Check if the estimated position of the aircraft is within the notification range
+		if ac.Distance <= config.MaxRangeKilometers {
 			aircraftInNotificationRange = append(aircraftInNotificationRange, ac)
 		}
 	}
 
+	// The MQTT summary counts the aircraft in the notification range, whether or not they are in one of the airspaces
+	nearby := aircraftInNotificationRange
+
+	// Aircraft are only spotted once they enter one of the airspaces, and spotted again when they re-enter it
+	if len(config.NotifyAirspaces) > 0 {
+		aircraftInNotificationRange = filterAircraftByAirspaces(aircraftInNotificationRange, config.NotifyAirspaces)
+	}
+
 	// For notifications, we need to track what's new and filter by type
 	var newlySpottedAircraft []Aircraft
 	newlySpottedAircraft, *alreadySpottedAircraft = validateAircraft(aircraftInNotificationRange, alreadySpottedAircraft)
@@ -264,13 +272,36 @@ func HandleAircraft(alreadySpottedAircraft *[]Aircraft, config configuration.Con
 		filteredForNotifications = filterAircraftByAltitude(filteredForNotifications, config.MaxAltitudeFeet)
 	}
 
+	// Apply the height above ground filter if configured
+	if config.MaxHeightAboveGroundFeet > 0 {
+		filteredForNotifications = filterAircraftByHeightAboveGround(filteredForNotifications, config.MaxHeightAboveGroundFeet)
+	}
+
+	// Only notify about aircraft that meet all of the conditions on their telemetry
+	if len(notifyConditions) > 0 {
+		filteredForNotifications = filterAircraftByConditions(filteredForNotifications, notifyConditions)
+	}
+
+	// Filter out aircraft that are hidden above the reported ceiling
+	if config.SuppressAboveCeiling {
+		filteredForNotifications = filterAircraftBelowCeiling(filteredForNotifications)
+	}
+
 	handleMetrics(newlySpottedAircraft)
 
-	// Update the SpottedAircraft for the API to access - always store ALL aircraft in range
+	// Update the SpottedAircraft for the API to access - always store ALL aircraft in range.
+	// Images and flight routes that are still being fetched are added during a later cycle.
 	SpottedAircraft.Lock()
 	SpottedAircraft.Aircraft = allAircraftInRange
 	SpottedAircraft.Unlock()
 
+	publishMQTT(filteredForNotifications, allAircraftInRange, nearby, time.Now())
+
+	// Give the aircraft that we notify about a chance to get their image and flight route, without waiting forever
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.EnrichmentWaitSeconds)*time.Second)
+	waitForEnrichment(ctx, filteredForNotifications, true)
+	cancel()
+
 	// Return the filtered aircraft for notifications
 	return filteredForNotifications, nil
 }
@@ -290,6 +321,10 @@ func isAircraftDesired(aircraft Aircraft, aircraftType string) bool {
 		return true
 	}
 
+	if aircraftType == "CONTRAIL" && aircraft.ContrailLikely {
+		return true
+	}
+
 	if aircraft.Type == aircraftType || aircraftType == "ALL" {
 		return true
 	}
@@ -335,28 +370,6 @@ func ConvertFeetToMeters(feet float64) int {
 	return int(feet * 0.3048)
 }
 
-// getCloudCoverage gets the coverage percentage of the clouds at a given altitude block
-// Altitude blocks are one of the following
-// low    -> 0m up to 3000m
-// medium -> 3000m up to 8000m
-// high   -> above 8000m
-func getCloudCoverage(weather weather.Data, altitudeInFeet float64) (cloudCoveragePercentage int) {
-
-	altitudeInMeters := ConvertFeetToMeters(altitudeInFeet)
-	hourUTC := (time.Now().Hour())
-
-	switch {
-	case altitudeInMeters < 3000:
-		return weather.Hourly.CloudcoverLow[hourUTC]
-	case altitudeInMeters >= 3000 && altitudeInMeters < 8000:
-		return getHighestValue(weather.Hourly.CloudcoverLow[hourUTC], weather.Hourly.CloudcoverMid[hourUTC])
-	default:
-		return getHighestValue(weather.Hourly.CloudcoverLow[hourUTC],
-			weather.Hourly.CloudcoverMid[hourUTC],
-			weather.Hourly.CloudcoverHigh[hourUTC])
-	}
-}
-
 func getHighestValue(numbers ...int) (highest int) {
 	highest = 0
 	for _, v := range numbers {
@@ -391,14 +404,23 @@ func validateFields(aircraft AircraftRaw) AircraftRaw {
 // Specify true for extraInfo to include additional information such as flight route, origin, and destination.
 func ConvertToAircraft(aircraftRaw []AircraftRaw, config configuration.Config, extraInfo bool) (aircraft []Aircraft, err error) {
 	var ac Aircraft
-	cloudForecastSucceeded := true
-	weather, err := weather.GetCloudForecast(config.Location)
-	if err != nil {
-		log.Printf("Error getting cloud forecast: %v\n", err)
-		cloudForecastSucceeded = false
-	}
+	var weatherErr error
+
+	// The reported conditions are refreshed every few minutes, only the conversion that refreshes them waits for them.
+	// The weather forecast is looked up in the background.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.UpstreamTimeoutSeconds)*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	refreshConditions(ctx, config, now)
+	conditions, conditionsFound := currentConditions(config, now)
+	maxHorizon := time.Duration(config.MaxExtrapolationSeconds) * time.Second
+	aloftLevels := aloftProfile.Levels(now)
 
 	for _, acRaw := range aircraftRaw {
+		// Fill in what the ADS-B data is missing from the offline aircraft database
+		acRaw = applyRegistry(acRaw)
+
 		// Skip aircraft without registration
 		if acRaw.Registration == "" {
 			continue
@@ -408,8 +430,6 @@ func ConvertToAircraft(aircraftRaw []AircraftRaw, config configuration.Config, e
 		ac = Aircraft{} // Reset to empty to prevent data leakage between iterations
 
 		acRaw = validateFields(acRaw)
-		aircraftLocation := geodist.Coord{Lat: acRaw.Lat, Lon: acRaw.Lon}
-		image := planespotter.GetImageFromAPI(acRaw.ICAO, acRaw.Registration)
 
 		if acRaw.AltBaro == "groundft" || acRaw.AltBaro == "ground" {
 			acRaw.AltBaro = float64(0)
@@ -420,68 +440,65 @@ func ConvertToAircraft(aircraftRaw []AircraftRaw, config configuration.Config, e
 		ac.Latitude = acRaw.Lat
 		ac.Longitude = acRaw.Lon
 		ac.Description = acRaw.Desc
-		ac.Distance = CalculateDistance(config.Location, aircraftLocation)
 		ac.Speed = int(acRaw.GS)
 		ac.Registration = acRaw.Registration
-		ac.Country = GetCountryFromRegistration(acRaw.Registration)
+		countryName, c := getCountry(acRaw)
+		ac.Country = countryName
+		ac.CountryISO = c.ISO
+		ac.CountryFlag = c.Flag()
+		ac.Operator = acRaw.OwnOp
+		ac.Year = acRaw.Year
 		ac.Type = acRaw.PlaneType
 		ac.ICAO = acRaw.ICAO
 		ac.Heading = acRaw.Track
 		ac.TrackerURL = fmt.Sprintf("https://globe.airplanes.live/?icao=%v&SiteLat=%f&SiteLon=%f&zoom=11&enableLabels&extendedLabels=1&noIsolation",
 			acRaw.ICAO, config.Location.Lat, config.Location.Lon)
-		if cloudForecastSucceeded {
-			ac.CloudCoverage = getCloudCoverage(*weather, ac.Altitude)
+		if err := applyWeather(ctx, &ac, config, now); err != nil && weatherErr == nil {
+			weatherErr = err
 		}
-		ac.BearingFromLocation = CalculateBearing(config.Location, aircraftLocation)
-		ac.BearingFromAircraft = CalculateBearing(aircraftLocation, config.Location)
-		if image != nil {
-			ac.ImageThumbnailURL = image.ThumbnailLarge.Src
-			ac.ImageURL = image.Link
-			ac.Photographer = image.Photographer
+		if conditionsFound {
+			applyConditions(&ac, conditions, config)
 		}
 		ac.Military = isAircraftMilitary(acRaw)
-		// Check if aircraft is on the ground (altitude is 0)
-		ac.OnGround = ac.Altitude == 0
-		// If the aircraft is on the ground, it cannot be inbound
-		if ac.OnGround {
-			ac.Inbound = false
-		} else {
-			ac.Inbound = IsAircraftInbound(config.Location, acRaw, 30)
-		}
-
-		if extraInfo && acRaw.Callsign != "UNKNOWN" && len(acRaw.Callsign) > 3 {
-			// Fetch flight route information
-			flightRoute, err := getFlightRoute(acRaw.Callsign)
-			if err == nil && flightRoute != nil {
-				// Validate that the flight route matches this aircraft
-				if isValidFlightRouteForAircraft(flightRoute, acRaw) {
-					ac.Airline = flightRoute.Airline
-					ac.Origin = flightRoute.Origin
-					ac.Destination = flightRoute.Destination
-				} else {
-					// Flight route doesn't match this aircraft, log a message for debugging
-					log.Printf("Flight route for callsign %s doesn't match aircraft (ICAO: %s, Reg: %s)",
-						acRaw.Callsign, acRaw.ICAO, acRaw.Registration)
-				}
-			} else if err != nil && !strings.Contains(err.Error(), "API rate limit exceeded") {
-				// Only log errors that aren't rate limit related
-				log.Printf("Error getting flight route information for %s: %v", acRaw.Callsign, err)
-			}
-		}
+		applyOperator(&ac)
+		applyTelemetry(&ac, acRaw)
+		applyPositionSource(&ac, acRaw)
+		// Check if aircraft is on the ground, based on its altitude and the elevation of the ground
+		ac.OnGround = isOnGround(ac)
+		applyContrail(&ac, aloftLevels)
+		// Distance, bearings, inbound status and closest point of approach are based on the estimated position
+		ac.motion = newMotion(acRaw, ac.Altitude, now)
+		UpdateEstimate(&ac, config.Location, now, maxHorizon)
+		updateSurroundings(&ac)
+
+		// Images and flight routes that are not cached yet are fetched in the background
+		applyEnrichment(&ac, extraInfo)
 
 		aircraft = append(aircraft, ac)
 	}
+
+	if weatherErr != nil {
+		log.Printf("Error getting weather forecast: %v\n", weatherErr)
+	}
+
 	return aircraft, nil
 }
 
-// isValidFlightRouteForAircraft validates whether a flight route likely matches the given aircraft
-// by checking for ICAO/IATA code consistency, registration consistency, or other relevant factors
-func isValidFlightRouteForAircraft(route *FlightRoute, aircraft AircraftRaw) bool {
+// isValidFlightRouteForAircraft validates whether a flight route likely matches the given aircraft.
+// The callsign has to be consistent with the route, and the aircraft has to fly along the path of the route.
+func isValidFlightRouteForAircraft(route *FlightRoute, aircraft Aircraft) bool {
 	// Skip validation if we're missing essential data
 	if route == nil {
 		return false
 	}
 
+	return isCallsignConsistentWithRoute(route, aircraft) && isRouteGeometricallyPlausible(route, aircraft)
+}
+
+// isCallsignConsistentWithRoute validates whether a flight route likely matches the given aircraft
+// by checking for ICAO/IATA code consistency, registration consistency, or other relevant factors
+func isCallsignConsistentWithRoute(route *FlightRoute, aircraft Aircraft) bool {
+
 	// Basic validation: ensure origin and destination aren't empty
 	if route.Origin.Name == "" || route.Destination.Name == "" {
 		return false
@@ -518,19 +535,7 @@ func isValidFlightRouteForAircraft(route *FlightRoute, aircraft AircraftRaw) boo
 
 // IsAircraftInbound checks if the aircraft is inbound to the target location
 func IsAircraftInbound(location geodist.Coord, aircraft AircraftRaw, margin float64) bool {
-	// Calculate bearing from aircraft to location (where the aircraft should be pointing if heading to target)
-	bearingFromAircraft := CalculateBearing(geodist.Coord{Lat: aircraft.Lat, Lon: aircraft.Lon}, location)
-
-	// Calculate the absolute difference between the ideal bearing and actual aircraft heading
-	diff := math.Abs(bearingFromAircraft - aircraft.Track)
-
-	// If the difference is greater than 180 degrees, take the shorter angle
-	if diff > 180 {
-		diff = 360 - diff
-	}
-
-	// If the difference is within the margin, the aircraft is heading toward the target
-	return diff <= margin
+	return isInbound(location, geodist.Coord{Lat: aircraft.Lat, Lon: aircraft.Lon}, aircraft.Track, margin)
 }
 
 // SortByDistance sorts a slice of aircraft to show the closest aircraft first
@@ -565,119 +570,25 @@ func toDegrees(rad float64) float64 {
 	return rad * (180 / math.Pi)
 }
 
-// GetCountryFromRegistration determines the country of an aircraft based on the registration prefix
+// GetCountryFromRegistration determines the country of an aircraft based on the longest matching registration prefix
 func GetCountryFromRegistration(registration string) string {
 	if registration == "" {
 		return "Unknown"
 	}
 
-	// Map common registration prefixes to countries
-	registryMap := map[string]string{
-		// A few examples from each region
-		// North America
-		"87-": "United States", // Lockheed C-5M
-		"08-": "United States", // Boeing C-17A
-		"N":   "United States",
-		"C-F": "Canada",
-		"C-G": "Canada",
-		"C-I": "Canada",
-		"XA":  "Mexico",
-		"XB":  "Mexico",
-		"XC":  "Mexico",
-
-		// Europe
-		"G-":  "United Kingdom",
-		"F-":  "France",
-		"D-":  "Germany",
-		"I-":  "Italy",
-		"EC-": "Spain",
-		"CS-": "Portugal",
-		"EI-": "Ireland",
-		"OE-": "Austria",
-		"4L-": "Georgia",
-		"TF-": "Iceland",
-		"LZ-": "Bulgaria",
-		"T7-": "San Marino",
-		"HB-": "Switzerland",
-		"ER-": "Moldova",
-		"9A-": "Croatia",
-		"ES-": "Estonia",
-		"OO-": "Belgium",
-		"FA-": "Belgium", // Belgian F16
-		"FB-": "Belgium", // Belgian F16
-		"CT-": "Belgium", // Belgian A400
-		"ST-": "Belgium", // Belgian AERMACCHI
-		"RN-": "Belgium", // Belgian NH-90
-		"YL-": "Latvia",
-		"PH-": "Netherlands",
-		"L-":  "Netherlands", // Dutch PILATUS
-		"SE-": "Sweden",
-		"OY-": "Denmark",
-		"OH-": "Finland",
-		"LN-": "Norway",
-		"YR-": "Romania",
-		"SP-": "Poland",
-		"OK-": "Czech Republic",
-		"HA-": "Hungary",
-		"YU-": "Serbia",
-		"LY-": "Lithuania",
-		"UR-": "Ukraine",
-		"SX-": "Greece",
-		"LX-": "Luxembourg",
-		"9H-": "Malta",
-
-		// Asia & Oceania
-		"JA":  "Japan",
-		"B-":  "China",
-		"VT-": "India",
-		"HS-": "Thailand",
-		"PK-": "Indonesia",
-		"9M-": "Malaysia",
-		"9V-": "Singapore",
-		"VH-": "Australia",
-		"ZK-": "New Zealand",
-
-		// South America
-		"LV-": "Argentina",
-		"PP-": "Brazil",
-		"PR-": "Brazil",
-		"PT-": "Brazil",
-		"PU-": "Brazil",
-		"CC-": "Chile",
-		"HK-": "Colombia",
-
-		// Middle East & Africa
-		"4X-":  "Israel",
-		"TC-":  "Turkey",
-		"SU-":  "Egypt",
-		"ZS-":  "South Africa",
-		"ET-":  "Ethiopia",
-		"5N-":  "Nigeria",
-		"7T-":  "Algeria",
-		"TS-":  "Tunisia",
-		"CN-":  "Morocco",
-		"HZ-":  "Saudi Arabia",
-		"A6-":  "United Arab Emirates",
-		"A7-":  "Qatar",
-		"A9C-": "Bahrain",
-		"EP-":  "Iran",
-		"YI-":  "Iraq",
-		"9K-":  "Kuwait",
-		"4K-":  "Azerbaijan",
-		"9XR-": "Rwanda",
-	}
-
-	// Check for matching prefixes
-	for prefix, country := range registryMap {
-		if strings.HasPrefix(registration, prefix) {
-			return country
-		}
+	if c, found := country.FromRegistration(registration); found {
+		return c.Name
 	}
 
 	// If no match found, return the first character as a basic hint
-	if len(registration) > 0 {
-		return "Unknown (" + string(registration[0]) + ")"
-	}
+	return "Unknown (" + string(registration[0]) + ")"
+}
 
-	return "Unknown"
+// getCountry determines the country of an aircraft based on the block of its ICAO address,
+// or on its registration prefix if the address isn't allocated to a country.
+func getCountry(aircraft AircraftRaw) (name string, c country.Country) {
+	if c, found := country.Lookup(aircraft.ICAO, aircraft.Registration); found {
+		return c.Name, c
+	}
+	return GetCountryFromRegistration(aircraft.Registration), country.Country{}
 }
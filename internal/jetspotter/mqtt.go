@@ -0,0 +1,245 @@
+package jetspotter
+
+import (
+	"errors"
+	"log"
+	"regexp"
+	"sync"
+	"time"
+
+	"jetspotter/internal/configuration"
+	"jetspotter/internal/mqtt"
+	"jetspotter/internal/version"
+)
+
+// mqttClient publishes the aircraft to the MQTT broker, nil if no broker is configured
+var mqttClient *mqtt.Client
+
+// maxPendingSpotted is the maximum number of spotted aircraft that wait to be published, the oldest ones are dropped
+const maxPendingSpotted = 100
+
+// mqttUpdates wakes up the publisher when there is a new update in mqttPending
+var mqttUpdates = make(chan struct{}, 1)
+
+// mqttPending is the update that hasn't been published yet. The aircraft in range are replaced by newer ones,
+// so the publisher never falls behind the fetch loop when the broker is slow.
+var mqttPending struct {
+	sync.Mutex
+	spotted []Aircraft
+	inRange []Aircraft
+	nearby  []Aircraft
+	now     time.Time
+}
+
+// mqttState is what has been published, to clear the aircraft that left and to remember the last spotted aircraft
+var mqttState struct {
+	published     map[string]bool
+	lastSpotted   *Aircraft
+	lastSpottedAt *time.Time
+}
+
+// mqttSummary is published to the summary topic, the Home Assistant sensors read their state from it.
+// It summarizes the aircraft within the range that triggers notifications (MAX_RANGE_KILOMETERS).
+type mqttSummary struct {
+	// Number of aircraft in range
+	Count int `json:"count"`
+	// Number of military aircraft in range
+	Military      int        `json:"military"`
+	Nearest       *Aircraft  `json:"nearest"`
+	LastSpotted   *Aircraft  `json:"lastSpotted"`
+	LastSpottedAt *time.Time `json:"lastSpottedAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+// mqttEntities are the Home Assistant sensors that are created with MQTT discovery
+var mqttEntities = []mqtt.Entity{
+	{
+		Component:     "sensor",
+		ID:            "aircraft_in_range",
+		Name:          "Aircraft in range",
+		StateTopic:    "summary",
+		ValueTemplate: "{{ value_json.count }}",
+		Unit:          "aircraft",
+		StateClass:    "measurement",
+		Icon:          "mdi:airplane",
+	},
+	{
+		Component:     "sensor",
+		ID:            "military_aircraft_in_range",
+		Name:          "Military aircraft in range",
+		StateTopic:    "summary",
+		ValueTemplate: "{{ value_json.military }}",
+		Unit:          "aircraft",
+		StateClass:    "measurement",
+		Icon:          "mdi:shield-airplane",
+	},
+	{
+		Component:          "sensor",
+		ID:                 "nearest_aircraft",
+		Name:               "Nearest aircraft",
+		StateTopic:         "summary",
+		ValueTemplate:      "{{ value_json.nearest.Callsign if value_json.nearest else None }}",
+		AttributesTemplate: "{{ value_json.nearest | tojson if value_json.nearest else '{}' }}",
+		Icon:               "mdi:airplane-marker",
+	},
+	{
+		Component:     "sensor",
+		ID:            "nearest_aircraft_distance",
+		Name:          "Nearest aircraft distance",
+		StateTopic:    "summary",
+		ValueTemplate: "{{ value_json.nearest.Distance if value_json.nearest else None }}",
+		Unit:          "km",
+		DeviceClass:   "distance",
+		StateClass:    "measurement",
+	},
+	{
+		Component:          "sensor",
+		ID:                 "last_spotted",
+		Name:               "Last spotted",
+		StateTopic:         "summary",
+		ValueTemplate:      "{{ value_json.lastSpotted.Callsign if value_json.lastSpotted else None }}",
+		AttributesTemplate: "{{ value_json.lastSpotted | tojson if value_json.lastSpotted else '{}' }}",
+		Icon:               "mdi:airplane-clock",
+	},
+	{
+		Component:     "sensor",
+		ID:            "last_spotted_at",
+		Name:          "Last spotted at",
+		StateTopic:    "summary",
+		ValueTemplate: "{{ value_json.lastSpottedAt or None }}",
+		DeviceClass:   "timestamp",
+	},
+}
+
+var invalidDeviceID = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// SetupMQTT connects to the MQTT broker and creates the Home Assistant sensors, if a broker is configured.
+func SetupMQTT(config configuration.Config) error {
+	if config.MQTTBroker == "" {
+		return nil
+	}
+
+	options := mqtt.Options{
+		Broker:      config.MQTTBroker,
+		ClientID:    config.MQTTClientID,
+		Username:    config.MQTTUsername,
+		Password:    config.MQTTPassword,
+		TopicPrefix: config.MQTTTopicPrefix,
+	}
+	if config.MQTTDiscovery {
+		options.DiscoveryPrefix = config.MQTTDiscoveryPrefix
+	}
+	client, err := mqtt.Connect(options)
+	if err != nil {
+		return err
+	}
+
+	device := mqtt.Device{
+		ID:              invalidDeviceID.ReplaceAllString(config.MQTTClientID, "_"),
+		Name:            "Jetspotter",
+		Manufacturer:    "Jetspotter",
+		Model:           "Jetspotter",
+		SoftwareVersion: version.Version,
+	}
+	// The entities are sent again every time that the client connects, so a broker that is down doesn't stop jetspotter
+	if err := client.Discover(device, mqttEntities); err != nil {
+		log.Printf("Failed to send the Home Assistant discovery messages: %v", err)
+	}
+
+	mqttClient = client
+	mqttState.published = make(map[string]bool)
+	go runMQTTPublisher()
+	log.Printf("Publishing aircraft to MQTT broker %s", config.MQTTBroker)
+	return nil
+}
+
+// publishMQTT queues the newly spotted aircraft, the aircraft in the scan range and the aircraft in the notification range
+// to be published in the background
+func publishMQTT(spotted, inRange, nearby []Aircraft, now time.Time) {
+	if mqttClient == nil {
+		return
+	}
+
+	mqttPending.Lock()
+	mqttPending.spotted = append(mqttPending.spotted, spotted...)
+	if len(mqttPending.spotted) > maxPendingSpotted {
+		mqttPending.spotted = mqttPending.spotted[len(mqttPending.spotted)-maxPendingSpotted:]
+	}
+	mqttPending.inRange = inRange
+	mqttPending.nearby = nearby
+	mqttPending.now = now
+	mqttPending.Unlock()
+
+	select {
+	case mqttUpdates <- struct{}{}:
+	default:
+		// The publisher already has an update waiting, it picks up the latest aircraft
+	}
+}
+
+// runMQTTPublisher publishes the pending updates until the program exits
+func runMQTTPublisher() {
+	for range mqttUpdates {
+		mqttPending.Lock()
+		spotted, inRange, nearby, now := mqttPending.spotted, mqttPending.inRange, mqttPending.nearby, mqttPending.now
+		mqttPending.spotted = nil
+		mqttPending.Unlock()
+
+		publishMQTTUpdate(spotted, inRange, nearby, now)
+	}
+}
+
+// publishMQTTUpdate publishes the newly spotted aircraft as events, the state of the aircraft in the scan range and
+// the summary of the aircraft in the notification range. The state of aircraft that are no longer in range is cleared.
+// Nothing is published while the broker can't be reached, the state is published again after the next fetch.
+func publishMQTTUpdate(spotted, inRange, nearby []Aircraft, now time.Time) {
+	for i := range spotted {
+		mqttState.lastSpotted = &spotted[i]
+		mqttState.lastSpottedAt = &now
+	}
+	if !mqttClient.Connected() {
+		return
+	}
+
+	var errs []error
+	for _, ac := range spotted {
+		errs = append(errs, mqttClient.Publish("spotted", ac, false))
+	}
+
+	current := make(map[string]bool)
+	for _, ac := range inRange {
+		current[ac.ICAO] = true
+		errs = append(errs, mqttClient.Publish("aircraft/"+ac.ICAO, ac, true))
+	}
+	for icao := range mqttState.published {
+		if !current[icao] {
+			errs = append(errs, mqttClient.Clear("aircraft/"+icao))
+		}
+	}
+	mqttState.published = current
+
+	errs = append(errs, mqttClient.Publish("summary", buildMQTTSummary(nearby, now), true))
+
+	if err := errors.Join(errs...); err != nil {
+		log.Printf("Failed to publish to MQTT: %v", err)
+	}
+}
+
+// buildMQTTSummary summarizes the aircraft in the notification range for the Home Assistant sensors
+func buildMQTTSummary(nearby []Aircraft, now time.Time) mqttSummary {
+	summary := mqttSummary{
+		Count:         len(nearby),
+		LastSpotted:   mqttState.lastSpotted,
+		LastSpottedAt: mqttState.lastSpottedAt,
+		UpdatedAt:     now,
+	}
+	for i, ac := range nearby {
+		if ac.Military {
+			summary.Military++
+		}
+		if summary.Nearest == nil || ac.Distance < summary.Nearest.Distance {
+			summary.Nearest = &nearby[i]
+		}
+	}
+	return summary
+}
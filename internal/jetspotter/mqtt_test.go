@@ -0,0 +1,56 @@
+package jetspotter
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"jetspotter/internal/mqtt"
+)
+
+func TestMQTTSummary(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	empty, err := json.Marshal(buildMQTTSummary(nil, now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Home Assistant shows the sensors as unknown if there is no aircraft
+	if !strings.Contains(string(empty), `"count":0,"military":0,"nearest":null`) {
+		t.Errorf("unexpected summary without aircraft %s", empty)
+	}
+
+	inRange := []Aircraft{
+		{ICAO: "4ca7b5", Callsign: "RYR8TK", Distance: 28},
+		{ICAO: "ae07e0", Callsign: "RCH456", Distance: 7, Military: true},
+		{ICAO: "44c1e5", Callsign: "BAF01", Distance: 12, Military: true},
+	}
+	summary := buildMQTTSummary(inRange, now)
+	if summary.Count != 3 || summary.Military != 2 || summary.Nearest.Callsign != "RCH456" {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+}
+
+func TestMQTTUpdatesDoNotBlockTheFetchLoop(t *testing.T) {
+	mqttClient = &mqtt.Client{}
+	t.Cleanup(func() {
+		mqttClient = nil
+		mqttPending.spotted, mqttPending.inRange, mqttPending.nearby = nil, nil, nil
+		select {
+		case <-mqttUpdates:
+		default:
+		}
+	})
+
+	// Without a publisher the updates pile up, the aircraft in range are replaced by the latest ones
+	now := time.Now()
+	publishMQTT([]Aircraft{{ICAO: "ae07e0"}}, []Aircraft{{ICAO: "ae07e0"}}, []Aircraft{{ICAO: "ae07e0"}}, now)
+	publishMQTT([]Aircraft{{ICAO: "44c1e5"}}, []Aircraft{{ICAO: "44c1e5"}, {ICAO: "4ca7b5"}}, []Aircraft{{ICAO: "44c1e5"}}, now)
+
+	if len(mqttPending.spotted) != 2 || len(mqttPending.inRange) != 2 || mqttPending.inRange[0].ICAO != "44c1e5" || len(mqttPending.nearby) != 1 {
+		t.Errorf("unexpected pending update %+v", mqttPending.inRange)
+	}
+	if len(mqttUpdates) != 1 {
+		t.Errorf("expected one waiting update, got %d", len(mqttUpdates))
+	}
+}
@@ -0,0 +1,41 @@
+package jetspotter
+
+import "jetspotter/internal/operator"
+
+// applyOperator sets the airline and the spoken callsign of an aircraft based on the designator in its callsign.
+// This way the airline is known even if no flight route is found.
+func applyOperator(ac *Aircraft) {
+	op, number, found := operator.Lookup(ac.Callsign)
+	if !found {
+		return
+	}
+
+	ac.Airline = Airline{
+		Name:       op.Name,
+		ICAO:       op.Designator,
+		Country:    op.Country,
+		CountryISO: op.CountryISO,
+		Callsign:   op.Telephony,
+		Military:   op.Military,
+	}
+	if op.Telephony != "" {
+		ac.SpokenCallsign = op.Telephony + " " + number
+	}
+}
+
+// mergeAirline completes the airline of a flight route with the details that are missing in the route.
+func mergeAirline(route Airline, known Airline) Airline {
+	if route.Name == "" {
+		return known
+	}
+	if route.Callsign == "" && route.ICAO == known.ICAO {
+		route.Callsign = known.Callsign
+	}
+	if route.CountryISO == "" && route.Country == known.Country {
+		route.CountryISO = known.CountryISO
+	}
+	if route.ICAO == known.ICAO {
+		route.Military = known.Military
+	}
+	return route
+}
@@ -0,0 +1,49 @@
+package jetspotter
+
+import (
+	"testing"
+
+	"jetspotter/internal/configuration"
+)
+
+func TestOperatorFromCallsign(t *testing.T) {
+	raw := []AircraftRaw{
+		{ICAO: "44d0a1", Callsign: "BAF123  ", Registration: "CE-01", AltBaro: float64(8000)},
+		{ICAO: "4b1805", Callsign: "OOSNA", Registration: "OO-SNA", AltBaro: float64(8000)},
+	}
+
+	aircraft, err := ConvertToAircraft(raw, configuration.Config{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if aircraft[0].Airline.Name != "Belgian Air Force" || aircraft[0].Airline.Callsign != "BELGIAN AIRFORCE" ||
+		aircraft[0].SpokenCallsign != "BELGIAN AIRFORCE 123" || !aircraft[0].Airline.Military {
+		t.Errorf("expected the Belgian Air Force to operate BAF123, got %+v", aircraft[0])
+	}
+
+	// The operator doesn't change whether the aircraft counts as military
+	if aircraft[0].Military {
+		t.Errorf("expected the aircraft not to be marked as military by its operator")
+	}
+
+	if aircraft[1].Airline.Name != "" || aircraft[1].SpokenCallsign != "" || aircraft[1].Airline.Military {
+		t.Errorf("expected no operator for a registration as callsign, got %+v", aircraft[1])
+	}
+}
+
+func TestMergeAirline(t *testing.T) {
+	known := Airline{Name: "British Airways", ICAO: "BAW", Country: "United Kingdom", CountryISO: "GB", Callsign: "SPEEDBIRD"}
+	if merged := mergeAirline(Airline{Name: "Royal Air Force", ICAO: "RRR"}, Airline{ICAO: "RRR", Military: true}); !merged.Military {
+		t.Errorf("expected the route airline to keep the military operator, got %+v", merged)
+	}
+
+	merged := mergeAirline(Airline{Name: "British Airways", ICAO: "BAW", IATA: "BA", Country: "United Kingdom"}, known)
+	if merged.IATA != "BA" || merged.Callsign != "SPEEDBIRD" || merged.CountryISO != "GB" {
+		t.Errorf("expected the route airline to be completed, got %+v", merged)
+	}
+
+	if merged := mergeAirline(Airline{}, known); merged != known {
+		t.Errorf("expected the known airline if the route has none, got %+v", merged)
+	}
+}
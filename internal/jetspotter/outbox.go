@@ -0,0 +1,106 @@
+package jetspotter
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"jetspotter/internal/configuration"
+	"jetspotter/internal/email"
+	"jetspotter/internal/outbox"
+
+	"github.com/gin-gonic/gin"
+)
+
+// notificationOutbox stores the notifications until they are delivered.
+// Until SetupOutbox is called it is kept in memory and failed notifications are not retried.
+var notificationOutbox = outbox.New("", 1, time.Second, time.Second, time.Minute)
+
+// SetupOutbox configures the retries of the notification outbox, loads the notifications that weren't delivered before
+// the last restart and starts retrying them in the background. HTTP messages are sent to the endpoints by channel name.
+func SetupOutbox(config configuration.Config, endpoints map[string]outbox.Endpoint) error {
+	path := ""
+	if config.CacheDirectory != "" {
+		path = filepath.Join(config.CacheDirectory, "outbox.json")
+	}
+
+	notificationOutbox = outbox.New(path, config.NotificationMaxAttempts,
+		time.Duration(config.NotificationRetrySeconds)*time.Second,
+		time.Duration(config.NotificationMaxRetrySeconds)*time.Second,
+		time.Duration(config.NotificationTimeoutSeconds)*time.Second)
+	// The credentials of the SMTP server and the endpoints are kept out of the messages, which are stored on disk
+	// and returned by the API
+	notificationOutbox.Handle(outbox.HTTP, outbox.HTTPDeliverer(endpoints))
+	notificationOutbox.Handle(email.SMTP, email.Deliverer(email.Server{
+		Host:     config.SMTPHost,
+		Port:     config.SMTPPort,
+		Username: config.SMTPUsername,
+		Password: config.SMTPPassword,
+		Security: config.SMTPSecurity,
+	}, LoadImage))
+	if err := notificationOutbox.Load(); err != nil {
+		return err
+	}
+	if pending := len(notificationOutbox.Pending()); pending > 0 {
+		log.Printf("Retrying %d notifications that weren't delivered before the restart", pending)
+	}
+
+	go notificationOutbox.Run(context.Background())
+	return nil
+}
+
+// Outbox returns the outbox that notifications are sent through.
+func Outbox() *outbox.Outbox {
+	return notificationOutbox
+}
+
+// handleOutboxAPI returns the notifications that are waiting to be delivered and the ones that could not be delivered
+func handleOutboxAPI(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"pending":     notificationOutbox.Pending(),
+		"deadLetters": notificationOutbox.DeadLetters(),
+	})
+}
+
+// handleReplayAPI moves a dead letter back to the pending notifications, or all of them without an ID
+func handleReplayAPI(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusOK, gin.H{"replayed": notificationOutbox.ReplayAll()})
+		return
+	}
+
+	if !notificationOutbox.Replay(id) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "dead letter not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"replayed": 1})
+}
+
+// NotificationPreview renders the notifications of a channel about the aircraft without sending them, sample aircraft
+// are used if there are none. It is set by the notification package, which depends on this package.
+var NotificationPreview func(channel string, aircraft []Aircraft) (any, error)
+
+// handlePreviewAPI returns the notifications that a channel would send
+func handlePreviewAPI(c *gin.Context) {
+	if NotificationPreview == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "notifications are not set up"})
+		return
+	}
+
+	var aircraft []Aircraft
+	if c.Query("live") == "true" {
+		SpottedAircraft.Lock()
+		aircraft = append(aircraft, SpottedAircraft.Aircraft...)
+		SpottedAircraft.Unlock()
+	}
+
+	preview, err := NotificationPreview(c.Param("channel"), aircraft)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, preview)
+}
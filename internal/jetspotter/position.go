@@ -0,0 +1,88 @@
+package jetspotter
+
+import (
+	"strings"
+
+	"jetspotter/internal/configuration"
+)
+
+// Sources of the position of an aircraft
+const (
+	SourceADSB  = "ADS-B"
+	SourceADSR  = "ADS-R"
+	SourceTISB  = "TIS-B"
+	SourceADSC  = "ADS-C"
+	SourceMLAT  = "MLAT"
+	SourceModeS = "Mode S"
+	SourceOther = "Other"
+)
+
+// positionAccuracies are the bounds in meters that contain the reported position with a probability of 95%,
+// by Navigation Accuracy Category for Position (NACp). A NACp of 0 means that the accuracy is unknown.
+var positionAccuracies = []float64{0, 18520, 7408, 3704, 1852, 926, 555.6, 185.2, 92.6, 30, 10, 3}
+
+// positionSource returns where the position of the aircraft comes from.
+// Multilateration and TIS-B positions are listed in the mlat and tisb fields, even if the type of the aircraft is ADS-B.
+func positionSource(acRaw AircraftRaw) string {
+	if containsField(acRaw.MLAT, "lat") || strings.HasPrefix(acRaw.Type, "mlat") {
+		return SourceMLAT
+	}
+	if containsField(acRaw.TISB, "lat") {
+		return SourceTISB
+	}
+
+	switch {
+	case strings.HasPrefix(acRaw.Type, "adsb"):
+		return SourceADSB
+	case strings.HasPrefix(acRaw.Type, "adsr"):
+		return SourceADSR
+	case strings.HasPrefix(acRaw.Type, "tisb"):
+		return SourceTISB
+	case strings.HasPrefix(acRaw.Type, "adsc"):
+		return SourceADSC
+	case strings.HasPrefix(acRaw.Type, "mode_s"):
+		return SourceModeS
+	default:
+		return SourceOther
+	}
+}
+
+func containsField(fields []interface{}, name string) bool {
+	for _, field := range fields {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
+
+// positionAccuracy returns the estimated accuracy of the reported position in meters, 0 if it is unknown.
+// Multilateration positions have no NACp, their accuracy depends on the geometry of the receivers.
+func positionAccuracy(acRaw AircraftRaw, source string) float64 {
+	if source == SourceMLAT || acRaw.NACP <= 0 || acRaw.NACP >= len(positionAccuracies) {
+		return 0
+	}
+	return positionAccuracies[acRaw.NACP]
+}
+
+// applyPositionSource sets the source and the accuracy of the position of the aircraft
+func applyPositionSource(ac *Aircraft, acRaw AircraftRaw) {
+	ac.Source = positionSource(acRaw)
+	ac.PositionAccuracy = positionAccuracy(acRaw, ac.Source)
+}
+
+// checkPositionQuality flags the aircraft whose position is older or less accurate than configured,
+// and drops them instead if the configured action is to drop them.
+// Positions of unknown accuracy are never considered inaccurate.
+func checkPositionQuality(aircraft []Aircraft, config configuration.Config) []Aircraft {
+	var checkedAircraft []Aircraft
+	for _, ac := range aircraft {
+		ac.PositionStale = config.MaxPositionAgeSeconds > 0 && ac.PositionAge > float64(config.MaxPositionAgeSeconds)
+		ac.PositionInaccurate = config.MaxPositionErrorMeters > 0 && ac.PositionAccuracy > float64(config.MaxPositionErrorMeters)
+		if config.PositionQualityAction == configuration.PositionQualityDrop && (ac.PositionStale || ac.PositionInaccurate) {
+			continue
+		}
+		checkedAircraft = append(checkedAircraft, ac)
+	}
+	return checkedAircraft
+}
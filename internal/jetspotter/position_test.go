@@ -0,0 +1,76 @@
+package jetspotter
+
+import (
+	"encoding/json"
+	"testing"
+
+	"jetspotter/internal/configuration"
+)
+
+func TestPositionSource(t *testing.T) {
+	var raw []AircraftRaw
+	err := json.Unmarshal([]byte(`[
+		{"hex": "44c1e5", "type": "adsb_icao", "nac_p": 9, "mlat": [], "tisb": []},
+		{"hex": "484506", "type": "mlat", "nac_p": 0, "mlat": ["lat", "lon", "nic", "rc"], "tisb": []},
+		{"hex": "a1b2c3", "type": "adsb_icao", "nac_p": 8, "mlat": ["lat", "lon"], "tisb": []},
+		{"hex": "~2c4a1", "type": "tisb_trackfile", "nac_p": 6, "mlat": [], "tisb": ["lat", "lon"]},
+		{"hex": "3c6444", "type": "mode_s", "mlat": [], "tisb": []}
+	]`), &raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		source   string
+		accuracy float64
+	}{
+		{SourceADSB, 30},
+		{SourceMLAT, 0},
+		// Positions from multilateration are marked as such, even if the aircraft also sends ADS-B
+		{SourceMLAT, 0},
+		{SourceTISB, 555.6},
+		{SourceModeS, 0},
+	}
+	for i, acRaw := range raw {
+		var ac Aircraft
+		applyPositionSource(&ac, acRaw)
+		if ac.Source != expected[i].source || ac.PositionAccuracy != expected[i].accuracy {
+			t.Errorf("%s: expected %s with an accuracy of %vm, got %s with %vm",
+				acRaw.ICAO, expected[i].source, expected[i].accuracy, ac.Source, ac.PositionAccuracy)
+		}
+	}
+}
+
+func TestCheckPositionQuality(t *testing.T) {
+	aircraft := []Aircraft{
+		{ICAO: "fresh", PositionAge: 2, PositionAccuracy: 30},
+		{ICAO: "stale", PositionAge: 45, PositionAccuracy: 30},
+		{ICAO: "inaccurate", PositionAge: 2, PositionAccuracy: 1852},
+		// The accuracy of multilateration positions is unknown
+		{ICAO: "mlat", PositionAge: 2, Source: SourceMLAT},
+	}
+	config := configuration.Config{
+		MaxPositionAgeSeconds:  30,
+		MaxPositionErrorMeters: 500,
+		PositionQualityAction:  configuration.PositionQualityFlag,
+	}
+
+	flagged := checkPositionQuality(aircraft, config)
+	if len(flagged) != 4 {
+		t.Fatalf("expected all aircraft to be kept when flagging, got %d", len(flagged))
+	}
+	if flagged[0].PositionStale || flagged[0].PositionInaccurate || !flagged[1].PositionStale || !flagged[2].PositionInaccurate || flagged[3].PositionInaccurate {
+		t.Errorf("unexpected flags %+v", flagged)
+	}
+
+	config.PositionQualityAction = configuration.PositionQualityDrop
+	dropped := checkPositionQuality(aircraft, config)
+	if len(dropped) != 2 || dropped[0].ICAO != "fresh" || dropped[1].ICAO != "mlat" {
+		t.Errorf("expected only the fresh and the mlat aircraft to be kept, got %+v", dropped)
+	}
+
+	config.MaxPositionAgeSeconds, config.MaxPositionErrorMeters = 0, 0
+	if kept := checkPositionQuality(aircraft, config); len(kept) != 4 {
+		t.Errorf("expected the checks to be disabled, got %d aircraft", len(kept))
+	}
+}
@@ -0,0 +1,173 @@
+package jetspotter
+
+import (
+	"math"
+
+	"jetspotter/internal/configuration"
+
+	"github.com/jftuga/geodist"
+)
+
+// Tolerances of the geometric validation of flight routes, see SetupRouteValidation.
+var routeValidation = struct {
+	crossTrackKilometers float64
+	trackDegrees         float64
+}{crossTrackKilometers: 150, trackDegrees: 60}
+
+// airportAreaKilometers is the distance to an airport within which the track of an aircraft isn't checked,
+// since departing and arriving aircraft fly in all directions.
+const airportAreaKilometers = 50
+
+// SetupRouteValidation configures the tolerances of the geometric validation of flight routes.
+func SetupRouteValidation(config configuration.Config) {
+	routeValidation.crossTrackKilometers = float64(config.RouteCrossTrackToleranceKilometers)
+	routeValidation.trackDegrees = float64(config.RouteTrackToleranceDegrees)
+}
+
+// leg is a great-circle segment of a flight route
+type leg struct {
+	from, to geodist.Coord
+	// Length of the leg in kilometers
+	length float64
+}
+
+// routeLegs returns the legs of the route from the origin via the stops to the destination.
+// It returns nil if the coordinates of an airport are unknown.
+func routeLegs(origin, destination Airport, stops []Airport) []leg {
+	airports := append(append([]Airport{origin}, stops...), destination)
+
+	var legs []leg
+	for i := 0; i < len(airports)-1; i++ {
+		from, to := airports[i], airports[i+1]
+		if (from.Latitude == 0 && from.Longitude == 0) || (to.Latitude == 0 && to.Longitude == 0) {
+			return nil
+		}
+
+		l := leg{
+			from: geodist.Coord{Lat: from.Latitude, Lon: from.Longitude},
+			to:   geodist.Coord{Lat: to.Latitude, Lon: to.Longitude},
+		}
+		l.length = greatCircleDistance(l.from, l.to)
+		legs = append(legs, l)
+	}
+	return legs
+}
+
+// greatCircleDistance returns the distance between two coordinates in kilometers without rounding
+func greatCircleDistance(source, destination geodist.Coord) float64 {
+	lat1, lat2 := toRadians(source.Lat), toRadians(destination.Lat)
+	dLat := lat2 - lat1
+	dLon := toRadians(destination.Lon - source.Lon)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKilometers * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// project returns the cross-track distance of the position from the great circle of the leg,
+// and the along-track distance from the start of the leg to the closest point on it, both in kilometers.
+func (l leg) project(position geodist.Coord) (crossTrack, alongTrack float64) {
+	angularDistance := greatCircleDistance(l.from, position) / earthRadiusKilometers
+	bearingToPosition := toRadians(CalculateBearing(l.from, position))
+	bearingOfLeg := toRadians(CalculateBearing(l.from, l.to))
+
+	crossAngle := math.Asin(math.Sin(angularDistance) * math.Sin(bearingToPosition-bearingOfLeg))
+	alongAngle := math.Acos(math.Max(-1, math.Min(1, math.Cos(angularDistance)/math.Cos(crossAngle))))
+
+	// The closest point is before the start of the leg if the position is behind it
+	if math.Cos(bearingToPosition-bearingOfLeg) < 0 {
+		alongAngle = -alongAngle
+	}
+
+	return math.Abs(crossAngle) * earthRadiusKilometers, alongAngle * earthRadiusKilometers
+}
+
+// locateOnRoute returns the leg that the position is on and the distance along it, or -1 if the position isn't
+// within the cross-track tolerance of any leg.
+func locateOnRoute(legs []leg, position geodist.Coord, tolerance float64) (index int, alongTrack float64) {
+	index = -1
+	best := math.MaxFloat64
+	for i, l := range legs {
+		crossTrack, along := l.project(position)
+		if crossTrack > tolerance || along < -tolerance || along > l.length+tolerance {
+			continue
+		}
+		if crossTrack < best {
+			index, alongTrack, best = i, along, crossTrack
+		}
+	}
+	return index, alongTrack
+}
+
+// isRouteGeometricallyPlausible checks whether the aircraft lies near the great-circle path of the route
+// and its track roughly agrees with it. Routes without airport coordinates can't be checked and are accepted.
+func isRouteGeometricallyPlausible(route *FlightRoute, aircraft Aircraft) bool {
+	legs := routeLegs(route.Origin, route.Destination, route.Stops)
+	if legs == nil {
+		return true
+	}
+
+	position := estimatedPosition(aircraft)
+	index, alongTrack := locateOnRoute(legs, position, routeValidation.crossTrackKilometers)
+	if index < 0 {
+		return false
+	}
+
+	l := legs[index]
+	if aircraft.OnGround || aircraft.Speed < 50 || alongTrack < airportAreaKilometers || l.length-alongTrack < airportAreaKilometers {
+		return true
+	}
+
+	// Aircraft flying the route head towards the end of the leg
+	diff := math.Abs(CalculateBearing(position, l.to) - aircraft.Heading)
+	if diff > 180 {
+		diff = 360 - diff
+	}
+	return diff <= routeValidation.trackDegrees
+}
+
+// updateRouteProgress calculates how far the aircraft is along its route and when it arrives.
+// Nothing is calculated if the aircraft has no route or the coordinates of its airports are unknown.
+func updateRouteProgress(ac *Aircraft) {
+	ac.RouteProgress, ac.DistanceFlown, ac.DistanceRemaining, ac.ETASeconds = 0, 0, 0, 0
+
+	legs := routeLegs(ac.Origin, ac.Destination, ac.Stops)
+	if legs == nil {
+		return
+	}
+
+	index, alongTrack := locateOnRoute(legs, estimatedPosition(*ac), math.MaxFloat64)
+	if index < 0 {
+		return
+	}
+
+	total, flown := 0.0, 0.0
+	for i, l := range legs {
+		total += l.length
+		switch {
+		case i < index:
+			flown += l.length
+		case i == index:
+			flown += math.Max(0, math.Min(l.length, alongTrack))
+		}
+	}
+
+	if total == 0 {
+		return
+	}
+
+	remaining := total - flown
+	ac.RouteProgress = int(math.Round(flown / total * 100))
+	ac.DistanceFlown = int(math.Round(flown))
+	ac.DistanceRemaining = int(math.Round(remaining))
+	if ac.Speed > 0 && !ac.OnGround {
+		ac.ETASeconds = int(math.Round(remaining / (float64(ac.Speed) * 1.852) * 3600))
+	}
+}
+
+// estimatedPosition returns the estimated position of the aircraft, or the reported one if there is no estimate
+func estimatedPosition(ac Aircraft) geodist.Coord {
+	if ac.EstimatedLatitude == 0 && ac.EstimatedLongitude == 0 {
+		return geodist.Coord{Lat: ac.Latitude, Lon: ac.Longitude}
+	}
+	return geodist.Coord{Lat: ac.EstimatedLatitude, Lon: ac.EstimatedLongitude}
+}
@@ -0,0 +1,77 @@
+package jetspotter
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jftuga/geodist"
+)
+
+// Brussels to Madrid
+var (
+	brussels = Airport{ICAOCode: "EBBR", Name: "Brussels Airport", Latitude: 50.9014, Longitude: 4.48444}
+	madrid   = Airport{ICAOCode: "LEMD", Name: "Adolfo Suárez Madrid–Barajas Airport", Latitude: 40.4719, Longitude: -3.56264}
+	route    = &FlightRoute{Airline: Airline{ICAO: "IBE"}, Origin: brussels, Destination: madrid}
+)
+
+// halfwayAircraft returns an aircraft halfway between Brussels and Madrid flying the given track
+func halfwayAircraft(track float64) Aircraft {
+	l := routeLegs(brussels, madrid, nil)[0]
+	halfway := destinationPoint(l.from, CalculateBearing(l.from, l.to), l.length/2)
+	return Aircraft{Callsign: "IBE3201", Latitude: halfway.Lat, Longitude: halfway.Lon, Speed: 450, Heading: track,
+		Origin: brussels, Destination: madrid}
+}
+
+func TestRouteIsPlausibleAlongThePath(t *testing.T) {
+	aircraft := halfwayAircraft(0)
+	aircraft.Heading = CalculateBearing(geodist.Coord{Lat: aircraft.Latitude, Lon: aircraft.Longitude},
+		geodist.Coord{Lat: madrid.Latitude, Lon: madrid.Longitude})
+
+	if !isValidFlightRouteForAircraft(route, aircraft) {
+		t.Fatal("expected the route to be valid for an aircraft flying along it")
+	}
+
+	// Flying the opposite direction
+	aircraft.Heading = math.Mod(aircraft.Heading+180, 360)
+	if isValidFlightRouteForAircraft(route, aircraft) {
+		t.Fatal("expected the route to be invalid for an aircraft flying in the opposite direction")
+	}
+}
+
+func TestRouteIsImplausibleFarFromThePath(t *testing.T) {
+	// Same callsign prefix, but over Poland
+	aircraft := Aircraft{Callsign: "IBE3201", Latitude: 52.2, Longitude: 21.0, Speed: 450, Heading: 240}
+
+	if isValidFlightRouteForAircraft(route, aircraft) {
+		t.Fatal("expected the route to be invalid for an aircraft far away from it")
+	}
+}
+
+func TestRouteWithoutCoordinatesIsAccepted(t *testing.T) {
+	withoutCoordinates := &FlightRoute{Airline: Airline{ICAO: "IBE"}, Origin: Airport{Name: "A"}, Destination: Airport{Name: "B"}}
+	if !isValidFlightRouteForAircraft(withoutCoordinates, Aircraft{Callsign: "IBE3201"}) {
+		t.Fatal("expected a route without coordinates to be accepted on its callsign")
+	}
+}
+
+func TestUpdateRouteProgress(t *testing.T) {
+	aircraft := halfwayAircraft(210)
+	updateRouteProgress(&aircraft)
+
+	total := CalculateDistance(geodist.Coord{Lat: brussels.Latitude, Lon: brussels.Longitude},
+		geodist.Coord{Lat: madrid.Latitude, Lon: madrid.Longitude})
+
+	if aircraft.RouteProgress != 50 {
+		t.Fatalf("expected a progress of 50%%, got %d%%", aircraft.RouteProgress)
+	}
+
+	if math.Abs(float64(aircraft.DistanceFlown+aircraft.DistanceRemaining-total)) > 1 {
+		t.Fatalf("expected flown and remaining to add up to %dkm, got %d and %d", total, aircraft.DistanceFlown, aircraft.DistanceRemaining)
+	}
+
+	// 660km at 450 knots takes about 47 minutes
+	expected := float64(aircraft.DistanceRemaining) / (450 * 1.852) * 3600
+	if math.Abs(float64(aircraft.ETASeconds)-expected) > 1 {
+		t.Fatalf("expected to arrive in %.0f seconds, got %d", expected, aircraft.ETASeconds)
+	}
+}
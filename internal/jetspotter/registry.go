@@ -0,0 +1,59 @@
+package jetspotter
+
+import (
+	"time"
+
+	"jetspotter/internal/configuration"
+	"jetspotter/internal/registry"
+)
+
+// aircraftDatabase is the offline aircraft database, nil if none is configured.
+var aircraftDatabase *registry.Database
+
+// SetupRegistry loads the offline aircraft database and reloads it periodically when it changes on disk.
+func SetupRegistry(config configuration.Config) error {
+	if config.AircraftDatabase == "" {
+		return nil
+	}
+
+	db, err := registry.Load(config.AircraftDatabase)
+	if err != nil {
+		return err
+	}
+	aircraftDatabase = db
+
+	if config.AircraftDatabaseReloadMinutes > 0 {
+		go db.Watch(time.Duration(config.AircraftDatabaseReloadMinutes)*time.Minute, nil)
+	}
+
+	return nil
+}
+
+// applyRegistry fills in the fields that are missing from the ADS-B data with the offline aircraft database.
+func applyRegistry(aircraft AircraftRaw) AircraftRaw {
+	record, found := aircraftDatabase.Lookup(aircraft.ICAO)
+	if !found {
+		return aircraft
+	}
+
+	if aircraft.Registration == "" {
+		aircraft.Registration = record.Registration
+	}
+	if aircraft.PlaneType == "" {
+		aircraft.PlaneType = record.Type
+	}
+	if aircraft.Desc == "" {
+		aircraft.Desc = record.Description
+	}
+	if aircraft.OwnOp == "" {
+		aircraft.OwnOp = record.Operator
+	}
+	if aircraft.Year == "" {
+		aircraft.Year = record.Year
+	}
+	if record.Military && aircraft.DbFlags == 0 {
+		aircraft.DbFlags = 1
+	}
+
+	return aircraft
+}
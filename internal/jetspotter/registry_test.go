@@ -0,0 +1,35 @@
+package jetspotter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"jetspotter/internal/configuration"
+)
+
+func TestRegistryFillsMissingFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "aircraft.csv")
+	os.WriteFile(path, []byte("ae1234;12-3456;C17;10;BOEING C-17 Globemaster III;2012;United States Air Force\n"), 0o644)
+
+	err := SetupRegistry(configuration.Config{AircraftDatabase: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { aircraftDatabase = nil }()
+
+	raw := []AircraftRaw{{ICAO: "ae1234", Callsign: "RCH123", AltBaro: float64(20000), Lat: 51.1, Lon: 5.4}}
+	aircraft, err := ConvertToAircraft(raw, configuration.Config{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(aircraft) != 1 {
+		t.Fatal("expected the aircraft without registration in the ADS-B data to be kept")
+	}
+
+	ac := aircraft[0]
+	if ac.Registration != "12-3456" || ac.Type != "C17" || ac.Operator != "United States Air Force" || ac.Year != "2012" || !ac.Military {
+		t.Fatalf("unexpected aircraft %+v", ac)
+	}
+}
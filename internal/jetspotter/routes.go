@@ -0,0 +1,148 @@
+package jetspotter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"jetspotter/internal/configuration"
+	"jetspotter/internal/standingdata"
+)
+
+// RouteProvider resolves a callsign to the route that is flown with it.
+// A nil route without an error means that the provider doesn't know the callsign.
+type RouteProvider interface {
+	FlightRoute(ctx context.Context, callsign string) (*FlightRoute, error)
+}
+
+// routeProvider resolves the flight routes of the aircraft, the adsbdb.com API is used unless configured otherwise.
+var routeProvider RouteProvider = adsbdbRoutes{}
+
+// SetupRoutes configures the chain of route providers that are asked in order.
+func SetupRoutes(config configuration.Config) error {
+	var chain routeChain
+	for _, name := range config.RouteProviders {
+		switch name {
+		case "adsbdb":
+			chain = append(chain, adsbdbRoutes{})
+		case "standing-data":
+			if config.StandingDataDirectory == "" {
+				return fmt.Errorf("%s must be set to use the standing-data route provider", configuration.StandingDataDirectory)
+			}
+			db, err := loadStandingData(config.StandingDataDirectory)
+			if err != nil {
+				return err
+			}
+			chain = append(chain, standingDataRoutes{db: db})
+		default:
+			return fmt.Errorf("unknown route provider %q", name)
+		}
+	}
+
+	routeProvider = chain
+	return nil
+}
+
+// standingData is loaded once, it is used by both the route provider and the geocoder
+var standingData struct {
+	directory string
+	db        *standingdata.Database
+}
+
+// loadStandingData loads the standing data in the directory, unless it was already loaded.
+func loadStandingData(directory string) (*standingdata.Database, error) {
+	if standingData.db != nil && standingData.directory == directory {
+		return standingData.db, nil
+	}
+
+	db, err := standingdata.Load(directory)
+	if err != nil {
+		return nil, err
+	}
+	routes, airlines, airports := db.Len()
+	log.Printf("Loaded %d routes, %d airlines and %d airports from %s", routes, airlines, airports, directory)
+
+	standingData.directory, standingData.db = directory, db
+	return db, nil
+}
+
+// routeChain asks every provider in order until one of them knows the callsign
+type routeChain []RouteProvider
+
+func (c routeChain) FlightRoute(ctx context.Context, callsign string) (*FlightRoute, error) {
+	var errs []error
+	for _, provider := range c {
+		route, err := provider.FlightRoute(ctx, callsign)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if route != nil {
+			return route, nil
+		}
+	}
+
+	// Only report that the route is unknown if every provider could be asked
+	return nil, errors.Join(errs...)
+}
+
+// adsbdbRoutes looks up flight routes with the adsbdb.com API
+type adsbdbRoutes struct{}
+
+func (adsbdbRoutes) FlightRoute(ctx context.Context, callsign string) (*FlightRoute, error) {
+	return getFlightRoute(ctx, callsign)
+}
+
+// standingDataRoutes looks up flight routes in the standing data of Virtual Radar Server
+type standingDataRoutes struct {
+	db *standingdata.Database
+}
+
+func (s standingDataRoutes) FlightRoute(ctx context.Context, callsign string) (*FlightRoute, error) {
+	route, found := s.db.Route(callsign)
+	if !found {
+		return nil, nil
+	}
+
+	var airports []Airport
+	for _, code := range route.AirportCodes {
+		airport, found := s.db.Airport(code)
+		if !found {
+			// A route with unknown airports can't be shown
+			return nil, nil
+		}
+		airports = append(airports, Airport{
+			CountryISOName: airport.CountryISO,
+			Elevation:      airport.AltitudeFeet,
+			IATACode:       airport.IATA,
+			ICAOCode:       airport.ICAO,
+			Latitude:       airport.Latitude,
+			Longitude:      airport.Longitude,
+			Municipality:   airport.Location,
+			Name:           airport.Name,
+		})
+	}
+
+	flightRoute := &FlightRoute{
+		Callsign:     route.Callsign,
+		CallsignICAO: route.Callsign,
+		Origin:       airports[0],
+		Destination:  airports[len(airports)-1],
+		Stops:        airports[1 : len(airports)-1],
+	}
+
+	if airline, found := s.db.Airline(route.AirlineCode); found {
+		flightRoute.Airline = Airline{
+			Name: airline.Name,
+			ICAO: airline.ICAO,
+			IATA: airline.IATA,
+		}
+		if airline.IATA != "" && strings.HasPrefix(route.Callsign, airline.ICAO) {
+			flightRoute.CallsignIATA = airline.IATA + strings.TrimPrefix(route.Callsign, airline.ICAO)
+		}
+	}
+
+	return flightRoute, nil
+}
@@ -0,0 +1,76 @@
+package jetspotter
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"jetspotter/internal/configuration"
+
+	"github.com/jftuga/geodist"
+)
+
+type fakeRoutes struct {
+	route *FlightRoute
+	err   error
+	calls int
+}
+
+func (f *fakeRoutes) FlightRoute(ctx context.Context, callsign string) (*FlightRoute, error) {
+	f.calls++
+	return f.route, f.err
+}
+
+func TestStandingDataRoutes(t *testing.T) {
+	err := SetupRoutes(configuration.Config{
+		RouteProviders:        []string{"standing-data"},
+		StandingDataDirectory: "../standingdata/testdata",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { routeProvider = adsbdbRoutes{} }()
+
+	route, err := routeProvider.FlightRoute(context.Background(), "BAW15")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if route.Origin.IATACode != "LHR" || route.Destination.IATACode != "SYD" || len(route.Stops) != 1 || route.Stops[0].Name != "Changi" {
+		t.Fatalf("unexpected route %+v", route)
+	}
+
+	if route.Airline.Name != "British Airways" || route.CallsignIATA != "BA15" {
+		t.Fatalf("unexpected airline %+v or IATA callsign %s", route.Airline, route.CallsignIATA)
+	}
+
+	// On its way from London to Singapore over Germany
+	position := geodist.Coord{Lat: 52.5, Lon: 10.0}
+	aircraft := Aircraft{Callsign: "BAW15", Latitude: position.Lat, Longitude: position.Lon, Speed: 450,
+		Heading: CalculateBearing(position, geodist.Coord{Lat: route.Stops[0].Latitude, Lon: route.Stops[0].Longitude})}
+	if !isValidFlightRouteForAircraft(route, aircraft) {
+		t.Fatal("expected the route to be valid for the aircraft")
+	}
+
+	route, err = routeProvider.FlightRoute(context.Background(), "KLM123")
+	if route != nil || err != nil {
+		t.Fatalf("expected an unknown callsign, got %+v and %v", route, err)
+	}
+}
+
+func TestRouteChain(t *testing.T) {
+	local := &fakeRoutes{}
+	remote := &fakeRoutes{route: &FlightRoute{Callsign: "KLM123"}}
+
+	route, err := routeChain{local, remote}.FlightRoute(context.Background(), "KLM123")
+	if err != nil || route == nil || local.calls != 1 || remote.calls != 1 {
+		t.Fatalf("expected the fallback to be asked, got %+v and %v", route, err)
+	}
+
+	// A failing provider must not make the route look unknown
+	failing := &fakeRoutes{err: errors.New("API rate limit exceeded")}
+	route, err = routeChain{local, failing}.FlightRoute(context.Background(), "KLM123")
+	if err == nil || route != nil {
+		t.Fatalf("expected an error, got %+v", route)
+	}
+}
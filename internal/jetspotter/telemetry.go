@@ -0,0 +1,156 @@
+package jetspotter
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"jetspotter/internal/configuration"
+)
+
+// applyTelemetry copies the air data, weather and autopilot settings that the aircraft reports
+func applyTelemetry(ac *Aircraft, acRaw AircraftRaw) {
+	ac.IndicatedAirSpeed = acRaw.IAS
+	ac.TrueAirSpeed = acRaw.TAS
+	ac.Mach = acRaw.Mach
+	ac.VerticalRate = verticalRate(acRaw)
+	ac.WindDirection = acRaw.WD
+	ac.WindSpeed = acRaw.WS
+	ac.OutsideAirTemperature = acRaw.OAT
+	ac.TotalAirTemperature = acRaw.TAT
+	ac.SelectedAltitude = acRaw.NavAltMCP
+	ac.FMSAltitude = acRaw.NavAltFMS
+	ac.SelectedHeading = acRaw.NavHeading
+	ac.QNH = acRaw.NavQNH
+}
+
+// verticalRate returns the barometric vertical rate, or the geometric one if the barometric one isn't reported
+func verticalRate(acRaw AircraftRaw) *int {
+	if acRaw.BaroRate != nil {
+		return acRaw.BaroRate
+	}
+	return acRaw.GeomRate
+}
+
+// valueOf returns the value of an optional field, or 0 if it isn't set
+func valueOf[T int | float64](value *T) T {
+	if value == nil {
+		return 0
+	}
+	return *value
+}
+
+// condition compares a value of an aircraft with a number, for example "vertical_rate<-3000"
+type condition struct {
+	field    string
+	operator string
+	value    float64
+}
+
+// conditionFields are the values of an aircraft that conditions can compare, false if the aircraft doesn't report it
+var conditionFields = map[string]func(ac Aircraft) (float64, bool){
+	"altitude":            func(ac Aircraft) (float64, bool) { return ac.Altitude, true },
+	"height_above_ground": func(ac Aircraft) (float64, bool) { return optional(ac.HeightAboveGround) },
+	"speed":               func(ac Aircraft) (float64, bool) { return float64(ac.Speed), true },
+	"ias":                 func(ac Aircraft) (float64, bool) { return optional(ac.IndicatedAirSpeed) },
+	"tas":                 func(ac Aircraft) (float64, bool) { return optional(ac.TrueAirSpeed) },
+	"mach":                func(ac Aircraft) (float64, bool) { return optional(ac.Mach) },
+	"vertical_rate":       func(ac Aircraft) (float64, bool) { return optional(ac.VerticalRate) },
+	"wind_direction":      func(ac Aircraft) (float64, bool) { return optional(ac.WindDirection) },
+	"wind_speed":          func(ac Aircraft) (float64, bool) { return optional(ac.WindSpeed) },
+	"oat":                 func(ac Aircraft) (float64, bool) { return optional(ac.OutsideAirTemperature) },
+	"tat":                 func(ac Aircraft) (float64, bool) { return optional(ac.TotalAirTemperature) },
+	"selected_altitude":   func(ac Aircraft) (float64, bool) { return optional(ac.SelectedAltitude) },
+	"fms_altitude":        func(ac Aircraft) (float64, bool) { return optional(ac.FMSAltitude) },
+	"selected_heading":    func(ac Aircraft) (float64, bool) { return optional(ac.SelectedHeading) },
+	"qnh":                 func(ac Aircraft) (float64, bool) { return optional(ac.QNH) },
+}
+
+// Operators of the conditions, the ones of two characters come first so that "<=" isn't parsed as "<"
+var conditionOperators = []string{"<=", ">=", "!=", "<", ">", "="}
+
+func optional[T int | float64](value *T) (float64, bool) {
+	if value == nil {
+		return 0, false
+	}
+	return float64(*value), true
+}
+
+// notifyConditions are the conditions that aircraft have to meet to be notified about, none until SetupConditions is called
+var notifyConditions []condition
+
+// SetupConditions parses the conditions that aircraft have to meet to be notified about.
+func SetupConditions(config configuration.Config) error {
+	notifyConditions = nil
+	for _, text := range config.NotifyConditions {
+		c, err := parseCondition(text)
+		if err != nil {
+			return err
+		}
+		notifyConditions = append(notifyConditions, c)
+	}
+	return nil
+}
+
+// parseCondition parses a condition like "vertical_rate<-3000" or "selected_altitude<=2000"
+func parseCondition(text string) (condition, error) {
+	value := strings.ToLower(strings.ReplaceAll(text, " ", ""))
+	for _, operator := range conditionOperators {
+		field, number, found := strings.Cut(value, operator)
+		if !found {
+			continue
+		}
+
+		if _, known := conditionFields[field]; !known {
+			return condition{}, fmt.Errorf("unknown field %q in condition %q", field, text)
+		}
+		n, err := strconv.ParseFloat(number, 64)
+		if err != nil {
+			return condition{}, fmt.Errorf("invalid number in condition %q: %w", text, err)
+		}
+		return condition{field: field, operator: operator, value: n}, nil
+	}
+	return condition{}, fmt.Errorf("missing operator in condition %q", text)
+}
+
+// matches checks whether the aircraft meets the condition, aircraft that don't report the value never do
+func (c condition) matches(ac Aircraft) bool {
+	value, ok := conditionFields[c.field](ac)
+	if !ok {
+		return false
+	}
+
+	switch c.operator {
+	case "<":
+		return value < c.value
+	case "<=":
+		return value <= c.value
+	case ">":
+		return value > c.value
+	case ">=":
+		return value >= c.value
+	case "=":
+		return math.Abs(value-c.value) < 1e-9
+	default:
+		return math.Abs(value-c.value) >= 1e-9
+	}
+}
+
+// filterAircraftByConditions returns the aircraft that meet all of the conditions
+func filterAircraftByConditions(aircraft []Aircraft, conditions []condition) []Aircraft {
+	var filteredAircraft []Aircraft
+	for _, ac := range aircraft {
+		matches := true
+		for _, c := range conditions {
+			if !c.matches(ac) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			filteredAircraft = append(filteredAircraft, ac)
+		}
+	}
+	return filteredAircraft
+}
@@ -0,0 +1,61 @@
+package jetspotter
+
+import (
+	"encoding/json"
+	"testing"
+
+	"jetspotter/internal/configuration"
+)
+
+func TestTelemetryIsNilWhenAbsent(t *testing.T) {
+	var raw []AircraftRaw
+	err := json.Unmarshal([]byte(`[
+		{"hex": "44c1e5", "ias": 0, "mach": 0.784, "geom_rate": -1408, "oat": -31, "nav_altitude_mcp": 2000},
+		{"hex": "484506", "baro_rate": -1344, "geom_rate": -1408, "nav_qnh": 1013.6}
+	]`), &raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var reported, absent Aircraft
+	applyTelemetry(&reported, raw[0])
+	applyTelemetry(&absent, raw[1])
+
+	if reported.IndicatedAirSpeed == nil || *reported.IndicatedAirSpeed != 0 || *reported.Mach != 0.784 || *reported.OutsideAirTemperature != -31 {
+		t.Errorf("expected the reported telemetry to be set, got %+v", reported)
+	}
+	// The geometric vertical rate is only used if the barometric one isn't reported
+	if *reported.VerticalRate != -1408 || *absent.VerticalRate != -1344 {
+		t.Errorf("unexpected vertical rates %d and %d", *reported.VerticalRate, *absent.VerticalRate)
+	}
+	if absent.IndicatedAirSpeed != nil || absent.Mach != nil || absent.SelectedAltitude != nil || *absent.QNH != 1013.6 {
+		t.Errorf("expected the telemetry that isn't reported to be nil, got %+v", absent)
+	}
+}
+
+func TestAircraftAreFilteredByConditions(t *testing.T) {
+	err := SetupConditions(configuration.Config{NotifyConditions: []string{"vertical_rate<-3000", "selected_altitude < 2000"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { notifyConditions = nil }()
+
+	descent, climb, approach := -3500, 1500, 1500
+	aircraft := []Aircraft{
+		{ICAO: "descending", VerticalRate: &descent, SelectedAltitude: &approach},
+		{ICAO: "climbing", VerticalRate: &climb, SelectedAltitude: &approach},
+		// Without an autopilot setting the condition on it can't be met
+		{ICAO: "unknown", VerticalRate: &descent},
+	}
+
+	filtered := filterAircraftByConditions(aircraft, notifyConditions)
+	if len(filtered) != 1 || filtered[0].ICAO != "descending" {
+		t.Errorf("expected only the descending aircraft, got %+v", filtered)
+	}
+
+	for _, invalid := range []string{"airspeed>200", "mach>=fast", "vertical_rate"} {
+		if err := SetupConditions(configuration.Config{NotifyConditions: []string{invalid}}); err == nil {
+			t.Errorf("expected an error for %q", invalid)
+		}
+	}
+}
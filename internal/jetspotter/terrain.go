@@ -0,0 +1,126 @@
+package jetspotter
+
+import (
+	"log"
+	"math"
+
+	"jetspotter/internal/configuration"
+	"jetspotter/internal/geocode"
+	"jetspotter/internal/terrain"
+)
+
+// terrainModel is the elevation of the terrain, unknown until SetupTerrain is called with a terrain directory
+var terrainModel *terrain.Model
+
+// airportElevations are the airports of the standing data with their elevation
+var airportElevations *geocode.Index
+
+const (
+	// Distance in kilometers to an airport within which its elevation is used as the elevation of the ground.
+	// Elevation models measure the surface including the buildings, the elevation of an airport is the one of its runways.
+	airportGroundKilometers = 3
+	// Aircraft that are this close to the ground and slower than taxiing speed are considered to be on the ground.
+	// Barometric altitudes aren't corrected for the air pressure, so they are off by a few hundred feet.
+	onGroundMarginFeet    = 200
+	onGroundMaxSpeedKnots = 50
+)
+
+// SetupTerrain loads the elevation data of the terrain directory and the airports of the standing data.
+func SetupTerrain(config configuration.Config) error {
+	terrainModel, airportElevations = nil, nil
+
+	if config.TerrainDirectory != "" {
+		model, err := terrain.Load(config.TerrainDirectory)
+		if err != nil {
+			return err
+		}
+		log.Printf("Loaded %d terrain tiles from %s", model.Len(), config.TerrainDirectory)
+		terrainModel = model
+	}
+
+	if config.StandingDataDirectory != "" {
+		db, err := loadStandingData(config.StandingDataDirectory)
+		if err != nil {
+			return err
+		}
+		airportElevations = geocode.NewIndex()
+		for _, airport := range db.Airports() {
+			if airport.AltitudeFeet == 0 {
+				continue
+			}
+			airportElevations.Add(geocode.Place{
+				Name:      airport.Name,
+				Kind:      geocode.Airport,
+				Latitude:  airport.Latitude,
+				Longitude: airport.Longitude,
+				Elevation: airport.AltitudeFeet,
+			})
+		}
+	}
+
+	return nil
+}
+
+// groundElevation returns the elevation of the ground in feet at the position, based on the nearby airport or the terrain
+func groundElevation(lat, lon float64) (float64, bool) {
+	if airportElevations != nil {
+		if airport, _, found := airportElevations.Nearest(lat, lon, airportGroundKilometers, geocode.Airport); found {
+			return float64(airport.Elevation), true
+		}
+	}
+
+	meters, ok := terrainModel.Elevation(lat, lon)
+	if !ok {
+		return 0, false
+	}
+	return math.Round(meters / 0.3048), true
+}
+
+// isOnGround checks whether the aircraft is on the ground. Aircraft without an altitude report being on the ground,
+// others are on the ground if they are slow and at the elevation of the ground.
+func isOnGround(ac Aircraft) bool {
+	if ac.Altitude == 0 {
+		return true
+	}
+	if ac.Speed >= onGroundMaxSpeedKnots {
+		return false
+	}
+
+	ground, ok := groundElevation(ac.Latitude, ac.Longitude)
+	return ok && ac.Altitude-ground < onGroundMarginFeet
+}
+
+// updateTerrain sets the elevation of the ground below the estimated position and the height of the aircraft above it
+func updateTerrain(ac *Aircraft, lat, lon float64) {
+	ac.GroundElevation, ac.HeightAboveGround = nil, nil
+
+	ground, ok := groundElevation(lat, lon)
+	if !ok {
+		return
+	}
+
+	height := math.Max(0, ac.EstimatedAltitude-ground)
+	if ac.OnGround {
+		height = 0
+	}
+	ac.GroundElevation, ac.HeightAboveGround = &ground, &height
+}
+
+// heightAboveGround returns the height of the aircraft above the ground, or its altitude if the terrain isn't known
+func heightAboveGround(ac Aircraft) float64 {
+	if ac.HeightAboveGround != nil {
+		return *ac.HeightAboveGround
+	}
+	return ac.Altitude
+}
+
+func filterAircraftByHeightAboveGround(aircraft []Aircraft, maxHeightFeet int) []Aircraft {
+	var filteredAircraft []Aircraft
+
+	for _, ac := range aircraft {
+		if int(heightAboveGround(ac)) <= maxHeightFeet {
+			filteredAircraft = append(filteredAircraft, ac)
+		}
+	}
+	return filteredAircraft
+}
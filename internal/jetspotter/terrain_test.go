@@ -0,0 +1,71 @@
+package jetspotter
+
+import (
+	"testing"
+	"time"
+
+	"jetspotter/internal/configuration"
+	"jetspotter/internal/terrain"
+
+	"github.com/jftuga/geodist"
+)
+
+// hills is terrain around Kleine-Brogel that rises from 0 m in the west to 1000 m in the east
+func hills() *terrain.Model {
+	model := &terrain.Model{}
+	model.Add(&terrain.Grid{
+		North: 52, West: 5, LatitudeStep: 1, LongitudeStep: 1, Rows: 2, Columns: 2,
+		Samples: []int16{0, 1000, 0, 1000},
+	})
+	return model
+}
+
+func TestHeightAboveGround(t *testing.T) {
+	terrainModel = hills()
+	defer func() { terrainModel = nil }()
+
+	location := geodist.Coord{Lat: 51.17, Lon: 5.47}
+
+	// Halfway the terrain is 500 m or 1640 ft high
+	ac := Aircraft{Latitude: 51.5, Longitude: 5.5, Altitude: 2640, Speed: 120}
+	ac.OnGround = isOnGround(ac)
+	UpdateEstimate(&ac, location, time.Now(), 0)
+	updateSurroundings(&ac)
+	if ac.OnGround || ac.GroundElevation == nil || *ac.GroundElevation != 1640 || *ac.HeightAboveGround != 1000 {
+		t.Errorf("expected 1000 ft above 1640 ft high terrain, got %+v", ac)
+	}
+
+	// Taxiing on a hill, while the barometric altitude isn't 0
+	taxiing := Aircraft{Latitude: 51.5, Longitude: 5.5, Altitude: 1700, Speed: 15}
+	if !isOnGround(taxiing) {
+		t.Error("expected a slow aircraft at the elevation of the ground to be on the ground")
+	}
+
+	filtered := filterAircraftByHeightAboveGround([]Aircraft{ac, {Altitude: 800}}, 900)
+	if len(filtered) != 1 || filtered[0].Altitude != 800 {
+		t.Errorf("expected only the aircraft below 900 ft to remain, got %+v", filtered)
+	}
+
+	// Outside of the terrain data the height isn't known
+	far := Aircraft{Latitude: 48.85, Longitude: 2.35, Altitude: 3000, Speed: 200}
+	UpdateEstimate(&far, location, time.Now(), 0)
+	if far.HeightAboveGround != nil {
+		t.Errorf("expected no height above ground without terrain data, got %v", *far.HeightAboveGround)
+	}
+}
+
+func TestAirportElevationIsUsedForTheGround(t *testing.T) {
+	err := SetupTerrain(configuration.Config{StandingDataDirectory: "../standingdata/testdata"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { airportElevations = nil }()
+
+	// Heathrow is 83 ft above sea level
+	if !isOnGround(Aircraft{Latitude: 51.4706, Longitude: -0.4619, Altitude: 100, Speed: 10}) {
+		t.Error("expected an aircraft taxiing at Heathrow to be on the ground")
+	}
+	if isOnGround(Aircraft{Latitude: 51.4706, Longitude: -0.4619, Altitude: 1000, Speed: 10}) {
+		t.Error("expected a helicopter hovering above Heathrow not to be on the ground")
+	}
+}
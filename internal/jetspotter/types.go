@@ -1,5 +1,7 @@
 package jetspotter
 
+import "jetspotter/internal/weather"
+
 // FlightData is a struct of the json received by the ADS-B api
 type FlightData struct {
 	// A slice of aircrafts
@@ -25,6 +27,10 @@ type AircraftRaw struct {
 	// Type of the aircraft
 	PlaneType string `json:"t"`
 	Desc      string `json:"desc"`
+	// Owner or operator of the aircraft
+	OwnOp string `json:"ownOp"`
+	// Year in which the aircraft was built
+	Year string `json:"year"`
 	// Barometric altitude in feet
 	AltBaro interface{} `json:"alt_baro"`
 	// Geometric (GNSS / INS) altitude in feet referenced to the WGS84 ellipsoid
@@ -32,31 +38,37 @@ type AircraftRaw struct {
 	// Ground speed in knots
 	GS float64 `json:"gs"`
 	// Indicated air speed in knots
-	IAS int `json:"ias"`
+	IAS *int `json:"ias"`
 	// True air speed in knots
-	TAS int `json:"tas"`
+	TAS *int `json:"tas"`
 	// Mach number
-	Mach      float64 `json:"mach"`
-	WD        int     `json:"wd"`
-	WS        int     `json:"ws"`
-	OAT       int     `json:"oat"`
-	TAT       int     `json:"tat"`
+	Mach *float64 `json:"mach"`
+	// Wind direction in degrees and speed in knots, calculated from the ground speed, air speed and heading
+	WD *int `json:"wd"`
+	WS *int `json:"ws"`
+	// Outside and total air temperature in degrees Celsius, calculated from the Mach number and air speed
+	OAT       *int    `json:"oat"`
+	TAT       *int    `json:"tat"`
 	Track     float64 `json:"track"`
 	TrackRate float64 `json:"track_rate"`
 	Roll      float64 `json:"roll"`
 	// Heading, degrees clockwise from magnetic north
 	MagHeading  float64 `json:"mag_heading"`
 	TrueHeading float64 `json:"true_heading"`
-	BaroRate    int     `json:"baro_rate"`
-	GeomRate    int     `json:"geom_rate"`
+	// Rate of change of the barometric and geometric altitude in feet per minute
+	BaroRate *int `json:"baro_rate"`
+	GeomRate *int `json:"geom_rate"`
 	// Mode A code (Squawk), encoded as 4 octal digits
-	Squawk     string  `json:"squawk"`
-	Emergency  string  `json:"emergency"`
-	Category   string  `json:"category"`
-	NavQNH     float64 `json:"nav_qnh"`
-	NavAltMCP  int     `json:"nav_altitude_mcp"`
-	NavAltFMS  int     `json:"nav_altitude_fms"`
-	NavHeading float64 `json:"nav_heading"`
+	Squawk    string `json:"squawk"`
+	Emergency string `json:"emergency"`
+	Category  string `json:"category"`
+	// Altimeter setting in hPa that is selected in the cockpit
+	NavQNH *float64 `json:"nav_qnh"`
+	// Altitude in feet that is selected on the autopilot and in the flight management system
+	NavAltMCP *int `json:"nav_altitude_mcp"`
+	NavAltFMS *int `json:"nav_altitude_fms"`
+	// Heading in degrees that is selected on the autopilot
+	NavHeading *float64 `json:"nav_heading"`
 	// Aircraft latitude position in decimal degrees
 	Lat float64 `json:"lat"`
 	// Aircraft longitude position in decimal degrees
@@ -93,6 +105,9 @@ type Aircraft struct {
 	// Callsign or flight name of the aircraft, if not set 'NONE' is used
 	Callsign string
 
+	// Callsign as it is spoken on the radio, for example "REACH 456" for RCH456, empty if the operator is unknown
+	SpokenCallsign string
+
 	// Description of the aircraft
 	Description string
 
@@ -108,25 +123,120 @@ type Aircraft struct {
 	// Registration number of the aircraft
 	Registration string
 
-	// Country of the aircraft based on the registration prefix
+	// Country of the aircraft based on the block of its ICAO address, or on the registration prefix
 	Country string
 
+	// ISO 3166-1 alpha-2 code of the country of the aircraft, empty if unknown
+	CountryISO string
+
+	// Emoji flag of the country of the aircraft, empty if unknown
+	CountryFlag string
+
+	// Owner or operator of the aircraft
+	Operator string
+
+	// Year in which the aircraft was built
+	Year string
+
 	// Alitude of the aircraft in feet
 	Altitude float64
 
 	// Speed in knots
 	Speed int
 
-	// Distance between the specified location and the location of the aircraft in kilometers
+	// Distance between the specified location and the estimated location of the aircraft in kilometers
 	// Height is not taken into consideration
 	Distance int
 
+	// Description of the estimated position relative to the nearby places, for example "over Leopoldsburg, 4 km NE of Kleine Brogel AB".
+	// Empty if no GeoNames dump is configured or there are no places nearby.
+	Place string
+
+	// Elevation in feet of the ground below the estimated position, based on the terrain and the nearby airports.
+	// Nil if no terrain data covers the position.
+	GroundElevation *float64
+
+	// Estimated height in feet of the aircraft above the ground, nil if the elevation of the ground isn't known
+	HeightAboveGround *float64
+
+	// Indicated air speed in knots, nil if it isn't reported
+	IndicatedAirSpeed *int
+
+	// True air speed in knots, nil if it isn't reported
+	TrueAirSpeed *int
+
+	// Mach number, nil if it isn't reported
+	Mach *float64
+
+	// Vertical rate in feet per minute, negative when descending, nil if it isn't reported
+	VerticalRate *int
+
+	// Direction in degrees that the wind at the aircraft blows from, nil if it isn't reported
+	WindDirection *int
+
+	// Speed in knots of the wind at the aircraft, nil if it isn't reported
+	WindSpeed *int
+
+	// Outside air temperature in degrees Celsius, nil if it isn't reported
+	OutsideAirTemperature *int
+
+	// Total air temperature in degrees Celsius, nil if it isn't reported
+	TotalAirTemperature *int
+
+	// Altitude in feet that is selected on the autopilot, nil if it isn't reported
+	SelectedAltitude *int
+
+	// Altitude in feet that is set in the flight management system, nil if it isn't reported
+	FMSAltitude *int
+
+	// Heading in degrees that is selected on the autopilot, nil if it isn't reported
+	SelectedHeading *float64
+
+	// Altimeter setting in hPa that is selected in the cockpit, nil if it isn't reported
+	QNH *float64
+
+	// Names of the airspaces that the aircraft is in at its estimated position and altitude
+	Airspaces []string
+
+	// Estimated current latitude of the aircraft, extrapolated from the last reported position
+	EstimatedLatitude float64
+
+	// Estimated current longitude of the aircraft, extrapolated from the last reported position
+	EstimatedLongitude float64
+
+	// Estimated current altitude of the aircraft in feet, extrapolated from the vertical rate
+	EstimatedAltitude float64
+
+	// Age of the last reported position in seconds
+	PositionAge float64
+
+	// Source of the position of the aircraft: ADS-B, ADS-R, TIS-B, ADS-C, MLAT, Mode S or Other
+	Source string
+
+	// Estimated accuracy of the reported position in meters, derived from the NACp, 0 if it is unknown
+	PositionAccuracy float64
+
+	// Specifies if the position is older than the maximum age of positions
+	PositionStale bool
+
+	// Specifies if the position is less accurate than the maximum error of positions
+	PositionInaccurate bool
+
+	// Distance in kilometers between the specified location and the aircraft at its closest point of approach
+	CPADistance int
+
+	// Seconds until the aircraft reaches its closest point of approach, 0 if it is moving away
+	CPASeconds int
+
 	// TrackerURL is to URL track the aircraft using the ADS-B website
 	TrackerURL string
 
 	// ImageThumbnailURL is the URL for the thumbnail of the aircraft
 	ImageThumbnailURL string
 
+	// ImageThumbnailPath is the path under which jetspotter serves the thumbnail itself, empty if it isn't cached
+	ImageThumbnailPath string
+
 	// ImageURL is the URL showing more images of the aircraft
 	ImageURL string
 
@@ -136,6 +246,33 @@ type Aircraft struct {
 	// Percentage of cloud coverage, lower cloud coverage means that you have more chance to spot the aircraft
 	CloudCoverage int
 
+	// Estimated height of the cloud base above the ground in feet, 0 if unknown
+	CloudBase int
+
+	// Specifies if the aircraft likely leaves a contrail, based on the temperature and humidity at its altitude
+	ContrailLikely bool
+
+	// Specifies if the contrail likely persists and spreads, because the air is saturated with respect to ice
+	ContrailPersistent bool
+
+	// Visibility at the ground in meters
+	Visibility int
+
+	// Precipitation during the current hour in millimeters
+	Precipitation float64
+
+	// Conditions reported by the METAR or TAF of the nearby station, for example "cloud base 1,200 ft, vis 8 km, -RA"
+	Conditions string
+
+	// Station and type of report of the conditions, for example "EBBL METAR"
+	ConditionsSource string
+
+	// Ceiling in feet above the station according to the METAR or TAF, 0 if there is no ceiling
+	Ceiling int
+
+	// Runway that is likely in use at the station based on the reported wind
+	Runway string
+
 	// Bearing from your location to the aircraft
 	BearingFromLocation float64
 
@@ -162,6 +299,27 @@ type Aircraft struct {
 
 	// Destination of the flight
 	Destination Airport
+
+	// Intermediate stops of the flight between the origin and the destination
+	Stops []Airport
+
+	// Percentage of the route from the origin to the destination that has been flown
+	RouteProgress int
+
+	// Distance along the route that has been flown in kilometers
+	DistanceFlown int
+
+	// Distance along the route that remains to the destination in kilometers
+	DistanceRemaining int
+
+	// Estimated number of seconds until the aircraft arrives at its destination at its current ground speed, 0 if unknown
+	ETASeconds int
+
+	// Kinematics used to extrapolate the position of the aircraft
+	motion motion
+
+	// Forecast of the weather at the aircraft, with the temperature and humidity aloft
+	forecast weather.Conditions
 }
 
 // FlightRouteResponse represents the structure of the response from the adsbdb.com API
@@ -179,6 +337,8 @@ type FlightRoute struct {
 	Airline      Airline `json:"airline"`
 	Origin       Airport `json:"origin"`
 	Destination  Airport `json:"destination"`
+	// Intermediate stops between the origin and the destination
+	Stops []Airport `json:"stops,omitempty"`
 }
 
 // Airline contains information about an airline
@@ -189,6 +349,9 @@ type Airline struct {
 	Country    string `json:"country"`
 	CountryISO string `json:"country_iso"`
 	Callsign   string `json:"callsign"`
+	// Military is true if the operator is a military operator, such as an air force.
+	// The aircraft itself is only marked as military based on the ADS-B data.
+	Military bool `json:"military"`
 }
 
 // Airport contains information about an airport
@@ -0,0 +1,90 @@
+package jetspotter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"jetspotter/internal/configuration"
+	"jetspotter/internal/weather"
+
+	"github.com/jftuga/geodist"
+)
+
+// weatherProvider provides the weather of the aircraft, no weather is looked up until SetupWeather is called.
+var weatherProvider weather.Provider
+
+// SetupWeather configures the provider of the weather forecast.
+func SetupWeather(config configuration.Config) error {
+	var provider weather.Provider
+	switch config.WeatherProvider {
+	case "open-meteo":
+		provider = weather.NewOpenMeteo()
+	case "file":
+		if config.WeatherFile == "" {
+			return fmt.Errorf("%s must be set when %s is file", configuration.WeatherFile, configuration.WeatherProvider)
+		}
+		provider = weather.NewFile(config.WeatherFile)
+	default:
+		return fmt.Errorf("unknown weather provider %q", config.WeatherProvider)
+	}
+
+	weatherProvider = weather.NewCached(provider, config.WeatherGridDegrees, time.Duration(config.NegativeCacheTTLMinutes)*time.Minute)
+	return nil
+}
+
+// applyWeather adds the weather at the position of the aircraft at the given time.
+// If no grid is configured, the weather at the configured location is used.
+func applyWeather(ctx context.Context, ac *Aircraft, config configuration.Config, now time.Time) error {
+	if weatherProvider == nil {
+		return nil
+	}
+
+	location := config.Location
+	if config.WeatherGridDegrees > 0 {
+		location = geodist.Coord{Lat: ac.Latitude, Lon: ac.Longitude}
+	}
+
+	forecast, err := weatherProvider.Forecast(ctx, location)
+	if errors.Is(err, weather.ErrPending) {
+		// The weather is added once the forecast is in
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	conditions, found := forecast.At(now)
+	if !found {
+		return fmt.Errorf("no forecast available for %s", now.UTC().Format(time.RFC3339))
+	}
+
+	ac.CloudCoverage = getCloudCoverage(conditions, ac.Altitude)
+	ac.CloudBase = int(conditions.CloudBaseFeet)
+	ac.Visibility = int(conditions.VisibilityMeters)
+	ac.Precipitation = conditions.PrecipitationMillimeters
+	ac.forecast = conditions
+	return nil
+}
+
+// getCloudCoverage gets the coverage percentage of the clouds at a given altitude block
+// Altitude blocks are one of the following
+// low    -> 0m up to 3000m
+// medium -> 3000m up to 8000m
+// high   -> above 8000m
+func getCloudCoverage(conditions weather.Conditions, altitudeInFeet float64) (cloudCoveragePercentage int) {
+
+	altitudeInMeters := ConvertFeetToMeters(altitudeInFeet)
+
+	switch {
+	case altitudeInMeters < 3000:
+		return conditions.CloudCoverLow
+	case altitudeInMeters >= 3000 && altitudeInMeters < 8000:
+		return getHighestValue(conditions.CloudCoverLow, conditions.CloudCoverMid)
+	default:
+		return getHighestValue(conditions.CloudCoverLow,
+			conditions.CloudCoverMid,
+			conditions.CloudCoverHigh)
+	}
+}
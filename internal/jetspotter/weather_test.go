@@ -0,0 +1,70 @@
+package jetspotter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"jetspotter/internal/configuration"
+	"jetspotter/internal/weather"
+
+	"github.com/jftuga/geodist"
+)
+
+type fakeWeather struct {
+	locations []geodist.Coord
+}
+
+func (f *fakeWeather) Forecast(ctx context.Context, location geodist.Coord) (*weather.Forecast, error) {
+	f.locations = append(f.locations, location)
+	return &weather.Forecast{Hours: []weather.Conditions{{
+		Time:           time.Now().UTC().Truncate(time.Hour),
+		CloudCoverLow:  10,
+		CloudCoverMid:  60,
+		CloudCoverHigh: 90,
+		CloudBaseFeet:  3000,
+	}}}, nil
+}
+
+func TestApplyWeather(t *testing.T) {
+	fake := &fakeWeather{}
+	weatherProvider = fake
+	defer func() { weatherProvider = nil }()
+
+	config := configuration.Config{
+		Location:           geodist.Coord{Lat: 51.17, Lon: 5.46},
+		WeatherGridDegrees: 0.5,
+	}
+
+	ac := Aircraft{Latitude: 50.9, Longitude: 4.4, Altitude: 15000}
+	err := applyWeather(context.Background(), &ac, config, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ac.CloudCoverage != 60 || ac.CloudBase != 3000 {
+		t.Fatalf("expected cloud coverage of 60%% and cloud base of 3000ft, got %d%% and %dft", ac.CloudCoverage, ac.CloudBase)
+	}
+
+	if fake.locations[0].Lat != 50.9 || fake.locations[0].Lon != 4.4 {
+		t.Fatalf("expected the weather to be looked up at the aircraft, got %v", fake.locations[0])
+	}
+
+	config.WeatherGridDegrees = 0
+	applyWeather(context.Background(), &ac, config, time.Now())
+	if fake.locations[1] != config.Location {
+		t.Fatalf("expected the weather to be looked up at the location without a grid, got %v", fake.locations[1])
+	}
+}
+
+func TestGetCloudCoverage(t *testing.T) {
+	conditions := weather.Conditions{CloudCoverLow: 40, CloudCoverMid: 20, CloudCoverHigh: 70}
+
+	if coverage := getCloudCoverage(conditions, 5000); coverage != 40 {
+		t.Fatalf("expected low cloud coverage of 40%%, got %d%%", coverage)
+	}
+
+	if coverage := getCloudCoverage(conditions, 35000); coverage != 70 {
+		t.Fatalf("expected cloud coverage of 70%% at high altitude, got %d%%", coverage)
+	}
+}
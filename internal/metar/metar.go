@@ -0,0 +1,268 @@
+package metar
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cloud is a cloud layer of a report
+type Cloud struct {
+	// FEW, SCT, BKN, OVC or VV for vertical visibility
+	Cover string
+	// Height of the base of the layer above the station in feet, -1 if unknown
+	BaseFeet int
+	// CB or TCU for convective clouds
+	Type string
+}
+
+// Report contains the conditions of a METAR or of a TAF period
+type Report struct {
+	// ICAO code of the station
+	Station string
+	// Time of the observation
+	Time time.Time
+	// Original text of the report
+	Raw string
+	// Direction the wind is blowing from in degrees, -1 if variable
+	WindDirection int
+	// Wind speed in knots
+	WindSpeed int
+	// Wind gusts in knots, 0 if there are no gusts
+	WindGust int
+	// Prevailing visibility in meters, -1 if not reported
+	VisibilityMeters int
+	// Weather phenomena such as -RA or +TSRA
+	Weather []string
+	// Cloud layers from low to high
+	Clouds []Cloud
+	// Ceiling, visibility OK
+	CAVOK bool
+	// Explicitly reported that there are no clouds
+	NoClouds bool
+	// Temperature in degrees Celsius, nil if not reported
+	Temperature *int
+	// Dew point in degrees Celsius, nil if not reported
+	DewPoint *int
+}
+
+var (
+	stationPattern     = regexp.MustCompile(`^[A-Z][A-Z0-9]{3}$`)
+	timePattern        = regexp.MustCompile(`^(\d{2})(\d{2})(\d{2})Z$`)
+	windPattern        = regexp.MustCompile(`^(\d{3}|VRB)(\d{2,3})(?:G(\d{2,3}))?(KT|MPS|KMH)$`)
+	variationPattern   = regexp.MustCompile(`^\d{3}V\d{3}$`)
+	visibilityPattern  = regexp.MustCompile(`^(\d{4})(NDV|[NSEW]{1,2})?$`)
+	milesPattern       = regexp.MustCompile(`^(P|M)?(?:(\d+)|(\d+)/(\d+))SM$`)
+	rvrPattern         = regexp.MustCompile(`^R\d{2}[LCR]?/`)
+	weatherPattern     = regexp.MustCompile(`^(?:[-+]|VC)?(?:MI|PR|BC|DR|BL|SH|TS|FZ)?(?:DZ|RA|SN|SG|IC|PL|GR|GS|UP|BR|FG|FU|VA|DU|SA|HZ|PY|PO|SQ|FC|SS|DS)*$`)
+	cloudPattern       = regexp.MustCompile(`^(FEW|SCT|BKN|OVC|VV)(\d{3}|///)(CB|TCU|///)?$`)
+	temperaturePattern = regexp.MustCompile(`^(M?\d{2})/(M?\d{2})?$`)
+)
+
+// Parse parses the text of a METAR or SPECI, the day of the observation is resolved relative to reference.
+func Parse(raw string, reference time.Time) (Report, error) {
+	report := Report{Raw: strings.TrimSpace(raw), WindDirection: -1, VisibilityMeters: -1}
+
+	tokens := strings.Fields(strings.TrimSuffix(report.Raw, "="))
+	for len(tokens) > 0 && (tokens[0] == "METAR" || tokens[0] == "SPECI" || tokens[0] == "COR") {
+		tokens = tokens[1:]
+	}
+
+	if len(tokens) < 2 || !stationPattern.MatchString(tokens[0]) {
+		return Report{}, fmt.Errorf("not a METAR: %q", report.Raw)
+	}
+	report.Station = tokens[0]
+
+	match := timePattern.FindStringSubmatch(tokens[1])
+	if match == nil {
+		return Report{}, fmt.Errorf("METAR of %s has no observation time: %q", report.Station, report.Raw)
+	}
+	report.Time = resolveTime(reference, atoi(match[1]), atoi(match[2]), atoi(match[3]))
+
+	for _, token := range tokens[2:] {
+		// Trends and remarks are not part of the observation
+		if token == "RMK" || token == "NOSIG" || token == "BECMG" || token == "TEMPO" {
+			break
+		}
+		report.parseToken(token)
+	}
+
+	return report, nil
+}
+
+// parseToken parses a single group of a METAR or TAF, unknown groups are ignored.
+// It returns false if the token isn't a weather group.
+func (r *Report) parseToken(token string) bool {
+	switch {
+	case token == "CAVOK":
+		r.CAVOK = true
+		r.VisibilityMeters = 10000
+	case token == "NSC" || token == "SKC" || token == "CLR" || token == "NCD":
+		r.NoClouds = true
+	case token == "NSW":
+		// Not nil, so that it replaces the weather of the base forecast
+		r.Weather = []string{}
+	case windPattern.MatchString(token):
+		match := windPattern.FindStringSubmatch(token)
+		r.WindDirection = -1
+		if match[1] != "VRB" {
+			r.WindDirection = atoi(match[1])
+		}
+		r.WindSpeed = toKnots(atoi(match[2]), match[4])
+		r.WindGust = 0
+		if match[3] != "" {
+			r.WindGust = toKnots(atoi(match[3]), match[4])
+		}
+	case visibilityPattern.MatchString(token):
+		visibility := atoi(visibilityPattern.FindStringSubmatch(token)[1])
+		if visibility == 9999 {
+			visibility = 10000
+		}
+		r.VisibilityMeters = visibility
+	case milesPattern.MatchString(token):
+		match := milesPattern.FindStringSubmatch(token)
+		miles := float64(atoi(match[2]))
+		if match[3] != "" {
+			miles = float64(atoi(match[3])) / float64(max(1, atoi(match[4])))
+		}
+		// Whole miles that preceded the fraction, such as 1 1/2SM
+		if r.VisibilityMeters > 0 && r.VisibilityMeters < 16 && match[3] != "" {
+			miles += float64(r.VisibilityMeters)
+		}
+		r.VisibilityMeters = int(miles * 1609.344)
+	case cloudPattern.MatchString(token):
+		match := cloudPattern.FindStringSubmatch(token)
+		cloud := Cloud{Cover: match[1], BaseFeet: -1}
+		if match[2] != "///" {
+			cloud.BaseFeet = atoi(match[2]) * 100
+		}
+		if match[3] != "///" {
+			cloud.Type = match[3]
+		}
+		r.Clouds = append(r.Clouds, cloud)
+	case temperaturePattern.MatchString(token):
+		match := temperaturePattern.FindStringSubmatch(token)
+		temperature := parseTemperature(match[1])
+		r.Temperature = &temperature
+		if match[2] != "" {
+			dewPoint := parseTemperature(match[2])
+			r.DewPoint = &dewPoint
+		}
+	case len(token) == 1 && token[0] >= '1' && token[0] <= '9':
+		// Whole miles of a visibility such as 1 1/2SM, completed by the fraction that follows
+		r.VisibilityMeters = atoi(token)
+	case variationPattern.MatchString(token), rvrPattern.MatchString(token), token == "AUTO":
+	case token != "" && weatherPattern.MatchString(token) && len(token) >= 2:
+		r.Weather = append(r.Weather, token)
+	default:
+		return false
+	}
+	return true
+}
+
+// Ceiling returns the height in feet of the lowest broken or overcast layer, or of the vertical visibility.
+// It returns 0 if there is no ceiling.
+func (r Report) Ceiling() int {
+	for _, cloud := range r.Clouds {
+		if (cloud.Cover == "BKN" || cloud.Cover == "OVC" || cloud.Cover == "VV") && cloud.BaseFeet >= 0 {
+			return cloud.BaseFeet
+		}
+	}
+	return 0
+}
+
+// CloudBase returns the height in feet of the lowest cloud layer, or 0 if there are no clouds.
+func (r Report) CloudBase() int {
+	for _, cloud := range r.Clouds {
+		if cloud.BaseFeet >= 0 {
+			return cloud.BaseFeet
+		}
+	}
+	return 0
+}
+
+// Summary describes the conditions in a few words, such as "cloud base 1,200 ft, vis 8 km, -RA".
+func (r Report) Summary() string {
+	if r.CAVOK {
+		return "CAVOK"
+	}
+
+	var parts []string
+	if base := r.CloudBase(); base > 0 {
+		parts = append(parts, fmt.Sprintf("cloud base %s ft", formatThousands(base)))
+	} else if r.NoClouds {
+		parts = append(parts, "no clouds")
+	}
+
+	switch {
+	case r.VisibilityMeters >= 10000:
+		parts = append(parts, "vis 10+ km")
+	case r.VisibilityMeters >= 5000:
+		parts = append(parts, fmt.Sprintf("vis %d km", r.VisibilityMeters/1000))
+	case r.VisibilityMeters >= 0:
+		parts = append(parts, fmt.Sprintf("vis %d m", r.VisibilityMeters))
+	}
+
+	parts = append(parts, r.Weather...)
+	return strings.Join(parts, ", ")
+}
+
+// resolveTime returns the time with the given day of the month, hour and minute that is closest to the reference.
+// Reports only contain the day of the month, so the month and year are taken from the reference.
+func resolveTime(reference time.Time, day, hour, minute int) time.Time {
+	reference = reference.UTC()
+	var best time.Time
+	for _, months := range []int{-1, 0, 1} {
+		month := time.Date(reference.Year(), reference.Month()+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+		candidate := time.Date(month.Year(), month.Month(), day, hour, minute, 0, 0, time.UTC)
+		// Skip days that don't exist in the month, such as the 31st of April
+		if candidate.Month() != month.Month() && !(hour == 24 && candidate.Day() == 1) {
+			continue
+		}
+		if best.IsZero() || absDuration(candidate.Sub(reference)) < absDuration(best.Sub(reference)) {
+			best = candidate
+		}
+	}
+	return best
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+func toKnots(speed int, unit string) int {
+	switch unit {
+	case "MPS":
+		return int(float64(speed)*1.94384 + 0.5)
+	case "KMH":
+		return int(float64(speed)/1.852 + 0.5)
+	default:
+		return speed
+	}
+}
+
+func parseTemperature(value string) int {
+	if strings.HasPrefix(value, "M") {
+		return -atoi(value[1:])
+	}
+	return atoi(value)
+}
+
+func atoi(value string) int {
+	number, _ := strconv.Atoi(value)
+	return number
+}
+
+// formatThousands formats a number with a comma as thousands separator
+func formatThousands(number int) string {
+	text := strconv.Itoa(number)
+	for i := len(text) - 3; i > 0; i -= 3 {
+		text = text[:i] + "," + text[i:]
+	}
+	return text
+}
@@ -0,0 +1,141 @@
+package metar
+
+import (
+	"testing"
+	"time"
+)
+
+var reference = time.Date(2024, 6, 18, 12, 0, 0, 0, time.UTC)
+
+func TestParseMetar(t *testing.T) {
+	report, err := Parse("METAR EBBL 181150Z AUTO 24012G25KT 210V270 8000 -RA FEW012 BKN025CB OVC080 14/11 Q1012 NOSIG=", reference)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Station != "EBBL" || !report.Time.Equal(time.Date(2024, 6, 18, 11, 50, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected station %s or time %s", report.Station, report.Time)
+	}
+
+	if report.WindDirection != 240 || report.WindSpeed != 12 || report.WindGust != 25 {
+		t.Fatalf("unexpected wind %d/%dG%d", report.WindDirection, report.WindSpeed, report.WindGust)
+	}
+
+	if report.VisibilityMeters != 8000 || len(report.Weather) != 1 || report.Weather[0] != "-RA" {
+		t.Fatalf("unexpected visibility %d or weather %v", report.VisibilityMeters, report.Weather)
+	}
+
+	if report.Ceiling() != 2500 || report.CloudBase() != 1200 || report.Clouds[1].Type != "CB" {
+		t.Fatalf("unexpected clouds %+v", report.Clouds)
+	}
+
+	if *report.Temperature != 14 || *report.DewPoint != 11 {
+		t.Fatalf("unexpected temperature %d/%d", *report.Temperature, *report.DewPoint)
+	}
+
+	expected := "cloud base 1,200 ft, vis 8 km, -RA"
+	if report.Summary() != expected {
+		t.Fatalf("expected summary %q, got %q", expected, report.Summary())
+	}
+}
+
+func TestParseMetarStatuteMiles(t *testing.T) {
+	report, err := Parse("KJFK 181151Z VRB03KT 1 1/2SM BR VV004 M01/M02 A2992", reference)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.VisibilityMeters != 2414 {
+		t.Fatalf("expected visibility of 2414m, got %d", report.VisibilityMeters)
+	}
+
+	if report.WindDirection != -1 || report.Ceiling() != 400 || *report.Temperature != -1 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+}
+
+func TestParseMetarCAVOK(t *testing.T) {
+	report, err := Parse("EHEH 181155Z 05008KT CAVOK 21/09 Q1020", reference)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Summary() != "CAVOK" || report.Ceiling() != 0 {
+		t.Fatalf("unexpected summary %q", report.Summary())
+	}
+}
+
+func TestParseInvalidMetar(t *testing.T) {
+	if _, err := Parse("No data", reference); err == nil {
+		t.Fatal("expected an error for text that isn't a METAR")
+	}
+}
+
+func TestResolveTimeAcrossMonths(t *testing.T) {
+	actual := resolveTime(time.Date(2024, 7, 1, 0, 30, 0, 0, time.UTC), 30, 23, 50)
+	expected := time.Date(2024, 6, 30, 23, 50, 0, 0, time.UTC)
+	if !actual.Equal(expected) {
+		t.Fatalf("expected %s, got %s", expected, actual)
+	}
+}
+
+func TestTAF(t *testing.T) {
+	taf, err := ParseTAF(`TAF EBBL 181100Z 1812/1918 24010KT 9999 SCT030
+		BECMG 1814/1816 BKN015
+		TEMPO 1816/1820 4000 SHRA BKN008
+		FM190300 30015G28KT 6000 -RA OVC010`, reference)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(taf.Periods) != 4 {
+		t.Fatalf("expected 4 periods, got %d", len(taf.Periods))
+	}
+
+	report, found := taf.At(time.Date(2024, 6, 18, 13, 0, 0, 0, time.UTC))
+	if !found || report.Ceiling() != 0 || report.VisibilityMeters != 10000 {
+		t.Fatalf("expected the base forecast, got %+v", report)
+	}
+
+	// Gradual change applies, the temporary one doesn't
+	report, _ = taf.At(time.Date(2024, 6, 18, 17, 0, 0, 0, time.UTC))
+	if report.Ceiling() != 1500 || report.VisibilityMeters != 10000 || report.WindDirection != 240 {
+		t.Fatalf("expected a ceiling of 1500ft after the gradual change, got %+v", report)
+	}
+
+	report, _ = taf.At(time.Date(2024, 6, 19, 4, 0, 0, 0, time.UTC))
+	if report.Ceiling() != 1000 || report.WindDirection != 300 || report.Summary() != "cloud base 1,000 ft, vis 6 km, -RA" {
+		t.Fatalf("expected the from group to apply, got %+v", report)
+	}
+
+	if _, found := taf.At(time.Date(2024, 6, 20, 0, 0, 0, 0, time.UTC)); found {
+		t.Fatal("expected no conditions after the TAF expired")
+	}
+}
+
+func TestSplitReports(t *testing.T) {
+	reports := SplitReports("EBBL 181150Z 24012KT 9999 FEW030 14/11 Q1012\nEHEH 181155Z 05008KT CAVOK 21/09 Q1020\n\nTAF EBBL 181100Z 1812/1918 24010KT 9999 SCT030\n  BECMG 1814/1816 BKN015\n")
+	if len(reports) != 3 {
+		t.Fatalf("expected 3 reports, got %d: %q", len(reports), reports)
+	}
+
+	if reports[2] != "TAF EBBL 181100Z 1812/1918 24010KT 9999 SCT030 BECMG 1814/1816 BKN015" {
+		t.Fatalf("expected continuation lines to be joined, got %q", reports[2])
+	}
+}
+
+func TestLikelyRunway(t *testing.T) {
+	runways := []string{"05/23", "07L/25R"}
+
+	if runway := LikelyRunway(runways, 255, 12); runway != "25R" {
+		t.Fatalf("expected runway 25R, got %s", runway)
+	}
+
+	if runway := LikelyRunway(runways, 40, 8); runway != "05" {
+		t.Fatalf("expected runway 05, got %s", runway)
+	}
+
+	if runway := LikelyRunway(runways, 240, 2); runway != "" {
+		t.Fatalf("expected no runway for calm wind, got %s", runway)
+	}
+}
@@ -0,0 +1,43 @@
+package metar
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// calmWindKnots is the wind speed below which no runway is preferred
+const calmWindKnots = 3
+
+// LikelyRunway returns the runway end that is most likely in use, which is the one with the most headwind.
+// Runways are written as both ends separated by a slash, such as 07L/25R. Without a preferred direction,
+// because the wind is calm or variable, an empty string is returned.
+func LikelyRunway(runways []string, windDirection, windSpeed int) string {
+	if windDirection < 0 || windSpeed < calmWindKnots {
+		return ""
+	}
+
+	best := ""
+	bestAngle := math.MaxFloat64
+	for _, runway := range runways {
+		for _, end := range strings.Split(runway, "/") {
+			end = strings.TrimSpace(end)
+			heading, err := strconv.Atoi(strings.TrimRight(end, "LCR"))
+			if err != nil || heading < 1 || heading > 36 {
+				continue
+			}
+
+			angle := math.Abs(float64(heading*10 - windDirection))
+			if angle > 180 {
+				angle = 360 - angle
+			}
+
+			if angle < bestAngle {
+				best = end
+				bestAngle = angle
+			}
+		}
+	}
+
+	return best
+}
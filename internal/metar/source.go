@@ -0,0 +1,71 @@
+package metar
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"jetspotter/internal/upstream"
+)
+
+// Fetch returns the text of the reports of the stations from source, which is either a URL or the path of a file.
+// The placeholder {stations} in a URL is replaced by the comma separated list of stations.
+func Fetch(ctx context.Context, source string, stations []string) (string, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+
+	endpoint := strings.ReplaceAll(source, "{stations}", url.QueryEscape(strings.Join(stations, ",")))
+	res, err := upstream.AviationWeather.Get(ctx, endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if res.StatusCode >= 400 {
+		return "", fmt.Errorf("API call to %s returned error: %s", endpoint, res.Status)
+	}
+
+	return string(body), nil
+}
+
+// SplitReports splits text that contains multiple reports into one string per report.
+// Every report starts on a new line, lines that start with whitespace continue the previous report.
+func SplitReports(text string) (reports []string) {
+	var current strings.Builder
+	flush := func() {
+		if report := strings.TrimSpace(current.String()); report != "" {
+			reports = append(reports, report)
+		}
+		current.Reset()
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+
+		if line[0] != ' ' && line[0] != '\t' {
+			flush()
+		}
+
+		current.WriteString(" ")
+		current.WriteString(strings.TrimSpace(line))
+	}
+	flush()
+
+	return reports
+}
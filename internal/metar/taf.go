@@ -0,0 +1,172 @@
+package metar
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Period is a period of a TAF during which the forecast conditions apply
+type Period struct {
+	// Empty for the base forecast, otherwise FM, BECMG, TEMPO or PROB30/PROB40 optionally followed by TEMPO
+	Change string
+	From   time.Time
+	To     time.Time
+	// Only contains the groups that were forecast in this period
+	Report Report
+}
+
+// TAF is a terminal aerodrome forecast
+type TAF struct {
+	Station string
+	Issued  time.Time
+	Raw     string
+	Periods []Period
+}
+
+var (
+	validityPattern = regexp.MustCompile(`^(\d{2})(\d{2})/(\d{2})(\d{2})$`)
+	fromPattern     = regexp.MustCompile(`^FM(\d{2})(\d{2})(\d{2})$`)
+	probPattern     = regexp.MustCompile(`^PROB\d{2}$`)
+)
+
+// ParseTAF parses the text of a TAF, the days of the periods are resolved relative to reference.
+func ParseTAF(raw string, reference time.Time) (TAF, error) {
+	taf := TAF{Raw: strings.TrimSpace(raw)}
+
+	tokens := strings.Fields(strings.TrimSuffix(taf.Raw, "="))
+	for len(tokens) > 0 && (tokens[0] == "TAF" || tokens[0] == "AMD" || tokens[0] == "COR") {
+		tokens = tokens[1:]
+	}
+
+	if len(tokens) < 3 || !stationPattern.MatchString(tokens[0]) {
+		return TAF{}, fmt.Errorf("not a TAF: %q", taf.Raw)
+	}
+	taf.Station = tokens[0]
+	tokens = tokens[1:]
+
+	if match := timePattern.FindStringSubmatch(tokens[0]); match != nil {
+		taf.Issued = resolveTime(reference, atoi(match[1]), atoi(match[2]), atoi(match[3]))
+		tokens = tokens[1:]
+	}
+
+	match := validityPattern.FindStringSubmatch(tokens[0])
+	if match == nil {
+		return TAF{}, fmt.Errorf("TAF of %s has no validity period: %q", taf.Station, taf.Raw)
+	}
+
+	period := &Period{
+		From:   resolveTime(reference, atoi(match[1]), atoi(match[2]), 0),
+		To:     resolveTime(reference, atoi(match[3]), atoi(match[4]), 0),
+		Report: newPeriodReport(taf.Station),
+	}
+	end := period.To
+	if taf.Issued.IsZero() {
+		taf.Issued = period.From
+	}
+
+	for i := 1; i < len(tokens); i++ {
+		token := tokens[i]
+
+		switch {
+		case token == "RMK":
+			i = len(tokens)
+		case fromPattern.MatchString(token):
+			match := fromPattern.FindStringSubmatch(token)
+			taf.Periods = append(taf.Periods, *period)
+			period = &Period{
+				Change: "FM",
+				From:   resolveTime(reference, atoi(match[1]), atoi(match[2]), atoi(match[3])),
+				To:     end,
+				Report: newPeriodReport(taf.Station),
+			}
+		case token == "BECMG" || token == "TEMPO" || probPattern.MatchString(token):
+			taf.Periods = append(taf.Periods, *period)
+			period = &Period{Change: token, Report: newPeriodReport(taf.Station)}
+			// PROB30 TEMPO is a single change group
+			if probPattern.MatchString(token) && i+1 < len(tokens) && tokens[i+1] == "TEMPO" {
+				period.Change += " TEMPO"
+				i++
+			}
+			if i+1 < len(tokens) {
+				if match := validityPattern.FindStringSubmatch(tokens[i+1]); match != nil {
+					period.From = resolveTime(reference, atoi(match[1]), atoi(match[2]), 0)
+					period.To = resolveTime(reference, atoi(match[3]), atoi(match[4]), 0)
+					i++
+				}
+			}
+		default:
+			period.Report.parseToken(token)
+		}
+	}
+	taf.Periods = append(taf.Periods, *period)
+
+	// A from group ends where the next one starts
+	for i := range taf.Periods {
+		if taf.Periods[i].Change != "FM" && taf.Periods[i].Change != "" {
+			continue
+		}
+		for j := i + 1; j < len(taf.Periods); j++ {
+			if taf.Periods[j].Change == "FM" {
+				taf.Periods[i].To = taf.Periods[j].From
+				break
+			}
+		}
+	}
+
+	return taf, nil
+}
+
+func newPeriodReport(station string) Report {
+	return Report{Station: station, WindDirection: -1, VisibilityMeters: -1}
+}
+
+// At returns the prevailing conditions that are forecast at the given time.
+// Temporary and probable changes are ignored, gradual changes apply from the start of their period.
+func (t TAF) At(at time.Time) (Report, bool) {
+	var report Report
+	found := false
+
+	for _, period := range t.Periods {
+		switch period.Change {
+		case "", "FM":
+			if !at.Before(period.From) && at.Before(period.To) {
+				report = period.Report
+				found = true
+			}
+		case "BECMG":
+			if found && !at.Before(period.From) {
+				report = merge(report, period.Report)
+			}
+		}
+	}
+
+	if !found {
+		return Report{}, false
+	}
+
+	report.Raw = t.Raw
+	report.Time = t.Issued
+	return report, true
+}
+
+// merge returns the base report with the groups that were forecast in change replaced
+func merge(base, change Report) Report {
+	if change.WindSpeed > 0 || change.WindDirection >= 0 {
+		base.WindDirection, base.WindSpeed, base.WindGust = change.WindDirection, change.WindSpeed, change.WindGust
+	}
+	if change.VisibilityMeters >= 0 {
+		base.VisibilityMeters = change.VisibilityMeters
+	}
+	if change.CAVOK {
+		base.CAVOK, base.Clouds, base.Weather = true, nil, nil
+	}
+	if len(change.Clouds) > 0 || change.NoClouds {
+		base.Clouds, base.NoClouds, base.CAVOK = change.Clouds, change.NoClouds, change.CAVOK
+	}
+	if change.Weather != nil {
+		base.Weather = change.Weather
+	}
+	return base
+}
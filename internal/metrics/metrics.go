@@ -26,6 +26,62 @@ var aircraftAltitude = promauto.NewHistogramVec(prometheus.HistogramOpts{
 	[]string{"type", "description", "military"},
 )
 
+var cacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "jetspotter_cache_requests_total",
+	Help: "The total number of cache lookups by result (hit, negative_hit or miss).",
+},
+	[]string{"cache", "result"},
+)
+
+var cacheEntries = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "jetspotter_cache_entries",
+	Help: "The number of entries in the cache.",
+},
+	[]string{"cache"},
+)
+
+var aloftWindDirection = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "jetspotter_aloft_wind_direction_degrees",
+	Help: "The direction the wind blows from, by altitude band, as reported by the aircraft.",
+},
+	[]string{"band"},
+)
+
+var aloftWindSpeed = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "jetspotter_aloft_wind_speed_knots",
+	Help: "The speed of the wind, by altitude band, as reported by the aircraft.",
+},
+	[]string{"band"},
+)
+
+var aloftTemperature = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "jetspotter_aloft_temperature_celsius",
+	Help: "The outside air temperature, by altitude band, as reported by the aircraft.",
+},
+	[]string{"band"},
+)
+
+var aloftReports = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "jetspotter_aloft_reports",
+	Help: "The number of reports of the wind or temperature that the values of an altitude band are based on.",
+},
+	[]string{"band", "kind"},
+)
+
+var notificationDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "jetspotter_notification_deliveries_total",
+	Help: "The total number of notification delivery attempts by channel and result (delivered, retry or dead_letter).",
+},
+	[]string{"channel", "result"},
+)
+
+var notificationOutbox = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "jetspotter_notification_outbox_messages",
+	Help: "The number of notifications in the outbox by channel and state (pending or dead_letter).",
+},
+	[]string{"channel", "state"},
+)
+
 // IncrementMetrics handles the metrics that need to be incremented
 func IncrementMetrics(aircrafType, description, military string, altitude float64) {
 	go func() {
@@ -34,6 +90,16 @@ func IncrementMetrics(aircrafType, description, military string, altitude float6
 	}()
 }
 
+// IncrementCacheRequests counts a lookup in a cache
+func IncrementCacheRequests(cache, result string) {
+	cacheRequests.WithLabelValues(cache, result).Inc()
+}
+
+// SetCacheEntries sets the number of entries that are stored in a cache
+func SetCacheEntries(cache string, entries int) {
+	cacheEntries.WithLabelValues(cache).Set(float64(entries))
+}
+
 func HandleMetrics(config configuration.Config) error {
 	path := "/metrics"
 	port := config.MetricsPort
@@ -45,3 +111,40 @@ func HandleMetrics(config configuration.Config) error {
 	}
 	return nil
 }
+
+// ResetAloft removes the winds and temperatures aloft, so that bands without reports aren't exported anymore
+func ResetAloft() {
+	aloftWindDirection.Reset()
+	aloftWindSpeed.Reset()
+	aloftTemperature.Reset()
+	aloftReports.Reset()
+}
+
+// SetAloftWind sets the wind of an altitude band
+func SetAloftWind(band string, direction, speed float64, reports int) {
+	aloftWindDirection.WithLabelValues(band).Set(direction)
+	aloftWindSpeed.WithLabelValues(band).Set(speed)
+	aloftReports.WithLabelValues(band, "wind").Set(float64(reports))
+}
+
+// SetAloftTemperature sets the temperature of an altitude band
+func SetAloftTemperature(band string, celsius float64, reports int) {
+	aloftTemperature.WithLabelValues(band).Set(celsius)
+	aloftReports.WithLabelValues(band, "temperature").Set(float64(reports))
+}
+
+// IncrementNotificationDeliveries counts an attempt to deliver a notification to a channel
+func IncrementNotificationDeliveries(channel, result string) {
+	notificationDeliveries.WithLabelValues(channel, result).Inc()
+}
+
+// SetNotificationOutbox sets the number of pending notifications and dead letters of each channel
+func SetNotificationOutbox(pending, deadLetters map[string]int) {
+	notificationOutbox.Reset()
+	for channel, count := range pending {
+		notificationOutbox.WithLabelValues(channel, "pending").Set(float64(count))
+	}
+	for channel, count := range deadLetters {
+		notificationOutbox.WithLabelValues(channel, "dead_letter").Set(float64(count))
+	}
+}
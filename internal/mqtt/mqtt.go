@@ -0,0 +1,285 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+const (
+	// qos is the quality of service of the messages, they are delivered at least once
+	qos = 1
+
+	// statusTopic contains online or offline, the broker sets it to offline when jetspotter disconnects unexpectedly
+	statusTopic = "status"
+	online      = "online"
+	offline     = "offline"
+)
+
+// ErrNotConnected is returned when publishing while the client isn't connected to the broker.
+// The message is dropped instead of being queued until the client reconnects.
+var ErrNotConnected = errors.New("not connected to the MQTT broker")
+
+// Options configure the connection to the broker
+type Options struct {
+	// URL of the broker, for example tcp://localhost:1883 or ssl://localhost:8883
+	Broker   string
+	ClientID string
+	Username string
+	Password string
+	// Prefix of the topics that are published to, for example "jetspotter"
+	TopicPrefix string
+	// Prefix of the Home Assistant discovery topics, for example "homeassistant". Discovery is disabled if it is empty.
+	DiscoveryPrefix string
+	// Time to wait for the broker to acknowledge a message
+	Timeout time.Duration
+}
+
+// Client publishes JSON messages to the topics below the topic prefix.
+// It reconnects when the connection is lost, announcing itself and its entities again.
+type Client struct {
+	client  paho.Client
+	options Options
+
+	mu       sync.Mutex
+	device   Device
+	entities []Entity
+}
+
+// Connect connects to the broker. If the broker can't be reached within the timeout, the client keeps trying
+// to connect in the background and messages are published once it is connected.
+func Connect(options Options) (*Client, error) {
+	if options.Timeout <= 0 {
+		options.Timeout = 10 * time.Second
+	}
+	c := &Client{options: options}
+
+	clientOptions := paho.NewClientOptions().
+		AddBroker(options.Broker).
+		SetClientID(options.ClientID).
+		SetUsername(options.Username).
+		SetPassword(options.Password).
+		SetWill(c.topic(statusTopic), offline, qos, true).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetConnectTimeout(options.Timeout).
+		SetOnConnectHandler(func(paho.Client) {
+			// The handler runs in its own goroutine, so it can wait for the broker
+			c.announce()
+		}).
+		SetConnectionLostHandler(func(_ paho.Client, err error) {
+			log.Printf("Lost the connection to MQTT broker %s: %v", options.Broker, err)
+		})
+	c.client = paho.NewClient(clientOptions)
+
+	token := c.client.Connect()
+	if !token.WaitTimeout(options.Timeout) {
+		log.Printf("MQTT broker %s can't be reached yet, retrying in the background", options.Broker)
+		return c, nil
+	}
+	if err := token.Error(); err != nil {
+		return nil, fmt.Errorf("failed to connect to MQTT broker %s: %w", options.Broker, err)
+	}
+	return c, nil
+}
+
+// topic returns the full name of a topic below the topic prefix
+func (c *Client) topic(name string) string {
+	if c.options.TopicPrefix == "" {
+		return name
+	}
+	return c.options.TopicPrefix + "/" + name
+}
+
+// Publish publishes the payload as JSON to a topic below the topic prefix.
+// The broker keeps the last retained message of a topic and sends it to new subscribers.
+func (c *Client) Publish(topic string, payload any, retained bool) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return c.publish(c.topic(topic), data, retained)
+}
+
+// Clear removes the retained message of a topic below the topic prefix
+func (c *Client) Clear(topic string) error {
+	return c.publish(c.topic(topic), []byte{}, true)
+}
+
+// Connected returns whether the client is connected to the broker. While it is reconnecting, the client
+// reports to be connected but messages would wait for the connection.
+func (c *Client) Connected() bool {
+	return c.client.IsConnectionOpen()
+}
+
+func (c *Client) publish(topic string, payload any, retained bool) error {
+	if !c.Connected() {
+		return fmt.Errorf("failed to publish to %s: %w", topic, ErrNotConnected)
+	}
+	token := c.client.Publish(topic, qos, retained, payload)
+	if !token.WaitTimeout(c.options.Timeout) {
+		return fmt.Errorf("timed out publishing to %s", topic)
+	}
+	if err := token.Error(); err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", topic, err)
+	}
+	return nil
+}
+
+// Close marks jetspotter as offline and disconnects from the broker
+func (c *Client) Close() {
+	if c.Connected() {
+		_ = c.publish(c.topic(statusTopic), offline, true)
+	}
+	c.client.Disconnect(250)
+}
+
+// announce marks jetspotter as online and sends the discovery messages, every time that it connects
+func (c *Client) announce() {
+	err := c.publish(c.topic(statusTopic), online, true)
+	if err == nil {
+		err = c.discover()
+	}
+	if err != nil {
+		log.Printf("Failed to announce jetspotter on the MQTT broker: %v", err)
+	}
+
+	if c.options.DiscoveryPrefix == "" {
+		return
+	}
+	// Home Assistant loses the entities when it restarts without a persistent broker, so they are sent again when it comes online
+	c.client.Subscribe(c.options.DiscoveryPrefix+"/"+statusTopic, qos, func(_ paho.Client, message paho.Message) {
+		if string(message.Payload()) != online {
+			return
+		}
+		go func() {
+			if err := c.discover(); err != nil {
+				log.Printf("Failed to send the Home Assistant discovery messages: %v", err)
+			}
+		}()
+	})
+}
+
+// Device groups the entities of jetspotter in Home Assistant
+type Device struct {
+	ID              string
+	Name            string
+	Manufacturer    string
+	Model           string
+	SoftwareVersion string
+}
+
+// Entity is a Home Assistant entity that is created with MQTT discovery, its state is read from a topic of the client
+type Entity struct {
+	// Component of the entity, for example "sensor" or "binary_sensor"
+	Component string
+	// ID of the entity, unique within the device
+	ID   string
+	Name string
+	// Topic below the topic prefix that contains the state, as JSON
+	StateTopic string
+	// Template that extracts the state from the JSON, for example "{{ value_json.count }}"
+	ValueTemplate string
+	// Template that extracts the attributes from the JSON, no attributes if it is empty
+	AttributesTemplate string
+	Unit               string
+	DeviceClass        string
+	StateClass         string
+	Icon               string
+}
+
+// discoveryDevice and discoveryConfig are the discovery payload that Home Assistant expects
+type discoveryDevice struct {
+	Identifiers     []string `json:"identifiers"`
+	Name            string   `json:"name"`
+	Manufacturer    string   `json:"manufacturer,omitempty"`
+	Model           string   `json:"model,omitempty"`
+	SoftwareVersion string   `json:"sw_version,omitempty"`
+}
+
+type discoveryConfig struct {
+	Name                string          `json:"name"`
+	UniqueID            string          `json:"unique_id"`
+	ObjectID            string          `json:"object_id"`
+	StateTopic          string          `json:"state_topic"`
+	ValueTemplate       string          `json:"value_template,omitempty"`
+	AttributesTopic     string          `json:"json_attributes_topic,omitempty"`
+	AttributesTemplate  string          `json:"json_attributes_template,omitempty"`
+	Unit                string          `json:"unit_of_measurement,omitempty"`
+	DeviceClass         string          `json:"device_class,omitempty"`
+	StateClass          string          `json:"state_class,omitempty"`
+	Icon                string          `json:"icon,omitempty"`
+	AvailabilityTopic   string          `json:"availability_topic"`
+	PayloadAvailable    string          `json:"payload_available"`
+	PayloadNotAvailable string          `json:"payload_not_available"`
+	Device              discoveryDevice `json:"device"`
+}
+
+// Discover creates the entities of the device in Home Assistant. They are sent again when the client reconnects
+// or when Home Assistant restarts.
+func (c *Client) Discover(device Device, entities []Entity) error {
+	c.mu.Lock()
+	c.device = device
+	c.entities = entities
+	c.mu.Unlock()
+
+	if !c.Connected() {
+		// They are sent once the client is connected
+		return nil
+	}
+	return c.discover()
+}
+
+func (c *Client) discover() error {
+	if c.options.DiscoveryPrefix == "" {
+		return nil
+	}
+
+	c.mu.Lock()
+	device, entities := c.device, c.entities
+	c.mu.Unlock()
+
+	var errs []error
+	for _, entity := range entities {
+		config := discoveryConfig{
+			Name:                entity.Name,
+			UniqueID:            device.ID + "_" + entity.ID,
+			ObjectID:            device.ID + "_" + entity.ID,
+			StateTopic:          c.topic(entity.StateTopic),
+			ValueTemplate:       entity.ValueTemplate,
+			Unit:                entity.Unit,
+			DeviceClass:         entity.DeviceClass,
+			StateClass:          entity.StateClass,
+			Icon:                entity.Icon,
+			AvailabilityTopic:   c.topic(statusTopic),
+			PayloadAvailable:    online,
+			PayloadNotAvailable: offline,
+			Device: discoveryDevice{
+				Identifiers:     []string{device.ID},
+				Name:            device.Name,
+				Manufacturer:    device.Manufacturer,
+				Model:           device.Model,
+				SoftwareVersion: device.SoftwareVersion,
+			},
+		}
+		if entity.AttributesTemplate != "" {
+			config.AttributesTopic = config.StateTopic
+			config.AttributesTemplate = entity.AttributesTemplate
+		}
+
+		data, err := json.Marshal(config)
+		if err != nil {
+			return err
+		}
+		topic := fmt.Sprintf("%s/%s/%s/%s/config", c.options.DiscoveryPrefix, entity.Component, device.ID, entity.ID)
+		if err := c.publish(topic, data, true); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,177 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	broker "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+)
+
+// startBroker starts an embedded broker on a free port and returns its URL
+func startBroker(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	server := broker.New(&broker.Options{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))})
+	if err := server.AddHook(new(auth.AllowHook), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.AddListener(listeners.NewTCP(listeners.Config{ID: "test", Address: address})); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		_ = server.Serve()
+	}()
+	t.Cleanup(func() {
+		_ = server.Close()
+	})
+	return "tcp://" + address
+}
+
+// subscribe returns the messages of the topics, including the retained ones
+func subscribe(t *testing.T, url, topic string) <-chan paho.Message {
+	messages := make(chan paho.Message, 100)
+	client := paho.NewClient(paho.NewClientOptions().AddBroker(url).SetClientID("subscriber-" + topic))
+	if token := client.Connect(); !token.WaitTimeout(5*time.Second) || token.Error() != nil {
+		t.Fatalf("failed to connect the subscriber: %v", token.Error())
+	}
+	token := client.Subscribe(topic, 1, func(_ paho.Client, message paho.Message) {
+		messages <- message
+	})
+	if !token.WaitTimeout(5*time.Second) || token.Error() != nil {
+		t.Fatalf("failed to subscribe: %v", token.Error())
+	}
+	t.Cleanup(func() {
+		client.Disconnect(0)
+	})
+	return messages
+}
+
+func receive(t *testing.T, messages <-chan paho.Message) paho.Message {
+	select {
+	case message := <-messages:
+		return message
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected a message")
+		return nil
+	}
+}
+
+func connect(t *testing.T, url string) *Client {
+	client, err := Connect(Options{Broker: url, ClientID: "jetspotter", TopicPrefix: "jetspotter", DiscoveryPrefix: "homeassistant", Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestPublishedStateIsRetained(t *testing.T) {
+	url := startBroker(t)
+	client := connect(t, url)
+
+	if err := client.Publish("aircraft/ae07e0", map[string]string{"Callsign": "RCH456"}, true); err != nil {
+		t.Fatal(err)
+	}
+
+	// Subscribers that connect later receive the retained state and jetspotter being online
+	status := subscribe(t, url, "jetspotter/status")
+	if message := receive(t, status); string(message.Payload()) != "online" || !message.Retained() {
+		t.Errorf("expected jetspotter to be online, got %q", message.Payload())
+	}
+	aircraft := subscribe(t, url, "jetspotter/aircraft/+")
+	if message := receive(t, aircraft); string(message.Payload()) != `{"Callsign":"RCH456"}` || !message.Retained() {
+		t.Errorf("expected the retained state of the aircraft, got %q", message.Payload())
+	}
+
+	if err := client.Clear("aircraft/ae07e0"); err != nil {
+		t.Fatal(err)
+	}
+	if message := receive(t, aircraft); len(message.Payload()) != 0 {
+		t.Errorf("expected the state to be cleared, got %q", message.Payload())
+	}
+
+	client.Close()
+	if message := receive(t, status); string(message.Payload()) != "offline" {
+		t.Errorf("expected jetspotter to be offline after closing, got %q", message.Payload())
+	}
+}
+
+func TestDiscoveryIsSentAgainWhenHomeAssistantRestarts(t *testing.T) {
+	url := startBroker(t)
+	client := connect(t, url)
+	defer client.Close()
+
+	device := Device{ID: "jetspotter", Name: "Jetspotter"}
+	entities := []Entity{{
+		Component:          "sensor",
+		ID:                 "nearest_aircraft",
+		Name:               "Nearest aircraft",
+		StateTopic:         "summary",
+		ValueTemplate:      "{{ value_json.nearest.Callsign }}",
+		AttributesTemplate: "{{ value_json.nearest | tojson }}",
+	}}
+	if err := client.Discover(device, entities); err != nil {
+		t.Fatal(err)
+	}
+
+	discovery := subscribe(t, url, "homeassistant/sensor/jetspotter/nearest_aircraft/config")
+	var config discoveryConfig
+	if err := json.Unmarshal(receive(t, discovery).Payload(), &config); err != nil {
+		t.Fatal(err)
+	}
+	if config.UniqueID != "jetspotter_nearest_aircraft" || config.StateTopic != "jetspotter/summary" ||
+		config.AttributesTopic != "jetspotter/summary" || config.AvailabilityTopic != "jetspotter/status" || config.Device.Identifiers[0] != "jetspotter" {
+		t.Errorf("unexpected discovery payload %+v", config)
+	}
+
+	// Home Assistant announces that it is online after a restart
+	homeAssistant := paho.NewClient(paho.NewClientOptions().AddBroker(url).SetClientID("homeassistant"))
+	if token := homeAssistant.Connect(); !token.WaitTimeout(5 * time.Second) {
+		t.Fatalf("failed to connect Home Assistant")
+	}
+	defer homeAssistant.Disconnect(0)
+	homeAssistant.Publish("homeassistant/status", 1, false, "online").WaitTimeout(5 * time.Second)
+
+	if message := receive(t, discovery); message.Retained() || len(message.Payload()) == 0 {
+		t.Errorf("expected the discovery to be sent again, got %q", message.Payload())
+	}
+}
+
+func TestUnreachableBrokerDoesNotBlock(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	url := "tcp://" + listener.Addr().String()
+	listener.Close()
+
+	client, err := Connect(Options{Broker: url, ClientID: "jetspotter", TopicPrefix: "jetspotter", DiscoveryPrefix: "homeassistant", Timeout: 200 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	// The client keeps trying to connect, messages are dropped instead of waiting for the broker
+	start := time.Now()
+	if err := client.Discover(Device{ID: "jetspotter"}, []Entity{{Component: "sensor", ID: "aircraft_in_range", StateTopic: "summary"}}); err != nil {
+		t.Errorf("expected the discovery to wait for the connection, got %v", err)
+	}
+	if err := client.Publish("summary", map[string]int{"count": 1}, true); !errors.Is(err, ErrNotConnected) {
+		t.Errorf("expected the message to be dropped, got %v", err)
+	}
+	if time.Since(start) > 100*time.Millisecond {
+		t.Errorf("expected publishing to return right away, took %v", time.Since(start))
+	}
+}
@@ -1,8 +1,11 @@
 package notification
 
 import (
+	"context"
 	"jetspotter/internal/configuration"
 	"jetspotter/internal/jetspotter"
+	"jetspotter/internal/outbox"
+	"strings"
 
 	"github.com/bwmarrin/discordgo"
 )
@@ -21,37 +24,67 @@ const (
 	grey        = 3815994
 )
 
-// SendDiscordMessage sends a discord message containing metadata of a list of aircraft
-func SendDiscordMessage(aircraft []jetspotter.Aircraft, config configuration.Config) error {
-	// Discord has a limit of 10 embeds per message, so we need to split larger batches
+func init() {
+	Register(Discord, func(config configuration.Config) []Notifier {
+		var notifiers []Notifier
+		for i, url := range config.DiscordWebHookURL {
+			notifiers = append(notifiers, discordNotifier{name: instanceName(Discord, i, len(config.DiscordWebHookURL)), url: url, config: config})
+		}
+		return notifiers
+	}, func(aircraft []jetspotter.Aircraft, config configuration.Config) (any, error) {
+		return buildDiscordMessages(aircraft, config)
+	})
+}
+
+// discordNotifier sends notifications to a Discord webhook
+type discordNotifier struct {
+	name   string
+	url    string
+	config configuration.Config
+}
+
+func (n discordNotifier) Name() string {
+	return n.name
+}
+
+// Send sends discord messages containing metadata of a list of aircraft
+func (n discordNotifier) Send(ctx context.Context, aircraft []jetspotter.Aircraft) error {
+	messages, err := buildDiscordMessages(aircraft, n.config)
+	if err != nil {
+		return err
+	}
+
+	return sendEach(ctx, messages, func(ctx context.Context, message discordgo.Message) error {
+		return SendMessage(ctx, Notification{
+			Message: message,
+			Type:    Discord,
+			Channel: n.name,
+		})
+	})
+}
+
+func (n discordNotifier) endpoint() outbox.Endpoint {
+	return jsonEndpoint(n.url)
+}
+
+// buildDiscordMessages splits the aircraft in batches, because Discord has a limit of 10 embeds per message
+func buildDiscordMessages(aircraft []jetspotter.Aircraft, config configuration.Config) ([]discordgo.Message, error) {
 	const maxEmbedsPerMessage = 10
 
-	// If we have more than maxEmbedsPerMessage aircraft, split them into batches
+	var messages []discordgo.Message
 	for i := 0; i < len(aircraft); i += maxEmbedsPerMessage {
 		end := i + maxEmbedsPerMessage
 		if end > len(aircraft) {
 			end = len(aircraft)
 		}
 
-		batch := aircraft[i:end]
-		message, err := buildDiscordMessage(batch, config)
-		if err != nil {
-			return err
-		}
-
-		notification := Notification{
-			Message: message,
-			Type:    Discord,
-			URL:     config.DiscordWebHookURL,
-		}
-
-		err = SendMessage(notification)
+		message, err := buildDiscordMessage(aircraft[i:end], config)
 		if err != nil {
-			return err
+			return nil, err
 		}
+		messages = append(messages, message)
 	}
-
-	return nil
+	return messages, nil
 }
 
 func getColorByAltitude(altitude int) int {
@@ -81,89 +114,52 @@ func getColorByAltitude(altitude int) int {
 	}
 }
 
+// Discord rejects embeds with more fields and fields without a value
+const (
+	maxDiscordFields  = 25
+	emptyDiscordValue = "N/A"
+)
+
 func buildDiscordMessage(aircraft []jetspotter.Aircraft, config configuration.Config) (message discordgo.Message, err error) {
-	message.Content = ":airplane: A jet has been spotted! :airplane:"
+	message.Content, err = renderHeader(Discord, aircraft, config)
+	if err != nil {
+		return message, err
+	}
+
 	var embeds []*discordgo.MessageEmbed
 	for _, ac := range aircraft {
-		embed := &discordgo.MessageEmbed{
-			Fields: []*discordgo.MessageEmbedField{
-				{
-					Name:   "Callsign",
-					Value:  formatCallsign(ac, Markdown),
-					Inline: true,
-				},
-				{
-					Name:   "Registration",
-					Value:  formatRegistration(ac, Markdown),
-					Inline: true,
-				},
-				{
-					Name:   "Country",
-					Value:  ac.Country,
-					Inline: true,
-				},
-				{
-					Name:   "Speed",
-					Value:  printSpeed(ac),
-					Inline: true,
-				},
-				{
-					Name:   "Altitude",
-					Value:  printAltitude(ac),
-					Inline: true,
-				},
-				{
-					Name:   "Distance",
-					Value:  printDistance(ac),
-					Inline: true,
-				},
-				{
-					Name:   "Bearing from location",
-					Value:  printBearingFromLocation(ac),
-					Inline: true,
-				},
-				{
-					Name:   "Heading",
-					Value:  printHeading(ac),
-					Inline: true,
-				},
-				{
-					Name:   "Bearing from aircraft",
-					Value:  printBearingFromAircraft(ac),
-					Inline: true,
-				},
-				{
-					Name:   "Cloud coverage",
-					Value:  printCloudCoverage(ac),
-					Inline: true,
-				},
-				{
-					Name:   "Inbound",
-					Value:  getInboundStatus(ac),
-					Inline: true,
-				},
-				{
-					Name:   "Type",
-					Value:  ac.Description,
-					Inline: true,
-				},
-				{
-					Name:   "Origin",
-					Value:  printOriginName(ac),
-					Inline: true,
-				},
-				{
-					Name:   "Destination",
-					Value:  printDestinationName(ac),
-					Inline: true,
-				},
-				{
-					Name:   "Airline",
-					Value:  printAirlineName(ac),
-					Inline: true,
-				},
-			},
+		embed := &discordgo.MessageEmbed{}
+		embed.Title, err = renderTitle(Discord, ac, config)
+		if err != nil {
+			return message, err
+		}
+
+		body, err := renderBody(Discord, ac, config)
+		if err != nil {
+			return message, err
+		}
+		var description []string
+		for i, group := range parseFields(body) {
+			for _, f := range group {
+				if f.Name == "" {
+					description = append(description, f.Value)
+					continue
+				}
+				if len(embed.Fields) == maxDiscordFields {
+					continue
+				}
+				if f.Value == "" {
+					f.Value = emptyDiscordValue
+				}
+				embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+					Name:  f.Name,
+					Value: f.Value,
+					// Only the fields of the first group are shown next to each other
+					Inline: i == 0,
+				})
+			}
 		}
+		embed.Description = strings.Join(description, "\n")
 
 		if config.DiscordColorAltitude == "true" {
 			embed.Color = getColorByAltitude(int(ac.Altitude))
@@ -0,0 +1,216 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"jetspotter/internal/configuration"
+	"jetspotter/internal/email"
+	"jetspotter/internal/jetspotter"
+	"log"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+)
+
+// emailHTML lays out the aircraft of an email, the fields are the rendered body of the email template
+var emailHTML = template.Must(template.New("email").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif;">
+{{- range .}}
+<h2>{{.Title}}</h2>
+{{- if .Image}}
+<p><img src="{{.Image}}" alt="{{.Alt}}" style="max-width: 100%;"></p>
+{{- end}}
+<table cellpadding="4">
+{{- range .Fields}}
+<tr>{{if .Name}}<th align="left">{{.Name}}</th><td>{{.Value}}</td>{{else}}<td colspan="2">{{.Value}}</td>{{end}}</tr>
+{{- end}}
+</table>
+<p>
+{{- if .TrackerURL}}<a href="{{.TrackerURL}}">Track aircraft</a>{{end}}
+{{- if and .TrackerURL .ImageURL}} | {{end}}
+{{- if .ImageURL}}<a href="{{.ImageURL}}">Photos</a>{{end -}}
+</p>
+<hr>
+{{- end}}
+</body>
+</html>
+`))
+
+// emailAircraft is an aircraft as it is laid out in the HTML body
+type emailAircraft struct {
+	Title      string
+	Image      template.URL
+	Alt        string
+	Fields     []field
+	TrackerURL string
+	ImageURL   string
+}
+
+func init() {
+	Register(Email, func(config configuration.Config) []Notifier {
+		if config.SMTPHost == "" || len(config.EmailTo) == 0 {
+			return nil
+		}
+		notifier := emailNotifier{name: Email, config: config}
+		if config.EmailDigestMinutes > 0 {
+			notifier.digest = &emailDigest{interval: time.Duration(config.EmailDigestMinutes) * time.Minute}
+		}
+		return []Notifier{notifier}
+	}, func(aircraft []jetspotter.Aircraft, config configuration.Config) (any, error) {
+		return buildEmailMessage(aircraft, config)
+	})
+}
+
+// emailNotifier sends an email about the aircraft to all recipients, or collects them in a digest
+type emailNotifier struct {
+	name   string
+	config configuration.Config
+	// digest collects the aircraft until they are sent together, nil if every batch is sent right away
+	digest *emailDigest
+}
+
+// emailDigest collects the spotted aircraft, the digest is sent once the interval has passed since the first one
+type emailDigest struct {
+	interval time.Duration
+
+	mu       sync.Mutex
+	aircraft []jetspotter.Aircraft
+	timer    *time.Timer
+}
+
+func (n emailNotifier) Name() string {
+	return n.name
+}
+
+// Send sends an email about the aircraft, or adds them to the digest
+func (n emailNotifier) Send(ctx context.Context, aircraft []jetspotter.Aircraft) error {
+	if n.digest == nil {
+		return n.send(ctx, aircraft)
+	}
+
+	n.digest.mu.Lock()
+	defer n.digest.mu.Unlock()
+	n.digest.aircraft = append(n.digest.aircraft, aircraft...)
+	if n.digest.timer == nil {
+		n.digest.timer = time.AfterFunc(n.digest.interval, n.flush)
+	}
+	return nil
+}
+
+// flush sends the aircraft of the digest
+func (n emailNotifier) flush() {
+	n.digest.mu.Lock()
+	aircraft := n.digest.aircraft
+	n.digest.aircraft = nil
+	n.digest.timer = nil
+	n.digest.mu.Unlock()
+
+	if len(aircraft) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(n.config.NotificationTimeoutSeconds)*time.Second)
+	defer cancel()
+	if err := n.send(ctx, jetspotter.SortByDistance(aircraft)); err != nil {
+		log.Printf("Failed to send %s digest: %v", n.name, err)
+	}
+}
+
+func (n emailNotifier) send(ctx context.Context, aircraft []jetspotter.Aircraft) error {
+	from, err := mail.ParseAddress(n.config.EmailFrom)
+	if err != nil {
+		return fmt.Errorf("invalid sender %q: %w", n.config.EmailFrom, err)
+	}
+	var to []string
+	for _, recipient := range n.config.EmailTo {
+		address, err := mail.ParseAddress(recipient)
+		if err != nil {
+			return fmt.Errorf("invalid recipient %q: %w", recipient, err)
+		}
+		to = append(to, address.Address)
+	}
+
+	message, err := buildEmailMessage(aircraft, n.config)
+	if err != nil {
+		return err
+	}
+
+	// The photos are loaded when the email is sent, so that the outbox only stores where they are
+	err = jetspotter.Outbox().Send(ctx, n.name, email.SMTP, email.Envelope{From: from.Address, To: to, Message: message})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("A %s notification has been sent!\n", n.name)
+	return nil
+}
+
+// buildEmailMessage builds an email about the aircraft with their photos inline. Thumbnails that jetspotter caches
+// are read from disk, so they are embedded even if jetspotter can't be reached from the outside.
+func buildEmailMessage(aircraft []jetspotter.Aircraft, config configuration.Config) (message email.Message, err error) {
+	message.From = config.EmailFrom
+	message.To = config.EmailTo
+	message.Subject, err = renderHeader(Email, aircraft, config)
+	if err != nil {
+		return message, err
+	}
+
+	var text strings.Builder
+	var layout []emailAircraft
+	embedded := make(map[string]bool)
+	for _, ac := range aircraft {
+		title, err := renderTitle(Email, ac, config)
+		if err != nil {
+			return message, err
+		}
+		body, err := renderBody(Email, ac, config)
+		if err != nil {
+			return message, err
+		}
+
+		fmt.Fprintf(&text, "%s\n\n%s\n", title, strings.TrimSpace(body))
+		if ac.TrackerURL != "" {
+			fmt.Fprintf(&text, "Track aircraft: %s\n", ac.TrackerURL)
+		}
+		if ac.ImageURL != "" {
+			fmt.Fprintf(&text, "Photos: %s\n", ac.ImageURL)
+		}
+		text.WriteString("\n")
+
+		entry := emailAircraft{
+			Title:      title,
+			Alt:        strings.TrimSpace(fmt.Sprintf("%s %s", ac.Description, ac.Registration)),
+			TrackerURL: ac.TrackerURL,
+			ImageURL:   ac.ImageURL,
+		}
+		for _, group := range parseFields(body) {
+			entry.Fields = append(entry.Fields, group...)
+		}
+		source := ac.ImageThumbnailPath
+		if source == "" {
+			source = ac.ImageThumbnailURL
+		}
+		if source != "" {
+			image := email.Image{ContentID: ac.ICAO + "@jetspotter", Source: source}
+			entry.Image = template.URL("cid:" + image.ContentID)
+			// An aircraft that is spotted twice in a digest refers to the same image
+			if !embedded[ac.ICAO] {
+				message.Images = append(message.Images, image)
+				embedded[ac.ICAO] = true
+			}
+		}
+		layout = append(layout, entry)
+	}
+	message.Text = text.String()
+
+	var html bytes.Buffer
+	if err := emailHTML.Execute(&html, layout); err != nil {
+		return message, err
+	}
+	message.HTML = html.String()
+	return message, nil
+}
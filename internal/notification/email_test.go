@@ -0,0 +1,153 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"jetspotter/internal/configuration"
+	"jetspotter/internal/email"
+	"jetspotter/internal/jetspotter"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// capturedEmail is an email that the outbox delivered, with the payload that the outbox stored
+type capturedEmail struct {
+	email.Envelope
+	Payload string
+	Data    string
+}
+
+// captureEmails delivers the emails of the outbox to a slice instead of an SMTP server
+func captureEmails(t *testing.T) func() []capturedEmail {
+	var mu sync.Mutex
+	var emails []capturedEmail
+	jetspotter.Outbox().Handle(email.SMTP, func(ctx context.Context, payload json.RawMessage) error {
+		var envelope email.Envelope
+		if err := json.Unmarshal(payload, &envelope); err != nil {
+			return err
+		}
+		data, err := envelope.Encode(ctx, jetspotter.LoadImage)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		emails = append(emails, capturedEmail{Envelope: envelope, Payload: string(payload), Data: string(data)})
+		mu.Unlock()
+		return nil
+	})
+	t.Cleanup(func() {
+		jetspotter.Outbox().Handle(email.SMTP, nil)
+	})
+
+	return func() []capturedEmail {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]capturedEmail(nil), emails...)
+	}
+}
+
+func TestEmailEmbedsThePhoto(t *testing.T) {
+	photos := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("\xff\xd8\xff\xe0\x00\x10JFIF\x00 thumbnail"))
+	}))
+	defer photos.Close()
+	emails := captureEmails(t)
+
+	aircraft := SampleAircraft()
+	aircraft[0].ImageThumbnailURL = photos.URL + "/ae07e0.jpg"
+	config := configuration.Config{
+		SMTPHost:  "localhost",
+		EmailFrom: "Jetspotter <jetspotter@example.com>",
+		EmailTo:   []string{"spotter@example.com", "Team <team@example.com>"},
+	}
+	var notifier Notifier
+	for _, n := range Notifiers(config) {
+		if n.Name() == Email {
+			notifier = n
+		}
+	}
+	if err := notifier.Send(context.Background(), aircraft); err != nil {
+		t.Fatal(err)
+	}
+
+	sent := emails()
+	if len(sent) != 1 || sent[0].From != "jetspotter@example.com" || strings.Join(sent[0].To, ",") != "spotter@example.com,team@example.com" {
+		t.Fatalf("expected one email to all recipients, got %+v", sent)
+	}
+	// The photo is only downloaded when the email is sent
+	if strings.Contains(sent[0].Payload, "JFIF") {
+		t.Errorf("expected the outbox not to store the photo")
+	}
+	// The text is quoted-printable, which encodes = as =3D
+	data := sent[0].Data
+	for _, expected := range []string{"multipart/related", "Content-ID: <ae07e0@jetspotter>", "Content-Type: image/jpeg", "Track aircraft: https://globe.airplanes.live/?icao=3Dae07e0"} {
+		if !strings.Contains(data, expected) {
+			t.Errorf("expected the email to contain %q", expected)
+		}
+	}
+}
+
+func TestEmailMessageLayout(t *testing.T) {
+	aircraft := SampleAircraft()
+	aircraft[0].ImageThumbnailURL = "https://t.plnspttrs.net/ae07e0.jpg"
+	aircraft[1].ImageThumbnailPath = "/images/cache/4ca7b5.jpg"
+	aircraft[1].Place = "<Hasselt & Genk>"
+
+	message, err := buildEmailMessage(aircraft, configuration.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Cached thumbnails are read from disk instead of being downloaded again
+	if message.Subject != "✈️ 2 aircraft have been spotted" || len(message.Images) != 2 || message.Images[1].Source != "/images/cache/4ca7b5.jpg" {
+		t.Errorf("unexpected subject %q or images %+v", message.Subject, message.Images)
+	}
+	for _, expected := range []string{
+		`<h2>RCH456 (REACH 456) - BOEING C-17A Globemaster III</h2>`,
+		`<img src="cid:ae07e0@jetspotter"`,
+		`<img src="cid:4ca7b5@jetspotter"`,
+		`<th align="left">Position</th><td>&lt;Hasselt &amp; Genk&gt;</td>`,
+		`<th align="left">Position source</th><td>MLAT</td>`,
+		`<a href="https://globe.airplanes.live/?icao=ae07e0">Track aircraft</a>`,
+	} {
+		if !strings.Contains(message.HTML, expected) {
+			t.Errorf("expected the HTML to contain %q", expected)
+		}
+	}
+	if !strings.HasPrefix(message.Text, "RCH456 (REACH 456) - BOEING C-17A Globemaster III\n\nRegistration: 05-5140\n") {
+		t.Errorf("unexpected text %q", message.Text)
+	}
+}
+
+func TestEmailDigestSendsOneEmailPerInterval(t *testing.T) {
+	emails := captureEmails(t)
+	config := configuration.Config{
+		SMTPHost:                   "localhost",
+		EmailFrom:                  "jetspotter@example.com",
+		EmailTo:                    []string{"spotter@example.com"},
+		NotificationTimeoutSeconds: 5,
+	}
+	notifier := emailNotifier{name: Email, config: config, digest: &emailDigest{interval: 100 * time.Millisecond}}
+
+	aircraft := SampleAircraft()
+	for _, ac := range aircraft {
+		if err := notifier.Send(context.Background(), []jetspotter.Aircraft{ac}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(emails()) != 0 {
+		t.Fatalf("expected the aircraft to be collected until the end of the interval")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(emails()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	sent := emails()
+	if len(sent) != 1 || !strings.Contains(sent[0].Data, "2_aircraft_have_been_spotted") {
+		t.Fatalf("expected one digest with both aircraft, got %d emails", len(sent))
+	}
+}
@@ -1,16 +1,44 @@
 package notification
 
 import (
+	"context"
 	"fmt"
 	"jetspotter/internal/configuration"
 	"jetspotter/internal/jetspotter"
+	"jetspotter/internal/outbox"
 
 	"github.com/gotify/go-api-client/v2/models"
 )
 
-// SendGotifyMessage sends a gotify message containing metadata of a list of aircraft
-func SendGotifyMessage(aircraft []jetspotter.Aircraft, config configuration.Config) error {
-	message, err := buildGotifyMessage(aircraft)
+func init() {
+	Register(Gotify, func(config configuration.Config) []Notifier {
+		if config.GotifyURL == "" {
+			return nil
+		}
+		var notifiers []Notifier
+		for i, token := range config.GotifyToken {
+			notifiers = append(notifiers, gotifyNotifier{name: instanceName(Gotify, i, len(config.GotifyToken)), token: token, config: config})
+		}
+		return notifiers
+	}, func(aircraft []jetspotter.Aircraft, config configuration.Config) (any, error) {
+		return buildGotifyMessage(aircraft, config)
+	})
+}
+
+// gotifyNotifier sends notifications to the application of a token on the gotify server
+type gotifyNotifier struct {
+	name   string
+	token  string
+	config configuration.Config
+}
+
+func (n gotifyNotifier) Name() string {
+	return n.name
+}
+
+// Send sends a gotify message containing metadata of a list of aircraft
+func (n gotifyNotifier) Send(ctx context.Context, aircraft []jetspotter.Aircraft) error {
+	message, err := buildGotifyMessage(aircraft, n.config)
 	if err != nil {
 		return err
 	}
@@ -18,19 +46,21 @@ func SendGotifyMessage(aircraft []jetspotter.Aircraft, config configuration.Conf
 	notification := Notification{
 		Message: message,
 		Type:    Gotify,
-		URL:     fmt.Sprintf("%s/message?token=%s", config.GotifyURL, config.GotifyToken),
+		Channel: n.name,
 	}
 
-	err = SendMessage(notification)
-	if err != nil {
-		return err
-	}
+	return SendMessage(ctx, notification)
+}
 
-	return nil
+func (n gotifyNotifier) endpoint() outbox.Endpoint {
+	return jsonEndpoint(fmt.Sprintf("%s/message?token=%s", n.config.GotifyURL, n.token))
 }
 
-func buildGotifyMessage(aircraft []jetspotter.Aircraft) (message models.MessageExternal, err error) {
-	message.Title = "An aircraft has been spotted!"
+func buildGotifyMessage(aircraft []jetspotter.Aircraft, config configuration.Config) (message models.MessageExternal, err error) {
+	message.Title, err = renderHeader(Gotify, aircraft, config)
+	if err != nil {
+		return message, err
+	}
 	message.Extras = map[string]interface{}{
 		"client::display": map[string]interface{}{
 			"contentType": "text/markdown",
@@ -38,24 +68,11 @@ func buildGotifyMessage(aircraft []jetspotter.Aircraft) (message models.MessageE
 	}
 
 	for _, ac := range aircraft {
-		message.Message += "==================\n\n"
-		message.Message += fmt.Sprintf("**Callsign**: %s\n\n", formatCallsign(ac, Markdown))
-		message.Message += fmt.Sprintf("**Registration**: %s\n\n", formatRegistration(ac, Markdown))
-		message.Message += fmt.Sprintf("**Country**: %s\n\n", ac.Country)
-		message.Message += fmt.Sprintf("**Speed:** %s\n\n", printSpeed(ac))
-		message.Message += fmt.Sprintf("**Altitude**: %s\n\n", printAltitude(ac))
-		message.Message += fmt.Sprintf("**Distance:** %s\n\n", printDistance(ac))
-		message.Message += fmt.Sprintf("**Bearing from location:** %s\n\n", printBearingFromLocation(ac))
-		message.Message += fmt.Sprintf("**Bearing to location:** %s\n\n", printBearingFromAircraft(ac))
-		message.Message += fmt.Sprintf("**Heading:** %s\n\n", printHeading(ac))
-		message.Message += fmt.Sprintf("**Cloud coverage:** %s\n\n", printCloudCoverage(ac))
-		message.Message += fmt.Sprintf("**Inbound:** %s\n\n", getInboundStatus(ac))
-		message.Message += fmt.Sprintf("**Type:** %s\n\n", ac.Type)
-		message.Message += fmt.Sprintf("**TrackerURL:** %s\n\n", ac.TrackerURL)
-		message.Message += fmt.Sprintf("**ImageURL:** %s\n\n", ac.ImageURL)
-		message.Message += fmt.Sprintf("**Origin:** %s\n\n", printOriginName(ac))
-		message.Message += fmt.Sprintf("**Destination:** %s\n\n", printDestinationName(ac))
-		message.Message += fmt.Sprintf("**Airline:** %s\n\n", printAirlineName(ac))
+		body, err := renderBody(Gotify, ac, config)
+		if err != nil {
+			return message, err
+		}
+		message.Message += body
 	}
 
 	return message, nil
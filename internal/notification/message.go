@@ -1,20 +1,28 @@
 package notification
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"jetspotter/internal/configuration"
 	"jetspotter/internal/jetspotter"
+	"jetspotter/internal/outbox"
 	"log"
 	"net/http"
+	"strings"
 )
 
 // Notification is a representation of the notfication that has to be sent
 type Notification struct {
 	Message interface{}
 	Type    string
-	URL     string
-	Token   string
+	// Name of the notifier that sends the notification, for example "Discord 2"
+	Channel string
+	// Appended to the URL of the endpoint of the channel, for example the method of an API
+	Path string
+	// Sent instead if the notification is rejected, nil if there is no alternative
+	Fallback *Notification
 }
 
 const (
@@ -26,76 +34,114 @@ const (
 	Gotify = "Gotify"
 	// Ntfy indicates the ntfy platform
 	Ntfy = "Ntfy"
+	// Telegram indicates the telegram platform
+	Telegram = "Telegram"
+	// Webhook indicates a generic webhook
+	Webhook = "Webhook"
+	// Email indicates email sent through an SMTP server
+	Email = "Email"
+	// Terminal indicates the output of jetspotter itself
+	Terminal = "Terminal"
 	// Markdown indicates markdown markup language
 	Markdown = "Markdown"
+	// HTML indicates the subset of HTML that telegram supports
+	HTML = "HTML"
 )
 
-// Format the callsign whether to display a hyperlink or not
-func formatCallsign(ac jetspotter.Aircraft, notificationType string) string {
-	if notificationType == Markdown {
-		return fmt.Sprintf("[%s](%s)", ac.Callsign, ac.TrackerURL)
-	}
+// printCallsign prints the callsign followed by how it is spoken on the radio, for example "RCH456 (REACH 456)"
+func printCallsign(ac jetspotter.Aircraft) string {
+	return ac.Callsign + printSpokenCallsign(ac)
+}
 
-	if notificationType == Slack {
-		return fmt.Sprintf("*Callsign:* <%s|%s>", ac.TrackerURL, ac.Callsign)
+func printSpokenCallsign(ac jetspotter.Aircraft) string {
+	if ac.SpokenCallsign == "" {
+		return ""
 	}
-
-	return ac.Callsign
+	return fmt.Sprintf(" (%s)", ac.SpokenCallsign)
 }
 
-// Format whether to display a hyperlink for the registration or not
-func formatRegistration(ac jetspotter.Aircraft, notificationType string) string {
-	if notificationType == Markdown {
-		if ac.ImageURL == "" {
-			return ac.Registration
-		}
-
-		return fmt.Sprintf("[%s](%s)", ac.Registration, ac.ImageURL)
+// SendMessage sends a message to a notification platform through the outbox.
+// If the first attempt fails, the error is returned and the message is retried in the background.
+func SendMessage(ctx context.Context, notification Notification) error {
+	request, err := buildRequest(notification)
+	if err != nil {
+		return err
 	}
+	return sendRequest(ctx, request.Endpoint, request)
+}
 
-	if notificationType == Slack {
-		if ac.ImageURL == "" {
-			return fmt.Sprintf("*Registration:* %s", ac.Registration)
+// sendEach sends a message for each item and returns the errors of all messages that couldn't be sent.
+// Failed messages are retried by the outbox, so the remaining ones are still sent.
+func sendEach[T any](ctx context.Context, items []T, send func(context.Context, T) error) error {
+	var errs []error
+	for _, item := range items {
+		if err := send(ctx, item); err != nil {
+			errs = append(errs, err)
 		}
-
-		return fmt.Sprintf("*Registration:* <%s|%s>", ac.ImageURL, ac.Registration)
-	}
-
-	if ac.ImageURL == "" {
-		return ac.Registration
 	}
-
-	return ac.Registration
+	return errors.Join(errs...)
 }
 
-// SendMessage sends a message to a notification platform
-func SendMessage(notification Notification) error {
+// buildRequest builds the HTTP request of a notification and of its fallback, which are sent to the endpoint of the channel
+func buildRequest(notification Notification) (outbox.Request, error) {
 	data, err := json.Marshal(notification.Message)
 	if err != nil {
-		return err
+		return outbox.Request{}, err
 	}
 
-	req, err := http.NewRequest("POST", notification.URL, bytes.NewReader(data))
-	if err != nil {
-		return err
+	request := outbox.Request{
+		Endpoint: notification.Channel,
+		Path:     notification.Path,
+		Body:     data,
+	}
+	if request.Endpoint == "" {
+		request.Endpoint = notification.Type
 	}
+	if notification.Fallback != nil {
+		fallback, err := buildRequest(*notification.Fallback)
+		if err != nil {
+			return outbox.Request{}, err
+		}
+		request.Fallback = &fallback
+	}
+	return request, nil
+}
+
+// endpointNotifier is a notifier that sends its messages as HTTP requests to an endpoint
+type endpointNotifier interface {
+	Notifier
+	endpoint() outbox.Endpoint
+}
 
-	req.Header.Set("Content-Type", "application/json")
-	if notification.Token != "" {
-		req.Header.Set("Authorization", "Bearer "+notification.Token)
+// Endpoints returns the endpoints of the configured notifiers by name, the outbox sends their requests to them.
+// They aren't part of the messages, because the URLs and headers of most endpoints contain credentials.
+func Endpoints(config configuration.Config) map[string]outbox.Endpoint {
+	endpoints := make(map[string]outbox.Endpoint)
+	for _, notifier := range Notifiers(config) {
+		if n, ok := notifier.(endpointNotifier); ok {
+			endpoints[n.Name()] = n.endpoint()
+		}
 	}
+	return endpoints
+}
 
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return err
+// jsonEndpoint is an endpoint that JSON messages are posted to
+func jsonEndpoint(url string) outbox.Endpoint {
+	return outbox.Endpoint{
+		Method: http.MethodPost,
+		URL:    url,
+		Header: map[string]string{"Content-Type": "application/json"},
 	}
+}
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		log.Printf("%s\n", string(data))
-		return fmt.Errorf("received status code %v", resp.StatusCode)
+// sendRequest sends an HTTP request to a channel through the outbox
+func sendRequest(ctx context.Context, channel string, request outbox.Request) error {
+	err := jetspotter.Outbox().Send(ctx, channel, outbox.HTTP, request)
+	if err != nil {
+		return err
 	}
 
-	log.Printf("A %s notification has been sent!\n", notification.Type)
+	log.Printf("A %s notification has been sent!\n", channel)
 	return nil
 }
 
@@ -107,6 +153,9 @@ func printAltitude(ac jetspotter.Aircraft) string {
 	if ac.OnGround {
 		return "On ground"
 	}
+	if ac.HeightAboveGround != nil {
+		return fmt.Sprintf("%vft | %dm (%.0fft AGL)", ac.Altitude, jetspotter.ConvertFeetToMeters(ac.Altitude), *ac.HeightAboveGround)
+	}
 	return fmt.Sprintf("%vft | %dm", ac.Altitude, jetspotter.ConvertFeetToMeters(ac.Altitude))
 }
 
@@ -114,27 +163,22 @@ func printDistance(ac jetspotter.Aircraft) string {
 	return fmt.Sprintf("%dkm", ac.Distance)
 }
 
-func printBearingFromLocation(ac jetspotter.Aircraft) string {
-	return fmt.Sprintf("%.0f°", ac.BearingFromLocation)
-}
-
-func printHeading(ac jetspotter.Aircraft) string {
-	return fmt.Sprintf("%.0f°", ac.Heading)
-}
-
-func printBearingFromAircraft(ac jetspotter.Aircraft) string {
-	return fmt.Sprintf("%.0f°", ac.BearingFromAircraft)
-}
-
 func printCloudCoverage(ac jetspotter.Aircraft) string {
-	return fmt.Sprintf("%d%%", ac.CloudCoverage)
-}
+	coverage := fmt.Sprintf("%d%%", ac.CloudCoverage)
+	switch {
+	case ac.ContrailPersistent:
+		coverage += " | persistent contrail likely"
+	case ac.ContrailLikely:
+		coverage += " | contrail likely"
+	}
 
-func getInboundStatus(ac jetspotter.Aircraft) string {
-	if ac.Inbound {
-		return "Yes"
+	if ac.Conditions == "" {
+		return coverage
 	}
-	return "No"
+	if ac.Runway != "" {
+		return fmt.Sprintf("%s | %s: %s, runway %s", coverage, ac.ConditionsSource, ac.Conditions, ac.Runway)
+	}
+	return fmt.Sprintf("%s | %s: %s", coverage, ac.ConditionsSource, ac.Conditions)
 }
 
 func printOriginName(ac jetspotter.Aircraft) string {
@@ -151,6 +195,65 @@ func printDestinationName(ac jetspotter.Aircraft) string {
 	return ac.Destination.Name
 }
 
+// printPlace prints the position of the aircraft relative to nearby places
+func printPlace(ac jetspotter.Aircraft) string {
+	if ac.Place == "" {
+		return "N/A"
+	}
+	return ac.Place
+}
+
+// printAirspaces prints the airspaces that the aircraft is in
+func printAirspaces(ac jetspotter.Aircraft) string {
+	if len(ac.Airspaces) == 0 {
+		return "N/A"
+	}
+	return strings.Join(ac.Airspaces, ", ")
+}
+
+// printTelemetry prints the air data, wind, temperatures and autopilot settings that the aircraft reports
+func printTelemetry(ac jetspotter.Aircraft) string {
+	var parts []string
+	if ac.IndicatedAirSpeed != nil {
+		parts = append(parts, fmt.Sprintf("IAS %dkt", *ac.IndicatedAirSpeed))
+	}
+	if ac.TrueAirSpeed != nil {
+		parts = append(parts, fmt.Sprintf("TAS %dkt", *ac.TrueAirSpeed))
+	}
+	if ac.Mach != nil {
+		parts = append(parts, fmt.Sprintf("Mach %.2f", *ac.Mach))
+	}
+	if ac.VerticalRate != nil {
+		parts = append(parts, fmt.Sprintf("%+dft/min", *ac.VerticalRate))
+	}
+	if ac.WindDirection != nil && ac.WindSpeed != nil {
+		parts = append(parts, fmt.Sprintf("Wind %03d°/%dkt", *ac.WindDirection, *ac.WindSpeed))
+	}
+	if ac.OutsideAirTemperature != nil {
+		parts = append(parts, fmt.Sprintf("OAT %d°C", *ac.OutsideAirTemperature))
+	}
+	if ac.TotalAirTemperature != nil {
+		parts = append(parts, fmt.Sprintf("TAT %d°C", *ac.TotalAirTemperature))
+	}
+	if ac.SelectedAltitude != nil {
+		parts = append(parts, fmt.Sprintf("Selected altitude %dft", *ac.SelectedAltitude))
+	}
+	if ac.FMSAltitude != nil {
+		parts = append(parts, fmt.Sprintf("FMS altitude %dft", *ac.FMSAltitude))
+	}
+	if ac.SelectedHeading != nil {
+		parts = append(parts, fmt.Sprintf("Selected heading %.0f°", *ac.SelectedHeading))
+	}
+	if ac.QNH != nil {
+		parts = append(parts, fmt.Sprintf("QNH %.0fhPa", *ac.QNH))
+	}
+
+	if len(parts) == 0 {
+		return "N/A"
+	}
+	return strings.Join(parts, ", ")
+}
+
 func printAirlineName(ac jetspotter.Aircraft) string {
 	if ac.Airline.Name == "" {
 		return "N/A"
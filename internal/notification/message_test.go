@@ -0,0 +1,40 @@
+package notification
+
+import (
+	"jetspotter/internal/configuration"
+	"jetspotter/internal/jetspotter"
+	"strings"
+	"testing"
+)
+
+func TestPrintTelemetry(t *testing.T) {
+	selected, fms, qnh := 24000, 35000, 1013.2
+	ac := jetspotter.Aircraft{SelectedAltitude: &selected, FMSAltitude: &fms, QNH: &qnh}
+	if telemetry := printTelemetry(ac); telemetry != "Selected altitude 24000ft, FMS altitude 35000ft, QNH 1013hPa" {
+		t.Errorf("unexpected telemetry %q", telemetry)
+	}
+
+	if telemetry := printTelemetry(jetspotter.Aircraft{}); telemetry != "N/A" {
+		t.Errorf("expected N/A without telemetry, got %q", telemetry)
+	}
+}
+
+func TestPositionSourceIsKeptOutOfThePlace(t *testing.T) {
+	ac := jetspotter.Aircraft{Source: jetspotter.SourceMLAT}
+	if place := printPlace(ac); place != "N/A" {
+		t.Errorf("expected the place to be N/A, got %q", place)
+	}
+
+	body, err := renderBody(Terminal, ac, configuration.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(body, "Position: N/A\nPosition source: MLAT\nAirspaces: N/A\n") {
+		t.Errorf("expected the source on its own line, got %q", body)
+	}
+
+	ac.Source = jetspotter.SourceADSB
+	if body, _ := renderBody(Terminal, ac, configuration.Config{}); strings.Contains(body, "Position source") {
+		t.Errorf("expected only multilateration to be shown, got %q", body)
+	}
+}
@@ -0,0 +1,88 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"jetspotter/internal/configuration"
+	"jetspotter/internal/jetspotter"
+	"log"
+	"sync"
+	"time"
+)
+
+// Notifier sends notifications about spotted aircraft to a single channel, for example one Discord webhook
+type Notifier interface {
+	// Name identifies the channel in the logs, for example "Discord 2"
+	Name() string
+	// Send notifies about the aircraft, it gives up when the context is done
+	Send(ctx context.Context, aircraft []jetspotter.Aircraft) error
+}
+
+// Factory creates the notifiers of a kind that are configured, none if the kind isn't configured
+type Factory func(config configuration.Config) []Notifier
+
+// Renderer returns the messages that the notifiers of a kind send about the aircraft, without sending them
+type Renderer func(aircraft []jetspotter.Aircraft, config configuration.Config) (any, error)
+
+type registration struct {
+	kind     string
+	factory  Factory
+	renderer Renderer
+}
+
+// registry contains the kinds of notifiers in the order in which they are registered
+var registry []registration
+
+// Register adds a kind of notifier, each kind is registered once when the package is initialized.
+// The renderer is used to preview the messages of the kind.
+func Register(kind string, factory Factory, renderer Renderer) {
+	for _, r := range registry {
+		if r.kind == kind {
+			panic(fmt.Sprintf("notifier %s is registered twice", kind))
+		}
+	}
+	registry = append(registry, registration{kind: kind, factory: factory, renderer: renderer})
+}
+
+// Notifiers creates the notifiers of all kinds that are configured.
+func Notifiers(config configuration.Config) []Notifier {
+	var notifiers []Notifier
+	for _, r := range registry {
+		notifiers = append(notifiers, r.factory(config)...)
+	}
+	return notifiers
+}
+
+// instanceName names one of the count notifiers of a kind, they are only numbered if there is more than one
+func instanceName(kind string, index, count int) string {
+	if count == 1 {
+		return kind
+	}
+	return fmt.Sprintf("%s %d", kind, index+1)
+}
+
+// Dispatch sends the aircraft to all notifiers concurrently, each of them is cancelled after the timeout.
+// A notifier that fails doesn't affect the others, the errors of all notifiers are logged and returned together.
+func Dispatch(notifiers []Notifier, aircraft []jetspotter.Aircraft, timeout time.Duration) error {
+	errs := make([]error, len(notifiers))
+
+	var wg sync.WaitGroup
+	for i, notifier := range notifiers {
+		wg.Add(1)
+		go func(i int, notifier Notifier) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			if err := notifier.Send(ctx, aircraft); err != nil {
+				errs[i] = fmt.Errorf("%s: %w", notifier.Name(), err)
+				log.Printf("Failed to send %s notification: %v", notifier.Name(), err)
+			}
+		}(i, notifier)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,165 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"jetspotter/internal/configuration"
+	"jetspotter/internal/jetspotter"
+	"jetspotter/internal/outbox"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// useEndpoints sends the HTTP messages of the test to the endpoints of the notifiers of the configuration
+func useEndpoints(t *testing.T, config configuration.Config) {
+	jetspotter.Outbox().Handle(outbox.HTTP, outbox.HTTPDeliverer(Endpoints(config)))
+	t.Cleanup(func() {
+		jetspotter.Outbox().Handle(outbox.HTTP, nil)
+	})
+}
+
+type fakeNotifier struct {
+	name  string
+	delay time.Duration
+	err   error
+	sent  atomic.Int32
+}
+
+func (n *fakeNotifier) Name() string {
+	return n.name
+}
+
+func (n *fakeNotifier) Send(ctx context.Context, aircraft []jetspotter.Aircraft) error {
+	select {
+	case <-time.After(n.delay):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if n.err != nil {
+		return n.err
+	}
+	n.sent.Add(int32(len(aircraft)))
+	return nil
+}
+
+func TestDispatchReportsErrorsPerNotifier(t *testing.T) {
+	working := &fakeNotifier{name: "working"}
+	failing := &fakeNotifier{name: "failing", err: errors.New("service unavailable")}
+	hanging := &fakeNotifier{name: "hanging", delay: time.Minute}
+
+	start := time.Now()
+	err := Dispatch([]Notifier{failing, hanging, working}, []jetspotter.Aircraft{{ICAO: "44c1e5"}}, 100*time.Millisecond)
+	if time.Since(start) > 5*time.Second {
+		t.Fatalf("expected the hanging notifier to be cancelled after its timeout")
+	}
+
+	if working.sent.Load() != 1 {
+		t.Errorf("expected the working notifier to send despite the others failing")
+	}
+	if err == nil || !strings.Contains(err.Error(), "failing: service unavailable") || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the errors of the failing and the hanging notifier, got %v", err)
+	}
+}
+
+func TestNotifiersSupportMultipleInstances(t *testing.T) {
+	config := configuration.Config{
+		DiscordWebHookURL: []string{"https://discord.example/1", "https://discord.example/2"},
+		NtfyTopic:         []string{"jets"},
+		// Gotify tokens are ignored without a server
+		GotifyToken: []string{"token"},
+	}
+
+	var names []string
+	for _, notifier := range Notifiers(config) {
+		names = append(names, notifier.Name())
+	}
+
+	expected := "Discord 1,Discord 2,Ntfy,Terminal"
+	if strings.Join(names, ",") != expected {
+		t.Errorf("expected notifiers %s, got %s", expected, strings.Join(names, ","))
+	}
+}
+
+func TestDiscordInstancesUseTheirOwnWebhook(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	config := configuration.Config{DiscordWebHookURL: []string{server.URL + "/broken", server.URL + "/working"}}
+	useEndpoints(t, config)
+	var discord []Notifier
+	for _, notifier := range Notifiers(config) {
+		if strings.HasPrefix(notifier.Name(), Discord) {
+			discord = append(discord, notifier)
+		}
+	}
+
+	aircraft := []jetspotter.Aircraft{{ICAO: "44c1e5", Callsign: "BAF01"}}
+	if err := discord[0].Send(context.Background(), aircraft); err == nil {
+		t.Errorf("expected an error from the broken webhook")
+	}
+	if err := discord[1].Send(context.Background(), aircraft); err != nil {
+		t.Errorf("expected the working webhook to succeed, got %v", err)
+	}
+	if strings.Join(paths, ",") != "/broken,/working" {
+		t.Errorf("unexpected requests %v", paths)
+	}
+}
+
+func TestCredentialsAreKeptOutOfTheOutbox(t *testing.T) {
+	config := configuration.Config{
+		DiscordWebHookURL: []string{"https://discord.example/api/webhooks/1/discord-secret"},
+		SlackWebHookURL:   []string{"https://hooks.slack.example/services/slack-secret"},
+		GotifyURL:         "https://gotify.example",
+		GotifyToken:       []string{"gotify-secret"},
+		NtfyServer:        "https://ntfy.example",
+		NtfyTopic:         []string{"jets"},
+		NtfyToken:         "ntfy-secret",
+		TelegramBotToken:  "123:telegram-secret",
+		TelegramChatID:    []string{"123456789"},
+		TelegramAPIURL:    "https://telegram.example",
+	}
+
+	var payloads []string
+	jetspotter.Outbox().Handle(outbox.HTTP, func(ctx context.Context, payload json.RawMessage) error {
+		payloads = append(payloads, string(payload))
+		return nil
+	})
+	t.Cleanup(func() {
+		jetspotter.Outbox().Handle(outbox.HTTP, nil)
+	})
+
+	endpoints := Endpoints(config)
+	for _, notifier := range Notifiers(config) {
+		if notifier.Name() == Terminal {
+			continue
+		}
+		if _, found := endpoints[notifier.Name()]; !found {
+			t.Errorf("expected an endpoint for %s", notifier.Name())
+		}
+		if err := notifier.Send(context.Background(), SampleAircraft()[:1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(payloads) != 5 {
+		t.Fatalf("expected a message for every notifier, got %d", len(payloads))
+	}
+	for _, payload := range payloads {
+		if strings.Contains(payload, "secret") {
+			t.Errorf("expected the credentials to be kept out of the outbox, got %s", payload)
+		}
+	}
+}
@@ -1,9 +1,10 @@
 package notification
 
 import (
-	"fmt"
+	"context"
 	"jetspotter/internal/configuration"
 	"jetspotter/internal/jetspotter"
+	"jetspotter/internal/outbox"
 )
 
 type NtfyAction struct {
@@ -22,31 +23,61 @@ type NtfyNotification struct {
 	Tags     []string     `json:"tags,omitempty"`
 }
 
-// SendNtfyMessage sends a ntfy message containing metadata of aircraft
+func init() {
+	Register(Ntfy, func(config configuration.Config) []Notifier {
+		var notifiers []Notifier
+		for i, topic := range config.NtfyTopic {
+			notifiers = append(notifiers, ntfyNotifier{name: instanceName(Ntfy, i, len(config.NtfyTopic)), topic: topic, config: config})
+		}
+		return notifiers
+	}, func(aircraft []jetspotter.Aircraft, config configuration.Config) (any, error) {
+		var messages []NtfyNotification
+		for _, ac := range aircraft {
+			message, err := buildNtfyMessage(ac, "preview", config)
+			if err != nil {
+				return nil, err
+			}
+			messages = append(messages, message)
+		}
+		return messages, nil
+	})
+}
+
+// ntfyNotifier publishes notifications to a topic of the ntfy server
+type ntfyNotifier struct {
+	name   string
+	topic  string
+	config configuration.Config
+}
+
+func (n ntfyNotifier) Name() string {
+	return n.name
+}
+
+// Send sends ntfy messages containing metadata of aircraft
 // Each aircraft will have its own separate notification
-func SendNtfyMessage(aircraft []jetspotter.Aircraft, config configuration.Config) error {
-	// Send a separate message for each aircraft
-	for _, ac := range aircraft {
+func (n ntfyNotifier) Send(ctx context.Context, aircraft []jetspotter.Aircraft) error {
+	return sendEach(ctx, aircraft, func(ctx context.Context, ac jetspotter.Aircraft) error {
 		// Build a message for a single aircraft
-		singleAircraftMessage, err := buildNtfyMessage(ac, config)
+		singleAircraftMessage, err := buildNtfyMessage(ac, n.topic, n.config)
 		if err != nil {
 			return err
 		}
 
-		notification := Notification{
+		return SendMessage(ctx, Notification{
 			Message: singleAircraftMessage,
 			Type:    Ntfy,
-			URL:     config.NtfyServer,
-			Token:   config.NtfyToken,
-		}
+			Channel: n.name,
+		})
+	})
+}
 
-		err = SendMessage(notification)
-		if err != nil {
-			return err
-		}
+func (n ntfyNotifier) endpoint() outbox.Endpoint {
+	endpoint := jsonEndpoint(n.config.NtfyServer)
+	if n.config.NtfyToken != "" {
+		endpoint.Header["Authorization"] = "Bearer " + n.config.NtfyToken
 	}
-
-	return nil
+	return endpoint
 }
 
 // Constructor function for NtfyAction thats sets default value for Action and Clear
@@ -59,28 +90,19 @@ func AddNtfyAction(label, url string) NtfyAction {
 	}
 }
 
-func buildNtfyMessage(aircraft jetspotter.Aircraft, config configuration.Config) (message NtfyNotification, err error) {
-	message.Title = "An aircraft has been spotted!"
-	message.Topic = config.NtfyTopic
+func buildNtfyMessage(aircraft jetspotter.Aircraft, topic string, config configuration.Config) (message NtfyNotification, err error) {
+	message.Title, err = renderTitle(Ntfy, aircraft, config)
+	if err != nil {
+		return message, err
+	}
+	message.Topic = topic
 	message.Tags = []string{"jetspotter"}
 	message.Markdown = true
 
-	message.Message += fmt.Sprintf("Callsign:               %s\n", formatCallsign(aircraft, Markdown))
-	message.Message += fmt.Sprintf("Registration:           %s\n", formatRegistration(aircraft, Markdown))
-	message.Message += fmt.Sprintf("Country:                %s\n", aircraft.Country)
-	message.Message += fmt.Sprintf("Speed:                  %s\n", printSpeed(aircraft))
-	message.Message += fmt.Sprintf("Altitude:               %s\n", printAltitude(aircraft))
-	message.Message += fmt.Sprintf("Distance:               %s\n", printDistance(aircraft))
-	message.Message += fmt.Sprintf("Bearing from location:  %s\n", printBearingFromLocation(aircraft))
-	message.Message += fmt.Sprintf("Bearing to location:    %s\n", printBearingFromAircraft(aircraft))
-	message.Message += fmt.Sprintf("Heading:                %s\n", printHeading(aircraft))
-	message.Message += fmt.Sprintf("Cloud coverage:         %s\n", printCloudCoverage(aircraft))
-	message.Message += fmt.Sprintf("Inbound:                %s\n", getInboundStatus(aircraft))
-	message.Message += fmt.Sprintf("Type:                   %s\n", aircraft.Type)
-	message.Message += fmt.Sprintf("Origin:                 %s\n", printOriginName(aircraft))
-	message.Message += fmt.Sprintf("Destination:            %s\n", printDestinationName(aircraft))
-	message.Message += fmt.Sprintf("Airline:                %s\n", printAirlineName(aircraft))
-	message.Message += fmt.Sprintf("ImageURL:               %s\n", aircraft.ImageURL)
+	message.Message, err = renderBody(Ntfy, aircraft, config)
+	if err != nil {
+		return message, err
+	}
 	// Add Ntfy Actions
 	message.Actions = []NtfyAction{
 		AddNtfyAction("Track Aircraft", aircraft.TrackerURL),
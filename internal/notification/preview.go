@@ -0,0 +1,85 @@
+package notification
+
+import (
+	"fmt"
+	"jetspotter/internal/configuration"
+	"jetspotter/internal/jetspotter"
+	"strings"
+)
+
+// Preview returns the messages that a kind of notifier sends about the aircraft, without sending them.
+// The kind is matched case insensitively, sample aircraft are used if there are no aircraft.
+func Preview(kind string, aircraft []jetspotter.Aircraft, config configuration.Config) (any, error) {
+	if len(aircraft) == 0 {
+		aircraft = SampleAircraft()
+	}
+
+	for _, r := range registry {
+		if strings.EqualFold(r.kind, kind) {
+			return r.renderer(aircraft, config)
+		}
+	}
+	return nil, fmt.Errorf("unknown notification channel %q", kind)
+}
+
+// SampleAircraft returns aircraft to preview the templates with, a military transport and an airliner
+func SampleAircraft() []jetspotter.Aircraft {
+	descent, mach, oat := -1408, 0.784, -31
+	heightAboveGround := 3150.0
+
+	return []jetspotter.Aircraft{
+		{
+			ICAO:                "ae07e0",
+			Callsign:            "RCH456",
+			SpokenCallsign:      "REACH 456",
+			Registration:        "05-5140",
+			Type:                "C17",
+			Description:         "BOEING C-17A Globemaster III",
+			Country:             "United States",
+			CountryISO:          "US",
+			CountryFlag:         "🇺🇸",
+			Military:            true,
+			Altitude:            3500,
+			HeightAboveGround:   &heightAboveGround,
+			Speed:               210,
+			Distance:            7,
+			Place:               "3 km N of Peer",
+			Airspaces:           []string{"TMA KLEINE BROGEL"},
+			BearingFromLocation: 34,
+			BearingFromAircraft: 214,
+			Heading:             235,
+			Inbound:             true,
+			CloudCoverage:       25,
+			Source:              jetspotter.SourceADSB,
+			VerticalRate:        &descent,
+			TrackerURL:          "https://globe.airplanes.live/?icao=ae07e0",
+		},
+		{
+			ICAO:                  "4ca7b5",
+			Callsign:              "RYR8TK",
+			Registration:          "EI-DWF",
+			Type:                  "B738",
+			Description:           "BOEING 737-800",
+			Country:               "Ireland",
+			CountryISO:            "IE",
+			CountryFlag:           "🇮🇪",
+			Altitude:              37000,
+			Speed:                 452,
+			Distance:              28,
+			Place:                 "12 km SE of Hasselt",
+			BearingFromLocation:   137,
+			BearingFromAircraft:   317,
+			Heading:               92,
+			CloudCoverage:         60,
+			ContrailLikely:        true,
+			Source:                jetspotter.SourceMLAT,
+			Mach:                  &mach,
+			OutsideAirTemperature: &oat,
+			Airline:               jetspotter.Airline{Name: "Ryanair"},
+			Origin:                jetspotter.Airport{Name: "Dublin Airport"},
+			Destination:           jetspotter.Airport{Name: "Berlin Brandenburg Airport"},
+			TrackerURL:            "https://globe.airplanes.live/?icao=4ca7b5",
+			ImageURL:              "https://www.planespotters.net/hex/4CA7B5",
+		},
+	}
+}
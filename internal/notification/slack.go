@@ -1,9 +1,11 @@
 package notification
 
 import (
+	"context"
 	"fmt"
 	"jetspotter/internal/configuration"
 	"jetspotter/internal/jetspotter"
+	"jetspotter/internal/outbox"
 )
 
 // SlackMessage is used to create a slack message
@@ -33,93 +35,49 @@ type Field struct {
 	Text string `json:"text,omitempty"`
 }
 
-func buildSlackMessage(aircraft []jetspotter.Aircraft) (SlackMessage, error) {
+// maxSlackFields is the maximum number of fields in a section of a Slack message
+const maxSlackFields = 10
+
+func buildSlackMessage(aircraft []jetspotter.Aircraft, config configuration.Config) (SlackMessage, error) {
+	header, err := renderHeader(Slack, aircraft, config)
+	if err != nil {
+		return SlackMessage{}, err
+	}
 
 	var blocks []Block
-	blocks = append(blocks, Block{
-		Type: "section",
-		Fields: []Field{
-			{
-				Type: "mrkdwn",
-				Text: ":airplane: A jet has been spotted! :airplane:",
-			},
-		},
-	})
+	if header != "" {
+		blocks = append(blocks, textSection(header))
+	}
 
 	for _, ac := range aircraft {
-		// First section block with first 8 fields
-		blocks = append(blocks, Block{
-			Type: "section",
-			Fields: []Field{
-				{
-					Type: "mrkdwn",
-					Text: fmt.Sprintf("*Callsign:* <%s|%s>", ac.TrackerURL, ac.Callsign),
-				},
-				{
-					Type: "mrkdwn",
-					Text: formatRegistration(ac, Slack),
-				},
-				{
-					Type: "mrkdwn",
-					Text: fmt.Sprintf("*Country:* %s", ac.Country),
-				},
-				{
-					Type: "mrkdwn",
-					Text: fmt.Sprintf("*Speed:* %s", printSpeed(ac)),
-				},
-				{
-					Type: "mrkdwn",
-					Text: fmt.Sprintf("*Altitude:* %s", printAltitude(ac)),
-				},
-				{
-					Type: "mrkdwn",
-					Text: fmt.Sprintf("*Distance:* %s", printDistance(ac)),
-				},
-				{
-					Type: "mrkdwn",
-					Text: fmt.Sprintf("*Bearing from location:* %s", printBearingFromLocation(ac)),
-				},
-				{
-					Type: "mrkdwn",
-					Text: fmt.Sprintf("*Heading:* %s", printHeading(ac)),
-				},
-			},
-		})
+		title, err := renderTitle(Slack, ac, config)
+		if err != nil {
+			return SlackMessage{}, err
+		}
+		if title != "" {
+			blocks = append(blocks, textSection(title))
+		}
 
-		// Second section block with remaining 7 fields
-		blocks = append(blocks, Block{
-			Type: "section",
-			Fields: []Field{
-				{
-					Type: "mrkdwn",
-					Text: fmt.Sprintf("*Bearing from aircraft:* %s", printBearingFromAircraft(ac)),
-				},
-				{
-					Type: "mrkdwn",
-					Text: fmt.Sprintf("*Cloud coverage:* %s", printCloudCoverage(ac)),
-				},
-				{
-					Type: "mrkdwn",
-					Text: fmt.Sprintf("*Inbound:* %s", getInboundStatus(ac)),
-				},
-				{
-					Type: "mrkdwn",
-					Text: fmt.Sprintf("*Type:* %s", ac.Description),
-				},
-				{
-					Type: "mrkdwn",
-					Text: fmt.Sprintf("*Origin:* %s", printOriginName(ac)),
-				},
-				{
-					Type: "mrkdwn",
-					Text: fmt.Sprintf("*Destination:* %s", printDestinationName(ac)),
-				},
-				{
-					Type: "mrkdwn",
-					Text: fmt.Sprintf("*Airline:* %s", printAirlineName(ac)),
-				},
-			},
-		})
+		body, err := renderBody(Slack, ac, config)
+		if err != nil {
+			return SlackMessage{}, err
+		}
+		// Every group of fields is a section, split in sections of at most maxSlackFields fields
+		for _, group := range parseFields(body) {
+			section := Block{Type: "section"}
+			for _, f := range group {
+				if len(section.Fields) == maxSlackFields {
+					blocks = append(blocks, section)
+					section = Block{Type: "section"}
+				}
+				text := f.Value
+				if f.Name != "" {
+					text = fmt.Sprintf("*%s:* %s", f.Name, f.Value)
+				}
+				section.Fields = append(section.Fields, Field{Type: "mrkdwn", Text: text})
+			}
+			blocks = append(blocks, section)
+		}
 
 		imageURL := ac.ImageThumbnailURL
 		if imageURL != "" {
@@ -147,35 +105,83 @@ func buildSlackMessage(aircraft []jetspotter.Aircraft) (SlackMessage, error) {
 	return slackMessage, nil
 }
 
-// SendSlackMessage sends a slack message containing metadata of a list of aircraft
-func SendSlackMessage(aircraft []jetspotter.Aircraft, config configuration.Config) error {
-	// Split aircraft into chunks to stay within Slack's block limit (max 50 blocks per message)
-	// Each aircraft uses approximately 4 blocks (2 sections + image + divider)
-	const maxAircraftPerMessage = 10
+// textSection is a section with a single field of text
+func textSection(text string) Block {
+	return Block{
+		Type: "section",
+		Fields: []Field{
+			{
+				Type: "mrkdwn",
+				Text: text,
+			},
+		},
+	}
+}
 
-	for i := 0; i < len(aircraft); i += maxAircraftPerMessage {
-		end := i + maxAircraftPerMessage
-		if end > len(aircraft) {
-			end = len(aircraft)
+func init() {
+	Register(Slack, func(config configuration.Config) []Notifier {
+		var notifiers []Notifier
+		for i, url := range config.SlackWebHookURL {
+			notifiers = append(notifiers, slackNotifier{name: instanceName(Slack, i, len(config.SlackWebHookURL)), url: url, config: config})
 		}
+		return notifiers
+	}, func(aircraft []jetspotter.Aircraft, config configuration.Config) (any, error) {
+		return buildSlackMessages(aircraft, config)
+	})
+}
 
-		chunk := aircraft[i:end]
-		message, err := buildSlackMessage(chunk)
-		if err != nil {
-			return err
-		}
+// slackNotifier sends notifications to a Slack webhook
+type slackNotifier struct {
+	name   string
+	url    string
+	config configuration.Config
+}
 
-		notification := Notification{
+func (n slackNotifier) Name() string {
+	return n.name
+}
+
+// Send sends slack messages containing metadata of a list of aircraft
+func (n slackNotifier) Send(ctx context.Context, aircraft []jetspotter.Aircraft) error {
+	messages, err := buildSlackMessages(aircraft, n.config)
+	if err != nil {
+		return err
+	}
+
+	return sendEach(ctx, messages, func(ctx context.Context, message SlackMessage) error {
+		err := SendMessage(ctx, Notification{
 			Message: message,
 			Type:    Slack,
-			URL:     config.SlackWebHookURL,
+			Channel: n.name,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to send slack message: %w", err)
+		}
+		return nil
+	})
+}
+
+func (n slackNotifier) endpoint() outbox.Endpoint {
+	return jsonEndpoint(n.url)
+}
+
+// buildSlackMessages splits the aircraft into chunks to stay within Slack's block limit (max 50 blocks per message)
+// Each aircraft uses approximately 4 blocks (2 sections + image + divider)
+func buildSlackMessages(aircraft []jetspotter.Aircraft, config configuration.Config) ([]SlackMessage, error) {
+	const maxAircraftPerMessage = 10
+
+	var messages []SlackMessage
+	for i := 0; i < len(aircraft); i += maxAircraftPerMessage {
+		end := i + maxAircraftPerMessage
+		if end > len(aircraft) {
+			end = len(aircraft)
 		}
 
-		err = SendMessage(notification)
+		message, err := buildSlackMessage(aircraft[i:end], config)
 		if err != nil {
-			return fmt.Errorf("failed to send slack message: %w", err)
+			return nil, err
 		}
+		messages = append(messages, message)
 	}
-
-	return nil
+	return messages, nil
 }
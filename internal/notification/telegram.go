@@ -0,0 +1,164 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"jetspotter/internal/configuration"
+	"jetspotter/internal/jetspotter"
+	"jetspotter/internal/outbox"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxTelegramCaption is the maximum length of the caption of a photo, longer messages are sent without the photo
+const maxTelegramCaption = 1024
+
+// TelegramMessage is the body of a sendPhoto or sendMessage request of the Telegram Bot API,
+// a message with a photo has a caption instead of a text
+type TelegramMessage struct {
+	ChatID          string               `json:"chat_id"`
+	MessageThreadID int                  `json:"message_thread_id,omitempty"`
+	Photo           string               `json:"photo,omitempty"`
+	Caption         string               `json:"caption,omitempty"`
+	Text            string               `json:"text,omitempty"`
+	ParseMode       string               `json:"parse_mode"`
+	ReplyMarkup     *TelegramReplyMarkup `json:"reply_markup,omitempty"`
+}
+
+// TelegramReplyMarkup contains the rows of buttons below a message
+type TelegramReplyMarkup struct {
+	InlineKeyboard [][]TelegramButton `json:"inline_keyboard"`
+}
+
+// TelegramButton opens a URL
+type TelegramButton struct {
+	Text string `json:"text"`
+	URL  string `json:"url"`
+}
+
+func init() {
+	Register(Telegram, func(config configuration.Config) []Notifier {
+		if config.TelegramBotToken == "" {
+			return nil
+		}
+		var notifiers []Notifier
+		for i, chat := range config.TelegramChatID {
+			chatID, topic := parseTelegramChat(chat)
+			notifiers = append(notifiers, telegramNotifier{
+				name:   instanceName(Telegram, i, len(config.TelegramChatID)),
+				chatID: chatID,
+				topic:  topic,
+				config: config,
+			})
+		}
+		return notifiers
+	}, func(aircraft []jetspotter.Aircraft, config configuration.Config) (any, error) {
+		var messages []TelegramMessage
+		for _, ac := range aircraft {
+			message, err := buildTelegramMessage(ac, "preview", 0, config)
+			if err != nil {
+				return nil, err
+			}
+			messages = append(messages, message)
+		}
+		return messages, nil
+	})
+}
+
+// parseTelegramChat splits a chat into its ID and the ID of the topic of a forum group, which is 0 without a topic.
+// The format is validated by the configuration.
+func parseTelegramChat(chat string) (string, int) {
+	chatID, topic, found := strings.Cut(chat, ":")
+	if !found {
+		return chat, 0
+	}
+	topicID, _ := strconv.Atoi(topic)
+	return chatID, topicID
+}
+
+// telegramNotifier sends notifications to a chat, or a topic of a forum group, with the Telegram bot
+type telegramNotifier struct {
+	name   string
+	chatID string
+	topic  int
+	config configuration.Config
+}
+
+func (n telegramNotifier) Name() string {
+	return n.name
+}
+
+// Send sends a telegram message for each aircraft, with its photo if there is one
+func (n telegramNotifier) Send(ctx context.Context, aircraft []jetspotter.Aircraft) error {
+	return sendEach(ctx, aircraft, func(ctx context.Context, ac jetspotter.Aircraft) error {
+		message, err := buildTelegramMessage(ac, n.chatID, n.topic, n.config)
+		if err != nil {
+			return err
+		}
+
+		notification := n.notification(message, "sendMessage")
+		if message.Photo != "" {
+			// Telegram rejects photos that it can't download, the message is sent without the photo instead
+			text := message
+			text.Photo, text.Caption, text.Text = "", "", message.Caption
+			fallback := n.notification(text, "sendMessage")
+			notification = n.notification(message, "sendPhoto")
+			notification.Fallback = &fallback
+		}
+
+		return SendMessage(ctx, notification)
+	})
+}
+
+// notification is a request to a method of the Bot API
+func (n telegramNotifier) notification(message TelegramMessage, method string) Notification {
+	return Notification{
+		Message: message,
+		Type:    Telegram,
+		Channel: n.name,
+		Path:    "/" + method,
+	}
+}
+
+// endpoint is the Bot API of the bot, the token is part of the URL
+func (n telegramNotifier) endpoint() outbox.Endpoint {
+	return jsonEndpoint(fmt.Sprintf("%s/bot%s", n.config.TelegramAPIURL, n.config.TelegramBotToken))
+}
+
+func buildTelegramMessage(aircraft jetspotter.Aircraft, chatID string, topic int, config configuration.Config) (message TelegramMessage, err error) {
+	message.ChatID = chatID
+	message.MessageThreadID = topic
+	message.ParseMode = HTML
+
+	title, err := renderTitle(Telegram, aircraft, config)
+	if err != nil {
+		return message, err
+	}
+	body, err := renderBody(Telegram, aircraft, config)
+	if err != nil {
+		return message, err
+	}
+	text := strings.TrimSpace(strings.TrimSpace(title) + "\n\n" + strings.TrimSpace(body))
+
+	// The length of the HTML is more than the length of the caption that Telegram counts, so this errs on the safe side
+	if aircraft.ImageThumbnailURL != "" && utf8.RuneCountInString(text) <= maxTelegramCaption {
+		message.Photo = aircraft.ImageThumbnailURL
+		message.Caption = text
+	} else {
+		message.Text = text
+	}
+
+	var buttons []TelegramButton
+	if aircraft.TrackerURL != "" {
+		buttons = append(buttons, TelegramButton{Text: "Track aircraft", URL: aircraft.TrackerURL})
+	}
+	if aircraft.ImageURL != "" {
+		buttons = append(buttons, TelegramButton{Text: "Photos", URL: aircraft.ImageURL})
+	}
+	if len(buttons) > 0 {
+		message.ReplyMarkup = &TelegramReplyMarkup{InlineKeyboard: [][]TelegramButton{buttons}}
+	}
+
+	return message, nil
+}
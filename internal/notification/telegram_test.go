@@ -0,0 +1,127 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"jetspotter/internal/configuration"
+	"jetspotter/internal/jetspotter"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTelegramSendsPhotosToEveryChat(t *testing.T) {
+	type request struct {
+		path    string
+		message TelegramMessage
+	}
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var message TelegramMessage
+		if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+			t.Errorf("failed to decode the message: %v", err)
+		}
+		requests = append(requests, request{path: r.URL.Path, message: message})
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok":true,"result":{}}`))
+	}))
+	defer server.Close()
+
+	config := configuration.Config{
+		TelegramBotToken: "123:secret",
+		TelegramChatID:   []string{"123456789", "-1001234567890:42"},
+		TelegramAPIURL:   server.URL,
+	}
+	useEndpoints(t, config)
+	sample := SampleAircraft()
+	sample[0].ImageThumbnailURL = "https://t.plnspttrs.net/05-5140.jpg"
+	sample[1].Place = "Between Hasselt & Genk"
+
+	notifiers := Notifiers(config)
+	var telegram []Notifier
+	for _, notifier := range notifiers {
+		if strings.HasPrefix(notifier.Name(), Telegram) {
+			telegram = append(telegram, notifier)
+		}
+	}
+	if len(telegram) != 2 || telegram[1].Name() != "Telegram 2" {
+		t.Fatalf("expected a notifier for each chat, got %d", len(telegram))
+	}
+	for _, notifier := range telegram {
+		if err := notifier.Send(context.Background(), sample); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(requests) != 4 {
+		t.Fatalf("expected a message for every aircraft in every chat, got %d", len(requests))
+	}
+	photo, text, topic := requests[0], requests[1], requests[2]
+	if photo.path != "/bot123:secret/sendPhoto" || photo.message.Photo != sample[0].ImageThumbnailURL || photo.message.Caption == "" {
+		t.Errorf("expected the aircraft with a thumbnail to be sent as a photo, got %s %+v", photo.path, photo.message)
+	}
+	if text.path != "/bot123:secret/sendMessage" || text.message.Photo != "" || !strings.Contains(text.message.Text, "Between Hasselt &amp; Genk") {
+		t.Errorf("expected the aircraft without a thumbnail to be sent as escaped text, got %s %+v", text.path, text.message)
+	}
+	if !strings.Contains(photo.message.Caption, `<b>Callsign:</b> <a href="https://globe.airplanes.live/?icao=ae07e0">RCH456</a>`) {
+		t.Errorf("expected an HTML caption, got %q", photo.message.Caption)
+	}
+	if photo.message.ChatID != "123456789" || photo.message.MessageThreadID != 0 || topic.message.ChatID != "-1001234567890" || topic.message.MessageThreadID != 42 {
+		t.Errorf("unexpected chats %+v and %+v", photo.message, topic.message)
+	}
+
+	buttons := text.message.ReplyMarkup.InlineKeyboard[0]
+	if len(buttons) != 2 || buttons[0].URL != sample[1].TrackerURL || buttons[1].URL != sample[1].ImageURL {
+		t.Errorf("expected buttons for the tracker and the photos, got %+v", buttons)
+	}
+}
+
+func TestTelegramLongCaptionsAreSentAsText(t *testing.T) {
+	ac := jetspotter.Aircraft{Callsign: "BAF01", ImageThumbnailURL: "https://t.plnspttrs.net/ct-01.jpg", Airspaces: []string{strings.Repeat("A", maxTelegramCaption)}}
+	message, err := buildTelegramMessage(ac, "123456789", 0, configuration.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message.Photo != "" || message.Text == "" || message.ReplyMarkup != nil {
+		t.Errorf("expected a text message without buttons, got %+v", message)
+	}
+}
+
+func TestTelegramPhotosThatAreRejectedAreSentAsText(t *testing.T) {
+	var methods []string
+	var text TelegramMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.URL.Path)
+		if strings.HasSuffix(r.URL.Path, "/sendPhoto") {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"ok":false,"description":"Bad Request: wrong file identifier/HTTP URL specified"}`))
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&text); err != nil {
+			t.Errorf("failed to decode the message: %v", err)
+		}
+		_, _ = w.Write([]byte(`{"ok":true,"result":{}}`))
+	}))
+	defer server.Close()
+
+	config := configuration.Config{TelegramBotToken: "123:secret", TelegramChatID: []string{"123456789"}, TelegramAPIURL: server.URL}
+	useEndpoints(t, config)
+	sample := SampleAircraft()[:1]
+	sample[0].ImageThumbnailURL = "https://t.plnspttrs.net/unreachable.jpg"
+	for _, notifier := range Notifiers(config) {
+		if notifier.Name() != Telegram {
+			continue
+		}
+		if err := notifier.Send(context.Background(), sample); err != nil {
+			t.Fatalf("expected the message to be sent without the photo, got %v", err)
+		}
+	}
+
+	if strings.Join(methods, ",") != "/bot123:secret/sendPhoto,/bot123:secret/sendMessage" {
+		t.Fatalf("expected the photo to be followed by a text message, got %v", methods)
+	}
+	if text.Photo != "" || !strings.Contains(text.Text, "RCH456") || text.ReplyMarkup == nil {
+		t.Errorf("expected the same text and buttons without the photo, got %+v", text)
+	}
+}
@@ -0,0 +1,299 @@
+package notification
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html"
+	"jetspotter/internal/configuration"
+	"jetspotter/internal/country"
+	"jetspotter/internal/jetspotter"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// Every channel has a template file with these templates, the ones that a file doesn't define are taken from the built-in default.
+// A file without any definitions is used as the body template.
+const (
+	// headerTemplate is executed once for all aircraft, for channels that send them in one message
+	headerTemplate = "header"
+	// titleTemplate is executed for every aircraft
+	titleTemplate = "title"
+	// bodyTemplate is executed for every aircraft. Discord and Slack turn every "Name: value" line into a field,
+	// Slack starts a new section after an empty line.
+	bodyTemplate = "body"
+)
+
+//go:embed templates/*.tmpl
+var defaultTemplates embed.FS
+
+// templates are the parsed templates by kind of notifier, the built-in defaults until SetupTemplates is called
+var (
+	templatesMu sync.Mutex
+	templates   = make(map[string]*template.Template)
+)
+
+// markups are the ways in which links are written by kind of notifier, kinds that aren't listed get plain text
+var markups = map[string]string{
+	Discord:  Markdown,
+	Slack:    Slack,
+	Gotify:   Markdown,
+	Ntfy:     Markdown,
+	Telegram: HTML,
+}
+
+// aircraftData is what the title and body templates are executed with, the fields of an aircraft and the configuration
+type aircraftData struct {
+	jetspotter.Aircraft
+	Config configuration.Config
+}
+
+// batchData is what the header template is executed with
+type batchData struct {
+	Aircraft []aircraftData
+	Config   configuration.Config
+}
+
+// SetupTemplates parses the template files of the template directory, on top of the built-in defaults.
+// The file of a kind of notifier is named after it in lower case, for example discord.tmpl.
+func SetupTemplates(config configuration.Config) error {
+	templatesMu.Lock()
+	defer templatesMu.Unlock()
+
+	for _, r := range registry {
+		t, err := parseTemplates(r.kind, config.NotificationTemplateDirectory)
+		if err != nil {
+			return err
+		}
+		templates[r.kind] = t
+	}
+	return nil
+}
+
+// parseTemplates parses the default templates of a kind and the ones in its file in the directory, if it exists
+func parseTemplates(kind, directory string) (*template.Template, error) {
+	name := strings.ToLower(kind) + ".tmpl"
+	t := template.New(bodyTemplate).Funcs(templateFuncs(markups[kind]))
+
+	defaults, err := defaultTemplates.ReadFile("templates/" + name)
+	if err != nil {
+		return nil, fmt.Errorf("no default template for %s: %w", kind, err)
+	}
+	if _, err := t.Parse(string(defaults)); err != nil {
+		return nil, fmt.Errorf("failed to parse default %s template: %w", kind, err)
+	}
+
+	if directory == "" {
+		return t, nil
+	}
+	custom, err := os.ReadFile(filepath.Join(directory, name))
+	if os.IsNotExist(err) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Definitions that are empty don't replace the defaults, so a file with only definitions keeps the default body
+	if _, err := t.Parse(string(custom)); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	return t, nil
+}
+
+// templatesOf returns the templates of a kind, parsing the defaults the first time if SetupTemplates wasn't called
+func templatesOf(kind string) (*template.Template, error) {
+	templatesMu.Lock()
+	defer templatesMu.Unlock()
+
+	if t, ok := templates[kind]; ok {
+		return t, nil
+	}
+	t, err := parseTemplates(kind, "")
+	if err != nil {
+		return nil, err
+	}
+	templates[kind] = t
+	return t, nil
+}
+
+func render(kind, name string, data any) (string, error) {
+	t, err := templatesOf(kind)
+	if err != nil {
+		return "", err
+	}
+	var buffer bytes.Buffer
+	if err := t.ExecuteTemplate(&buffer, name, data); err != nil {
+		return "", fmt.Errorf("failed to render %s %s template: %w", kind, name, err)
+	}
+	return buffer.String(), nil
+}
+
+// renderHeader renders the header of a message about the aircraft
+func renderHeader(kind string, aircraft []jetspotter.Aircraft, config configuration.Config) (string, error) {
+	data := batchData{Config: config}
+	for _, ac := range aircraft {
+		data.Aircraft = append(data.Aircraft, aircraftData{Aircraft: ac, Config: config})
+	}
+	header, err := render(kind, headerTemplate, data)
+	return strings.TrimSpace(header), err
+}
+
+// renderTitle renders the title of the notification about an aircraft
+func renderTitle(kind string, ac jetspotter.Aircraft, config configuration.Config) (string, error) {
+	title, err := render(kind, titleTemplate, aircraftData{Aircraft: ac, Config: config})
+	return strings.TrimSpace(title), err
+}
+
+// renderBody renders the body of the notification about an aircraft
+func renderBody(kind string, ac jetspotter.Aircraft, config configuration.Config) (string, error) {
+	return render(kind, bodyTemplate, aircraftData{Aircraft: ac, Config: config})
+}
+
+// field is a "Name: value" line of a rendered body, lines without a name only have a value
+type field struct {
+	Name  string
+	Value string
+}
+
+// parseFields splits a rendered body into groups of fields, which are separated by empty lines
+func parseFields(body string) [][]field {
+	var groups [][]field
+	var group []field
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			if len(group) > 0 {
+				groups = append(groups, group)
+				group = nil
+			}
+			continue
+		}
+
+		name, value, found := strings.Cut(line, ": ")
+		if !found {
+			name, value = "", line
+		}
+		group = append(group, field{Name: strings.TrimSpace(name), Value: strings.TrimSpace(value)})
+	}
+	if len(group) > 0 {
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// templateFuncs are the functions that templates can use, links are written in the markup of the channel
+func templateFuncs(markup string) template.FuncMap {
+	return template.FuncMap{
+		// Formatted values of an aircraft, as they are shown by the default templates
+		"callsign":      func(a aircraftData) string { return printCallsign(a.Aircraft) },
+		"speed":         func(a aircraftData) string { return printSpeed(a.Aircraft) },
+		"altitude":      func(a aircraftData) string { return printAltitude(a.Aircraft) },
+		"distance":      func(a aircraftData) string { return printDistance(a.Aircraft) },
+		"place":         func(a aircraftData) string { return printPlace(a.Aircraft) },
+		"airspaces":     func(a aircraftData) string { return printAirspaces(a.Aircraft) },
+		"telemetry":     func(a aircraftData) string { return printTelemetry(a.Aircraft) },
+		"cloudCoverage": func(a aircraftData) string { return printCloudCoverage(a.Aircraft) },
+		"origin":        func(a aircraftData) string { return printOriginName(a.Aircraft) },
+		"destination":   func(a aircraftData) string { return printDestinationName(a.Aircraft) },
+		"airline":       func(a aircraftData) string { return printAirlineName(a.Aircraft) },
+		// Positions from multilateration are less accurate, the default templates show their source
+		"mlat": func(a aircraftData) bool { return a.Source == jetspotter.SourceMLAT },
+
+		// Units
+		"meters":        func(feet float64) int { return jetspotter.ConvertFeetToMeters(feet) },
+		"kmh":           func(knots int) int { return jetspotter.ConvertKnotsToKilometersPerHour(knots) },
+		"miles":         func(kilometers int) float64 { return math.Round(float64(kilometers)/1.609344*10) / 10 },
+		"nauticalMiles": func(kilometers int) float64 { return math.Round(float64(kilometers)/1.852*10) / 10 },
+		"round":         func(value float64, decimals int) float64 { return roundTo(value, decimals) },
+
+		// Bearings
+		"degrees": func(bearing float64) string { return fmt.Sprintf("%.0f°", bearing) },
+		"compass": compassPoint,
+
+		// Flags and links
+		"flag":  country.Flag,
+		"link":  func(text, url string) string { return formatLink(text, url, markup) },
+		"yesno": yesNo,
+
+		// Helpers
+		"value":   value,
+		"default": func(fallback, v any) any { return defaultValue(fallback, v) },
+		"upper":   strings.ToUpper,
+		"lower":   strings.ToLower,
+		"join":    strings.Join,
+		"json":    toJSON,
+	}
+}
+
+// formatLink writes a link in the markup of a channel, text without a URL is written as is
+func formatLink(text, url, markup string) string {
+	if url == "" {
+		return text
+	}
+	switch markup {
+	case Markdown:
+		return fmt.Sprintf("[%s](%s)", text, url)
+	case Slack:
+		return fmt.Sprintf("<%s|%s>", url, text)
+	case HTML:
+		return fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(url), html.EscapeString(text))
+	default:
+		return text
+	}
+}
+
+var compassPoints = []string{"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE", "S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW"}
+
+// compassPoint returns the point of the 16-point compass rose of a bearing, for example NE for 45 degrees
+func compassPoint(bearing float64) string {
+	index := int(math.Round(math.Mod(math.Mod(bearing, 360)+360, 360)/22.5)) % len(compassPoints)
+	return compassPoints[index]
+}
+
+func roundTo(value float64, decimals int) float64 {
+	factor := math.Pow(10, float64(decimals))
+	return math.Round(value*factor) / factor
+}
+
+// toJSON writes a value as JSON, for example a quoted and escaped string
+func toJSON(v any) (string, error) {
+	data, err := json.Marshal(v)
+	return string(data), err
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "Yes"
+	}
+	return "No"
+}
+
+// value dereferences optional fields, such as the telemetry, nil pointers become nil
+func value(v any) any {
+	r := reflect.ValueOf(v)
+	for r.Kind() == reflect.Pointer {
+		if r.IsNil() {
+			return nil
+		}
+		r = r.Elem()
+	}
+	if !r.IsValid() {
+		return nil
+	}
+	return r.Interface()
+}
+
+// defaultValue returns the fallback if the value is empty, for example an empty string or a nil pointer
+func defaultValue(fallback, v any) any {
+	v = value(v)
+	if v == nil || reflect.ValueOf(v).IsZero() {
+		return fallback
+	}
+	return v
+}
@@ -0,0 +1,117 @@
+package notification
+
+import (
+	"bytes"
+	"jetspotter/internal/configuration"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestDefaultTemplatesKeepTheLayouts(t *testing.T) {
+	config := configuration.Config{NotifyTelemetry: true}
+	aircraft := SampleAircraft()
+
+	slack, err := buildSlackMessage(aircraft[:1], config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Header, the two sections of fields and a divider
+	if len(slack.Blocks) != 4 || len(slack.Blocks[1].Fields) != 9 || len(slack.Blocks[2].Fields) != 9 {
+		t.Fatalf("unexpected Slack sections %+v", slack.Blocks)
+	}
+	if slack.Blocks[1].Fields[0].Text != "*Callsign:* <https://globe.airplanes.live/?icao=ae07e0|RCH456> (REACH 456)" {
+		t.Errorf("unexpected callsign field %q", slack.Blocks[1].Fields[0].Text)
+	}
+
+	discord, err := buildDiscordMessage(aircraft[:1], config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := discord.Embeds[0].Fields
+	last := fields[len(fields)-1]
+	if discord.Content != ":airplane: A jet has been spotted! :airplane:" || last.Name != "Telemetry" || last.Inline || !fields[0].Inline {
+		t.Errorf("expected only the telemetry not to be inline, got %+v", last)
+	}
+}
+
+func TestCustomTemplatesOverrideTheDefaults(t *testing.T) {
+	directory := t.TempDir()
+	files := map[string]string{
+		// Only the title is replaced, the default body is kept
+		"discord.tmpl": `{{define "title"}}{{flag .CountryISO}} {{upper .Callsign}} heading {{compass .Heading}}{{end}}`,
+		// Without definitions the whole file is the body
+		"terminal.tmpl": `{{.Callsign}} at {{meters .Altitude}} m, {{round (nauticalMiles .Distance) 0}} NM away`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(directory, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	discord, err := parseTemplates(Discord, directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var title, body bytes.Buffer
+	ac := aircraftData{Aircraft: SampleAircraft()[0]}
+	if err := discord.ExecuteTemplate(&title, titleTemplate, ac); err != nil {
+		t.Fatal(err)
+	}
+	if err := discord.ExecuteTemplate(&body, bodyTemplate, ac); err != nil {
+		t.Fatal(err)
+	}
+	if title.String() != "🇺🇸 RCH456 heading SW" || !strings.HasPrefix(body.String(), "Callsign: [RCH456]") {
+		t.Errorf("unexpected title %q or body %q", title.String(), body.String())
+	}
+
+	terminal, err := parseTemplates(Terminal, directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body.Reset()
+	if err := terminal.ExecuteTemplate(&body, bodyTemplate, ac); err != nil {
+		t.Fatal(err)
+	}
+	if body.String() != "RCH456 at 1066 m, 4 NM away" {
+		t.Errorf("unexpected terminal body %q", body.String())
+	}
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	points := map[float64]string{0: "N", 11: "N", 12: "NNE", 45: "NE", 190: "S", 350: "N", -90: "W", 720: "N"}
+	for bearing, expected := range points {
+		if actual := compassPoint(bearing); actual != expected {
+			t.Errorf("expected %s for a bearing of %v, got %s", expected, bearing, actual)
+		}
+	}
+
+	if link := formatLink("RCH456", "https://example.com", Markdown); link != "[RCH456](https://example.com)" {
+		t.Errorf("unexpected markdown link %q", link)
+	}
+	if link := formatLink("RCH456", "", Slack); link != "RCH456" {
+		t.Errorf("expected text without a URL to stay as is, got %q", link)
+	}
+
+	var mach *float64
+	if defaultValue("unknown", mach) != "unknown" || defaultValue("unknown", "M0.78") != "M0.78" {
+		t.Errorf("expected the default of empty values only")
+	}
+}
+
+func TestPreview(t *testing.T) {
+	preview, err := Preview("discord", nil, configuration.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if messages, ok := preview.([]discordgo.Message); !ok || len(messages[0].Embeds) != len(SampleAircraft()) {
+		t.Errorf("expected the sample aircraft to be rendered, got %+v", preview)
+	}
+
+	if _, err := Preview("carrier pigeon", nil, configuration.Config{}); err == nil {
+		t.Errorf("expected an unknown channel to fail")
+	}
+}
@@ -1,6 +1,7 @@
 package notification
 
 import (
+	"context"
 	"fmt"
 	"jetspotter/internal/configuration"
 	"jetspotter/internal/jetspotter"
@@ -8,38 +9,52 @@ import (
 )
 
 // FormatAircraft prints an Aircraft in a readable manner.
-func FormatAircraft(aircraft jetspotter.Aircraft, config configuration.Config) string {
-	return fmt.Sprintf("Callsign: %s\n"+
-		"Description: %s\n"+
-		"Type: %s\n"+
-		"Tail number: %s\n"+
-		"Country: %s\n"+
-		"Altitude: %s\n"+
-		"Speed: %s\n"+
-		"Distance: %s\n"+
-		"Cloud coverage: %s\n"+
-		"Bearing from location: %s\n"+
-		"Bearing from aircraft: %s\n"+
-		"Heading: %s\n"+
-		"Inbound: %s\n"+
-		"Origin: %s\n"+
-		"Destination: %s\n"+
-		"Airline: %s\n"+
-		"TrackerURL: %s\n"+
-		"ImageURL: %s\n",
+func FormatAircraft(aircraft jetspotter.Aircraft, config configuration.Config) (string, error) {
+	return renderBody(Terminal, aircraft, config)
+}
+
+func init() {
+	Register(Terminal, func(config configuration.Config) []Notifier {
+		return []Notifier{terminalNotifier{config: config}}
+	}, func(aircraft []jetspotter.Aircraft, config configuration.Config) (any, error) {
+		header, err := renderHeader(Terminal, aircraft, config)
+		if err != nil {
+			return nil, err
+		}
+		output := header + "\n"
+		for _, ac := range aircraft {
+			formatted, err := FormatAircraft(ac, config)
+			if err != nil {
+				return nil, err
+			}
+			output += formatted + "\n"
+		}
+		return output, nil
+	})
+}
 
-		aircraft.Callsign, aircraft.Description, aircraft.Type,
-		aircraft.Registration, aircraft.Country, printAltitude(aircraft),
-		printSpeed(aircraft), printDistance(aircraft), printCloudCoverage(aircraft),
-		printBearingFromLocation(aircraft), printBearingFromAircraft(aircraft),
-		printHeading(aircraft), getInboundStatus(aircraft), printOriginName(aircraft),
-		printDestinationName(aircraft), printAirlineName(aircraft), aircraft.TrackerURL, aircraft.ImageURL)
+// terminalNotifier prints the aircraft to the standard output, it is always enabled
+type terminalNotifier struct {
+	config configuration.Config
 }
 
-// SendTerminalMessage prints a list of Aircraft in a readable manner.
-func SendTerminalMessage(aircraft []jetspotter.Aircraft, config configuration.Config) {
-	log.Println("🛫 A jet has been spotted! 🛫")
+func (n terminalNotifier) Name() string {
+	return Terminal
+}
+
+// Send prints a list of Aircraft in a readable manner.
+func (n terminalNotifier) Send(ctx context.Context, aircraft []jetspotter.Aircraft) error {
+	header, err := renderHeader(Terminal, aircraft, n.config)
+	if err != nil {
+		return err
+	}
+	log.Println(header)
 	for _, ac := range aircraft {
-		fmt.Println(FormatAircraft(ac, config))
+		formatted, err := FormatAircraft(ac, n.config)
+		if err != nil {
+			return err
+		}
+		fmt.Println(formatted)
 	}
+	return nil
 }
@@ -0,0 +1,90 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"jetspotter/internal/configuration"
+	"jetspotter/internal/jetspotter"
+	"jetspotter/internal/outbox"
+)
+
+func init() {
+	Register(Webhook, func(config configuration.Config) []Notifier {
+		var notifiers []Notifier
+		for i, url := range config.WebhookURL {
+			notifiers = append(notifiers, webhookNotifier{name: instanceName(Webhook, i, len(config.WebhookURL)), url: url, config: config})
+		}
+		return notifiers
+	}, func(aircraft []jetspotter.Aircraft, config configuration.Config) (any, error) {
+		var bodies []json.RawMessage
+		for _, ac := range aircraft {
+			body, err := buildWebhookBody(ac, config)
+			if err != nil {
+				return nil, err
+			}
+			bodies = append(bodies, body)
+		}
+		return bodies, nil
+	})
+}
+
+// webhookNotifier sends a request with a JSON body to its endpoint for every aircraft
+type webhookNotifier struct {
+	name   string
+	url    string
+	config configuration.Config
+}
+
+func (n webhookNotifier) Name() string {
+	return n.name
+}
+
+// Send sends a webhook request for each aircraft. The outbox adds the headers and the signature of the endpoint.
+func (n webhookNotifier) Send(ctx context.Context, aircraft []jetspotter.Aircraft) error {
+	return sendEach(ctx, aircraft, func(ctx context.Context, ac jetspotter.Aircraft) error {
+		body, err := buildWebhookBody(ac, n.config)
+		if err != nil {
+			return err
+		}
+
+		return SendMessage(ctx, Notification{
+			Message: json.RawMessage(body),
+			Type:    Webhook,
+			Channel: n.name,
+		})
+	})
+}
+
+// endpoint is the URL of the webhook with its method, headers and the signature of the requests
+func (n webhookNotifier) endpoint() outbox.Endpoint {
+	endpoint := outbox.Endpoint{
+		Method: n.config.WebhookMethod,
+		URL:    n.url,
+		Header: map[string]string{"Content-Type": "application/json"},
+		Signature: outbox.Signature{
+			Secret:          n.config.WebhookSecret,
+			Header:          n.config.WebhookSignatureHeader,
+			TimestampHeader: n.config.WebhookTimestampHeader,
+		},
+	}
+	for name, value := range n.config.WebhookHeaders {
+		endpoint.Header[name] = value
+	}
+	return endpoint
+}
+
+// buildWebhookBody renders the body of the request about an aircraft, which has to be valid JSON
+func buildWebhookBody(aircraft jetspotter.Aircraft, config configuration.Config) ([]byte, error) {
+	body, err := renderBody(Webhook, aircraft, config)
+	if err != nil {
+		return nil, err
+	}
+
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, []byte(body)); err != nil {
+		return nil, fmt.Errorf("webhook template doesn't render valid JSON: %w", err)
+	}
+	return compact.Bytes(), nil
+}
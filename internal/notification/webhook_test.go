@@ -0,0 +1,124 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"jetspotter/internal/configuration"
+	"jetspotter/internal/jetspotter"
+	"jetspotter/internal/outbox"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebhookRequestsAreSigned(t *testing.T) {
+	signature := outbox.Signature{Secret: "s3cret", Header: "X-Jetspotter-Signature", TimestampHeader: "X-Jetspotter-Timestamp"}
+	var body struct {
+		Event    string
+		Aircraft jetspotter.Aircraft
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		timestamp := r.Header.Get(signature.TimestampHeader)
+		if sent, err := strconv.ParseInt(timestamp, 10, 64); err != nil || time.Since(time.Unix(sent, 0)) > time.Minute {
+			t.Errorf("expected a recent timestamp, got %q", timestamp)
+		}
+		// The receiver verifies the signature with the shared secret
+		if r.Header.Get(signature.Header) != signature.Sign(timestamp, data) {
+			t.Errorf("invalid signature %q", r.Header.Get(signature.Header))
+		}
+		if r.Method != http.MethodPut || r.Header.Get("Authorization") != "Bearer token" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected request %s %v", r.Method, r.Header)
+		}
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Errorf("expected a JSON body, got %s", data)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	config := configuration.Config{
+		WebhookURL:             []string{server.URL},
+		WebhookMethod:          http.MethodPut,
+		WebhookHeaders:         map[string]string{"Authorization": "Bearer token"},
+		WebhookSecret:          signature.Secret,
+		WebhookSignatureHeader: signature.Header,
+		WebhookTimestampHeader: signature.TimestampHeader,
+	}
+	var webhook Notifier
+	for _, notifier := range Notifiers(config) {
+		if notifier.Name() == Webhook {
+			webhook = notifier
+		}
+	}
+
+	// The endpoint is configured when the outbox is set up, the messages only refer to it by name
+	var payloads []json.RawMessage
+	deliver := outbox.HTTPDeliverer(Endpoints(config))
+	jetspotter.Outbox().Handle(outbox.HTTP, func(ctx context.Context, payload json.RawMessage) error {
+		payloads = append(payloads, payload)
+		return deliver(ctx, payload)
+	})
+	t.Cleanup(func() {
+		jetspotter.Outbox().Handle(outbox.HTTP, nil)
+	})
+
+	if err := webhook.Send(context.Background(), SampleAircraft()[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if body.Event != "spotted" || body.Aircraft.Callsign != "RCH456" || !body.Aircraft.Military {
+		t.Errorf("unexpected body %+v", body)
+	}
+	if len(payloads) != 1 || strings.Contains(string(payloads[0]), "s3cret") || strings.Contains(string(payloads[0]), "Bearer") {
+		t.Errorf("expected the secret and the headers to be kept out of the outbox, got %s", payloads)
+	}
+}
+
+func TestWebhookBodyTemplate(t *testing.T) {
+	directory := t.TempDir()
+	custom := `{"text": {{json (printf "%s spotted at %s" .Callsign (distance .))}}, "military": {{.Military}}}`
+	if err := os.WriteFile(filepath.Join(directory, "webhook.tmpl"), []byte(custom), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := parseTemplates(Webhook, directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	templatesMu.Lock()
+	defaults := templates[Webhook]
+	templates[Webhook] = parsed
+	templatesMu.Unlock()
+	t.Cleanup(func() {
+		templatesMu.Lock()
+		templates[Webhook] = defaults
+		templatesMu.Unlock()
+	})
+
+	body, err := buildWebhookBody(jetspotter.Aircraft{Callsign: `"BAF01"`, Distance: 12, Military: true}, configuration.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != `{"text":"\"BAF01\" spotted at 12km","military":true}` {
+		t.Errorf("unexpected body %s", body)
+	}
+
+	parsed, err = parseTemplates(Webhook, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parsed.New(bodyTemplate).Parse(`{"callsign": {{.Callsign}}}`); err != nil {
+		t.Fatal(err)
+	}
+	templatesMu.Lock()
+	templates[Webhook] = parsed
+	templatesMu.Unlock()
+	if _, err := buildWebhookBody(jetspotter.Aircraft{Callsign: "BAF01"}, configuration.Config{}); err == nil {
+		t.Errorf("expected an unquoted string to be rejected as invalid JSON")
+	}
+}
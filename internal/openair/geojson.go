@@ -0,0 +1,62 @@
+package openair
+
+// FeatureCollection is a GeoJSON collection of airspaces, see RFC 7946
+type FeatureCollection struct {
+	Type     string    `json:"type"`
+	Features []Feature `json:"features"`
+}
+
+// Feature is a GeoJSON feature of a single airspace
+type Feature struct {
+	Type       string     `json:"type"`
+	Properties Properties `json:"properties"`
+	Geometry   Geometry   `json:"geometry"`
+}
+
+// Properties of an airspace in GeoJSON
+type Properties struct {
+	Name  string `json:"name"`
+	Class string `json:"class"`
+	Type  string `json:"type,omitempty"`
+	Lower string `json:"lower"`
+	Upper string `json:"upper"`
+	// Limits in feet, so that clients can filter on altitude without parsing the limits
+	LowerFeet int `json:"lowerFeet"`
+	UpperFeet int `json:"upperFeet"`
+}
+
+// Geometry is a GeoJSON polygon, positions are longitude and latitude pairs
+type Geometry struct {
+	Type        string         `json:"type"`
+	Coordinates [][][2]float64 `json:"coordinates"`
+}
+
+// GeoJSON converts the airspaces to a GeoJSON feature collection.
+func GeoJSON(airspaces []Airspace) FeatureCollection {
+	collection := FeatureCollection{Type: "FeatureCollection", Features: []Feature{}}
+	for _, airspace := range airspaces {
+		ring := make([][2]float64, 0, len(airspace.Polygon)+1)
+		for _, point := range airspace.Polygon {
+			ring = append(ring, [2]float64{point.Longitude, point.Latitude})
+		}
+		// Rings of GeoJSON polygons are closed
+		if first := ring[0]; first != ring[len(ring)-1] {
+			ring = append(ring, first)
+		}
+
+		collection.Features = append(collection.Features, Feature{
+			Type: "Feature",
+			Properties: Properties{
+				Name:      airspace.Name,
+				Class:     airspace.Class,
+				Type:      airspace.Type,
+				Lower:     airspace.Lower.Text,
+				Upper:     airspace.Upper.Text,
+				LowerFeet: airspace.Lower.Feet,
+				UpperFeet: airspace.Upper.Feet,
+			},
+			Geometry: Geometry{Type: "Polygon", Coordinates: [][][2]float64{ring}},
+		})
+	}
+	return collection
+}
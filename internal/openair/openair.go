@@ -0,0 +1,399 @@
+package openair
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Point is a position in decimal degrees
+type Point struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// Limit is the lower or upper limit of an airspace
+type Limit struct {
+	// Altitude in feet, flight levels are converted to feet as well
+	Feet int
+	// Reference of the altitude, "MSL", "AGL", "FL" or "UNL" for unlimited
+	Reference string
+	// Limit as it is written in the file, for example "FL95" or "2500ft MSL"
+	Text string
+}
+
+// Airspace is a controlled, restricted or otherwise special area in the sky
+type Airspace struct {
+	Name string
+	// Class of the airspace, for example "CTR", "R" for restricted, "Q" for danger, "P" for prohibited or "A" to "G"
+	Class string
+	// Type of the airspace in the extended OpenAir format, for example "TRA" or "TSA"
+	Type    string
+	Lower   Limit
+	Upper   Limit
+	Polygon []Point
+
+	minLat, maxLat, minLon, maxLon float64
+}
+
+// Altitude of unlimited upper limits
+const unlimitedFeet = 999999
+
+// Arcs and circles are approximated by a point for every few degrees
+const arcStepDegrees = 5
+
+const earthRadiusNauticalMiles = 3440.065
+
+// Load reads the airspaces of every OpenAir file.
+func Load(paths ...string) ([]Airspace, error) {
+	var airspaces []Airspace
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+
+		parsed, err := Parse(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		airspaces = append(airspaces, parsed...)
+	}
+	return airspaces, nil
+}
+
+// parser keeps the state of the airspace that is being parsed
+type parser struct {
+	airspaces []Airspace
+	current   *Airspace
+	center    Point
+	clockwise bool
+}
+
+// Parse reads airspaces in the OpenAir format, see http://www.winpilot.com/usersguide/userairspace.asp.
+// The AY and AI records of the extended format are supported as well.
+func Parse(reader io.Reader) ([]Airspace, error) {
+	p := &parser{clockwise: true}
+
+	scanner := bufio.NewScanner(reader)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if text == "" || strings.HasPrefix(text, "*") {
+			continue
+		}
+
+		command, argument, _ := strings.Cut(text, " ")
+		if err := p.parseRecord(strings.ToUpper(command), strings.TrimSpace(argument)); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	p.finish()
+	return p.airspaces, nil
+}
+
+func (p *parser) parseRecord(command, argument string) error {
+	if command == "AC" {
+		p.finish()
+		p.current = &Airspace{Class: strings.ToUpper(argument)}
+		p.clockwise = true
+		return nil
+	}
+
+	// Records outside of an airspace, like the ones for the styling of the whole file, are ignored
+	if p.current == nil {
+		return nil
+	}
+
+	switch command {
+	case "AN":
+		p.current.Name = argument
+	case "AY":
+		p.current.Type = strings.ToUpper(argument)
+	case "AL":
+		p.current.Lower = ParseLimit(argument)
+	case "AH":
+		p.current.Upper = ParseLimit(argument)
+	case "V":
+		return p.parseVariable(argument)
+	case "DP":
+		point, err := ParsePoint(argument)
+		if err != nil {
+			return err
+		}
+		p.current.Polygon = append(p.current.Polygon, point)
+	case "DC":
+		radius, err := strconv.ParseFloat(argument, 64)
+		if err != nil {
+			return fmt.Errorf("invalid circle radius %q", argument)
+		}
+		p.addArc(radius, 0, 360, true)
+	case "DA":
+		values := strings.Split(argument, ",")
+		if len(values) != 3 {
+			return fmt.Errorf("invalid arc %q", argument)
+		}
+		var numbers [3]float64
+		for i, value := range values {
+			number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				return fmt.Errorf("invalid arc %q", argument)
+			}
+			numbers[i] = number
+		}
+		p.addArc(numbers[0], numbers[1], numbers[2], p.clockwise)
+	case "DB":
+		from, to, found := strings.Cut(argument, ",")
+		if !found {
+			return fmt.Errorf("invalid arc %q", argument)
+		}
+		start, err := ParsePoint(from)
+		if err != nil {
+			return err
+		}
+		end, err := ParsePoint(to)
+		if err != nil {
+			return err
+		}
+		radius := distance(p.center, start)
+		p.addArc(radius, bearing(p.center, start), bearing(p.center, end), p.clockwise)
+	}
+
+	return nil
+}
+
+// parseVariable handles the center (X) and direction (D) of arcs
+func (p *parser) parseVariable(argument string) error {
+	name, value, found := strings.Cut(argument, "=")
+	if !found {
+		return fmt.Errorf("invalid variable %q", argument)
+	}
+
+	switch strings.ToUpper(strings.TrimSpace(name)) {
+	case "X":
+		center, err := ParsePoint(value)
+		if err != nil {
+			return err
+		}
+		p.center = center
+	case "D":
+		p.clockwise = strings.TrimSpace(value) != "-"
+	}
+	return nil
+}
+
+// addArc adds the points of an arc around the center, the angles are bearings in degrees and the radius is in nautical miles
+func (p *parser) addArc(radius, start, end float64, clockwise bool) {
+	sweep := math.Mod(end-start+360, 360)
+	if !clockwise {
+		sweep = -math.Mod(start-end+360, 360)
+	}
+	if start == end || (start == 0 && end == 360) {
+		sweep = 360
+	}
+
+	steps := int(math.Ceil(math.Abs(sweep) / arcStepDegrees))
+	for i := 0; i <= steps; i++ {
+		angle := start + sweep*float64(i)/float64(steps)
+		p.current.Polygon = append(p.current.Polygon, destination(p.center, radius, angle))
+	}
+}
+
+// finish adds the current airspace to the list, airspaces without an area are skipped
+func (p *parser) finish() {
+	if p.current == nil {
+		return
+	}
+
+	if len(p.current.Polygon) >= 3 {
+		p.current.computeBounds()
+		p.airspaces = append(p.airspaces, *p.current)
+	}
+	p.current = nil
+}
+
+func (a *Airspace) computeBounds() {
+	a.minLat, a.maxLat = math.Inf(1), math.Inf(-1)
+	a.minLon, a.maxLon = math.Inf(1), math.Inf(-1)
+	for _, point := range a.Polygon {
+		a.minLat, a.maxLat = math.Min(a.minLat, point.Latitude), math.Max(a.maxLat, point.Latitude)
+		a.minLon, a.maxLon = math.Min(a.minLon, point.Longitude), math.Max(a.maxLon, point.Longitude)
+	}
+}
+
+// Contains checks whether the position is inside the airspace, between its lower and upper limit.
+// Limits above the ground are assumed to be above sea level.
+func (a Airspace) Contains(lat, lon, altitudeFeet float64) bool {
+	return a.ContainsAboveGround(lat, lon, altitudeFeet, 0)
+}
+
+// ContainsAboveGround checks whether the position is inside the airspace, with the limits above the ground raised by
+// the elevation of the ground in feet. A lower limit of the ground itself includes everything below it.
+func (a Airspace) ContainsAboveGround(lat, lon, altitudeFeet, groundFeet float64) bool {
+	lower, upper := float64(a.Lower.Feet), float64(a.Upper.Feet)
+	if a.Lower.Reference == "AGL" && a.Lower.Feet > 0 {
+		lower += groundFeet
+	}
+	if a.Upper.Reference == "AGL" {
+		upper += groundFeet
+	}
+
+	if (a.Lower.Feet > 0 && altitudeFeet < lower) || (a.Upper.Feet > 0 && altitudeFeet > upper) {
+		return false
+	}
+
+	return a.ContainsPoint(lat, lon)
+}
+
+// ContainsPoint checks whether the position is inside the area of the airspace, regardless of its altitude.
+func (a Airspace) ContainsPoint(lat, lon float64) bool {
+	// The bounds are only known for parsed airspaces
+	bounded := a.minLat != 0 || a.maxLat != 0
+	if bounded && (lat < a.minLat || lat > a.maxLat || lon < a.minLon || lon > a.maxLon) {
+		return false
+	}
+
+	// Count the edges that a ray towards the east crosses
+	inside := false
+	for i, j := 0, len(a.Polygon)-1; i < len(a.Polygon); j, i = i, i+1 {
+		pi, pj := a.Polygon[i], a.Polygon[j]
+		if (pi.Latitude > lat) != (pj.Latitude > lat) &&
+			lon < (pj.Longitude-pi.Longitude)*(lat-pi.Latitude)/(pj.Latitude-pi.Latitude)+pi.Longitude {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// ParseLimit parses the altitude of a limit, for example "GND", "FL95", "2500ft MSL", "1500 AGL", "600m" or "UNL".
+// Altitudes without a unit are in feet and altitudes without a reference are above sea level.
+func ParseLimit(text string) Limit {
+	limit := Limit{Text: text}
+	value := strings.ToUpper(strings.ReplaceAll(text, " ", ""))
+
+	switch {
+	case value == "" || value == "GND" || value == "SFC" || value == "AGL":
+		limit.Reference = "AGL"
+		return limit
+	case strings.HasPrefix(value, "UNL"):
+		limit.Feet, limit.Reference = unlimitedFeet, "UNL"
+		return limit
+	case strings.HasPrefix(value, "FL"):
+		level, _ := strconv.Atoi(strings.TrimPrefix(value, "FL"))
+		limit.Feet, limit.Reference = level*100, "FL"
+		return limit
+	}
+
+	// Split the number from its unit and reference
+	end := strings.IndexFunc(value, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if end < 0 {
+		end = len(value)
+	}
+	number, _ := strconv.ParseFloat(value[:end], 64)
+	suffix := value[end:]
+
+	if strings.HasPrefix(suffix, "M") && !strings.HasPrefix(suffix, "MSL") {
+		number *= 3.28084
+	}
+
+	limit.Feet = int(math.Round(number))
+	limit.Reference = "MSL"
+	if strings.Contains(suffix, "AGL") || strings.Contains(suffix, "GND") || strings.Contains(suffix, "SFC") {
+		limit.Reference = "AGL"
+	}
+	return limit
+}
+
+// ParsePoint parses a coordinate, for example "51:11:00 N 005:28:00 E" or "51:11.5N 5:28.25E".
+func ParsePoint(text string) (Point, error) {
+	value := strings.ToUpper(strings.ReplaceAll(text, " ", ""))
+
+	split := strings.IndexAny(value, "NS")
+	if split < 0 || !strings.ContainsAny(value[split+1:], "EW") {
+		return Point{}, fmt.Errorf("invalid coordinate %q", text)
+	}
+
+	lat, err := parseDegrees(value[:split])
+	if err != nil {
+		return Point{}, fmt.Errorf("invalid coordinate %q", text)
+	}
+	if value[split] == 'S' {
+		lat = -lat
+	}
+
+	rest := strings.TrimPrefix(value[split+1:], ",")
+	lon, err := parseDegrees(rest[:len(rest)-1])
+	if err != nil || !strings.HasSuffix(rest, "E") && !strings.HasSuffix(rest, "W") {
+		return Point{}, fmt.Errorf("invalid coordinate %q", text)
+	}
+	if strings.HasSuffix(rest, "W") {
+		lon = -lon
+	}
+
+	return Point{Latitude: lat, Longitude: lon}, nil
+}
+
+// parseDegrees parses degrees, minutes and seconds separated by colons
+func parseDegrees(value string) (float64, error) {
+	degrees := 0.0
+	for i, part := range strings.Split(value, ":") {
+		if i > 2 {
+			return 0, fmt.Errorf("invalid degrees %q", value)
+		}
+		number, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0, err
+		}
+		degrees += number / math.Pow(60, float64(i))
+	}
+	return degrees, nil
+}
+
+// destination returns the point at the distance in nautical miles and bearing in degrees from the start
+func destination(start Point, distance, bearing float64) Point {
+	angularDistance := distance / earthRadiusNauticalMiles
+	lat1, lon1 := toRadians(start.Latitude), toRadians(start.Longitude)
+	course := toRadians(bearing)
+
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(angularDistance) + math.Cos(lat1)*math.Sin(angularDistance)*math.Cos(course))
+	lon2 := lon1 + math.Atan2(math.Sin(course)*math.Sin(angularDistance)*math.Cos(lat1), math.Cos(angularDistance)-math.Sin(lat1)*math.Sin(lat2))
+	return Point{Latitude: toDegrees(lat2), Longitude: toDegrees(lon2)}
+}
+
+// distance returns the great-circle distance between two points in nautical miles
+func distance(from, to Point) float64 {
+	lat1, lat2 := toRadians(from.Latitude), toRadians(to.Latitude)
+	dLat := lat2 - lat1
+	dLon := toRadians(to.Longitude - from.Longitude)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusNauticalMiles * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// bearing returns the initial bearing in degrees from the first to the second point
+func bearing(from, to Point) float64 {
+	lat1, lat2 := toRadians(from.Latitude), toRadians(to.Latitude)
+	dLon := toRadians(to.Longitude - from.Longitude)
+
+	y := math.Sin(dLon) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dLon)
+	return math.Mod(toDegrees(math.Atan2(y, x))+360, 360)
+}
+
+func toRadians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+func toDegrees(radians float64) float64 {
+	return radians * 180 / math.Pi
+}
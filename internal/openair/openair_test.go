@@ -0,0 +1,138 @@
+package openair
+
+import "testing"
+
+func TestLoad(t *testing.T) {
+	airspaces, err := Load("testdata/belgium.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(airspaces) != 3 {
+		t.Fatalf("expected 3 airspaces, got %d", len(airspaces))
+	}
+
+	restricted, ctr, tsa := airspaces[0], airspaces[1], airspaces[2]
+	if restricted.Name != "EBR05 KLEINE-BROGEL" || restricted.Class != "R" || restricted.Upper.Feet != 9500 {
+		t.Errorf("unexpected restricted area %+v", restricted)
+	}
+	if ctr.Type != "CTR" || ctr.Upper.Feet != 2500 || ctr.Upper.Reference != "MSL" || len(ctr.Polygon) != 4 {
+		t.Errorf("unexpected control zone %+v", ctr)
+	}
+
+	tests := []struct {
+		airspace      Airspace
+		lat, lon, alt float64
+		expected      bool
+	}{
+		// Center of the circle, below and above its upper limit
+		{restricted, 51.168, 5.47, 5000, true},
+		{restricted, 51.168, 5.47, 12000, false},
+		// The radius of the circle is 5 NM, about 9 km
+		{restricted, 51.168, 5.62, 5000, false},
+		{ctr, 51.10, 5.40, 1500, true},
+		{ctr, 51.10, 5.40, 3000, false},
+		// The arc is drawn clockwise from north to south, so only the eastern half is part of the area
+		{tsa, 50.5, 5.15, 15000, true},
+		{tsa, 50.5, 4.85, 15000, false},
+		{tsa, 50.5, 5.15, 5000, false},
+	}
+
+	for _, test := range tests {
+		if inside := test.airspace.Contains(test.lat, test.lon, test.alt); inside != test.expected {
+			t.Errorf("expected %v for %s at %.2f, %.2f and %.0f ft", test.expected, test.airspace.Name, test.lat, test.lon, test.alt)
+		}
+	}
+}
+
+func TestContainsAboveGround(t *testing.T) {
+	lowFlying := Airspace{
+		Name:    "EBLFA 11",
+		Lower:   ParseLimit("GND"),
+		Upper:   ParseLimit("1500 AGL"),
+		Polygon: []Point{{50, 5}, {50, 6}, {51, 6}, {51, 5}},
+	}
+
+	tests := []struct {
+		alt, ground float64
+		expected    bool
+	}{
+		// Over 1000 ft high terrain, the upper limit is 2500 ft above sea level
+		{2000, 1000, true},
+		{3000, 1000, false},
+		// Barometric altitudes below the terrain are still within a limit of the ground
+		{900, 1000, true},
+		{2000, 0, false},
+	}
+
+	for _, test := range tests {
+		if inside := lowFlying.ContainsAboveGround(50.5, 5.5, test.alt, test.ground); inside != test.expected {
+			t.Errorf("expected %v at %.0f ft over %.0f ft high terrain", test.expected, test.alt, test.ground)
+		}
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	tests := map[string]Limit{
+		"GND":        {Feet: 0, Reference: "AGL"},
+		"FL 95":      {Feet: 9500, Reference: "FL"},
+		"2500ft MSL": {Feet: 2500, Reference: "MSL"},
+		"1500 AGL":   {Feet: 1500, Reference: "AGL"},
+		"600m":       {Feet: 1969, Reference: "MSL"},
+		"4500":       {Feet: 4500, Reference: "MSL"},
+		"UNLTD":      {Feet: unlimitedFeet, Reference: "UNL"},
+	}
+
+	for text, expected := range tests {
+		limit := ParseLimit(text)
+		if limit.Feet != expected.Feet || limit.Reference != expected.Reference || limit.Text != text {
+			t.Errorf("expected %+v for %q, got %+v", expected, text, limit)
+		}
+	}
+}
+
+func TestParsePoint(t *testing.T) {
+	tests := map[string]Point{
+		"51:11:00 N 005:28:00 E": {51.18333, 5.46667},
+		"51:11.5N 5:28.25E":      {51.19167, 5.47083},
+		"33:56:00 S 151:10:30 E": {-33.93333, 151.175},
+		"40:38:23N, 073:46:44W":  {40.63972, -73.77889},
+	}
+
+	for text, expected := range tests {
+		point, err := ParsePoint(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff(point.Latitude, expected.Latitude) > 1e-4 || diff(point.Longitude, expected.Longitude) > 1e-4 {
+			t.Errorf("expected %+v for %q, got %+v", expected, text, point)
+		}
+	}
+
+	if _, err := ParsePoint("51:11:00 005:28:00"); err == nil {
+		t.Error("expected an error for a coordinate without hemispheres")
+	}
+}
+
+func TestGeoJSON(t *testing.T) {
+	airspaces, err := Load("testdata/belgium.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	collection := GeoJSON(airspaces)
+	ring := collection.Features[1].Geometry.Coordinates[0]
+	if len(ring) != 5 || ring[0] != ring[4] || ring[0] != [2]float64{5 + 20.0/60, 51 + 14.0/60} {
+		t.Errorf("expected a closed ring of longitude and latitude pairs, got %v", ring)
+	}
+	if properties := collection.Features[1].Properties; properties.Name != "EBBL CTR" || properties.Upper != "2500ft MSL" {
+		t.Errorf("unexpected properties %+v", properties)
+	}
+}
+
+func diff(a, b float64) float64 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
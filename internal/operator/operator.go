@@ -0,0 +1,147 @@
+package operator
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Operator is an airline, air force or other organisation that operates aircraft under its own callsign
+type Operator struct {
+	// ICAO three letter designator, empty for operators that are only known by their callsign pattern
+	Designator string
+	Name       string
+	Country    string
+	// ISO 3166-1 alpha-2 code of the country
+	CountryISO string
+	// Radio telephony designator that is spoken instead of the designator, for example "SPEEDBIRD"
+	Telephony string
+	Military  bool
+}
+
+// pattern is a callsign that is transmitted in full instead of as an ICAO designator, followed by a number.
+// These are mostly military flights, for example "FORTE11" for a Global Hawk or "NATO01" for an AWACS.
+type pattern struct {
+	prefix   string
+	operator Operator
+}
+
+var patterns = []pattern{
+	{"NATO", Operator{Name: "NATO Airborne Early Warning and Control Force", Telephony: "NATO", Military: true}},
+	{"FORTE", Operator{Name: "United States Air Force", Country: "United States", CountryISO: "US", Telephony: "FORTE", Military: true}},
+	{"HOMER", Operator{Name: "United States Navy", Country: "United States", CountryISO: "US", Telephony: "HOMER", Military: true}},
+	{"JAKE", Operator{Name: "United States Air Force", Country: "United States", CountryISO: "US", Telephony: "JAKE", Military: true}},
+	{"QUID", Operator{Name: "United States Air Force", Country: "United States", CountryISO: "US", Telephony: "QUID", Military: true}},
+	{"SPAR", Operator{Name: "United States Air Force", Country: "United States", CountryISO: "US", Telephony: "SPAR", Military: true}},
+	{"SAM", Operator{Name: "United States Air Force", Country: "United States", CountryISO: "US", Telephony: "SAM", Military: true}},
+	{"DUKE", Operator{Name: "United States Army", Country: "United States", CountryISO: "US", Telephony: "DUKE", Military: true}},
+	{"TARTN", Operator{Name: "Royal Air Force", Country: "United Kingdom", CountryISO: "GB", Telephony: "TARTAN", Military: true}},
+}
+
+//go:embed operators.csv
+var operatorsCSV string
+
+var operators = mustParse(operatorsCSV)
+
+// Lookup returns the operator of a flight and the flight number that follows its designator or callsign pattern.
+// ICAO callsigns consist of the three letter designator of the operator followed by a number, for example BAF123.
+// Callsigns that are registrations, such as OOSNA, don't belong to an operator.
+func Lookup(callsign string) (Operator, string, bool) {
+	callsign = strings.ToUpper(strings.TrimSpace(callsign))
+
+	for _, p := range patterns {
+		number, found := strings.CutPrefix(callsign, p.prefix)
+		if found && isFlightNumber(number) {
+			return p.operator, number, true
+		}
+	}
+
+	if len(callsign) < 4 || !isFlightNumber(callsign[3:]) {
+		return Operator{}, "", false
+	}
+
+	operator, found := operators[callsign[:3]]
+	if !found {
+		return Operator{}, "", false
+	}
+
+	return operator, callsign[3:], true
+}
+
+// Spoken returns the callsign as it is pronounced on the radio, for example "REACH 456" for RCH456.
+// An empty string is returned if the operator of the callsign is unknown.
+func Spoken(callsign string) string {
+	operator, number, found := Lookup(callsign)
+	if !found || operator.Telephony == "" {
+		return ""
+	}
+	return operator.Telephony + " " + number
+}
+
+// Len returns the number of operators with an ICAO designator.
+func Len() int {
+	return len(operators)
+}
+
+// isFlightNumber checks whether the part of a callsign after the designator is a flight number.
+// Flight numbers start with a digit and may end with letters, for example 1AB.
+func isFlightNumber(s string) bool {
+	if s == "" || len(s) > 5 || s[0] < '0' || s[0] > '9' {
+		return false
+	}
+
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'A' || c > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+func mustParse(data string) map[string]Operator {
+	operators, err := parse(data)
+	if err != nil {
+		panic(err)
+	}
+	return operators
+}
+
+func parse(data string) (map[string]Operator, error) {
+	records, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	operators := make(map[string]Operator)
+	for i, record := range records {
+		// The first record is the header
+		if i == 0 {
+			continue
+		}
+
+		if len(record) != 6 || len(record[0]) != 3 {
+			return nil, fmt.Errorf("invalid operator on line %d: %v", i+1, record)
+		}
+
+		military := false
+		if record[5] != "" {
+			military, err = strconv.ParseBool(record[5])
+			if err != nil {
+				return nil, fmt.Errorf("invalid operator on line %d: %w", i+1, err)
+			}
+		}
+
+		operators[record[0]] = Operator{
+			Designator: record[0],
+			Name:       record[1],
+			Country:    record[2],
+			CountryISO: record[3],
+			Telephony:  record[4],
+			Military:   military,
+		}
+	}
+
+	return operators, nil
+}
@@ -0,0 +1,63 @@
+package operator
+
+import "testing"
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		callsign string
+		name     string
+		number   string
+		military bool
+	}{
+		{"BAF123", "Belgian Air Force", "123", true},
+		{"rch456 ", "United States Air Force Air Mobility Command", "456", true},
+		{"BAW15", "British Airways", "15", false},
+		{"EZY12AB", "easyJet", "12AB", false},
+		{"NATO01", "NATO Airborne Early Warning and Control Force", "01", true},
+		{"FORTE11", "United States Air Force", "11", true},
+	}
+
+	for _, test := range tests {
+		operator, number, found := Lookup(test.callsign)
+		if !found || operator.Name != test.name || number != test.number || operator.Military != test.military {
+			t.Errorf("expected %s to be flight %s of %s, got %s of %+v", test.callsign, test.number, test.name, number, operator)
+		}
+	}
+
+	for _, callsign := range []string{"", "OOSNA", "N12345", "BAWA", "XXX123", "BAF123456"} {
+		if operator, _, found := Lookup(callsign); found {
+			t.Errorf("expected no operator for %s, got %+v", callsign, operator)
+		}
+	}
+}
+
+func TestSpoken(t *testing.T) {
+	tests := map[string]string{
+		"BAF123":  "BELGIAN AIRFORCE 123",
+		"RCH456":  "REACH 456",
+		"BAW15":   "SPEEDBIRD 15",
+		"TARTN41": "TARTAN 41",
+		"OOSNA":   "",
+	}
+
+	for callsign, expected := range tests {
+		if spoken := Spoken(callsign); spoken != expected {
+			t.Errorf("expected %s to be spoken as %q, got %q", callsign, expected, spoken)
+		}
+	}
+}
+
+func TestParseRejectsInvalidOperators(t *testing.T) {
+	for _, data := range []string{
+		"designator,name,country,country_iso,telephony,military\nBA,British Airways,United Kingdom,GB,SPEEDBIRD,\n",
+		"designator,name,country,country_iso,telephony,military\nBAF,Belgian Air Force,Belgium,BE,BELGIAN AIRFORCE,maybe\n",
+	} {
+		if _, err := parse(data); err == nil {
+			t.Errorf("expected an error for %q", data)
+		}
+	}
+
+	if Len() < 100 {
+		t.Errorf("expected the embedded database to contain at least 100 operators, got %d", Len())
+	}
+}
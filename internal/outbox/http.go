@@ -0,0 +1,131 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"jetspotter/internal/upstream"
+)
+
+// HTTP is the kind of the messages that are delivered as HTTP requests, the payload is a Request
+const HTTP = "http"
+
+// defaultRetryAfter is the delay after a rate limit response without a Retry-After header
+const defaultRetryAfter = 30 * time.Second
+
+// Request is the payload of an HTTP message. It refers to the endpoint of the channel by name,
+// so that the URLs, tokens and secrets of the endpoints aren't stored with the messages.
+type Request struct {
+	Endpoint string `json:"endpoint"`
+	// Appended to the URL of the endpoint, for example the method of an API
+	Path string `json:"path,omitempty"`
+	Body []byte `json:"body,omitempty"`
+	// Sent instead if the request is rejected with a client error, for example a message without the photo
+	// that the service couldn't download
+	Fallback *Request `json:"fallback,omitempty"`
+}
+
+// Endpoint is where the requests of a channel are sent. The endpoints are configured when the outbox is set up,
+// so that their credentials aren't stored with the messages. The requests are only signed if the endpoint has
+// a timestamp header.
+type Endpoint struct {
+	Method    string
+	URL       string
+	Header    map[string]string
+	Signature Signature
+}
+
+// Signature adds the Unix time at which a request is sent to a header and, if there is a secret,
+// the hex encoded HMAC-SHA256 of the timestamp, a dot and the body to another header, prefixed with "sha256=".
+type Signature struct {
+	Secret          string
+	Header          string
+	TimestampHeader string
+}
+
+// Sign returns the value of the signature header of a body sent at the timestamp
+func (s Signature) Sign(timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(s.Secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// HTTPDeliverer sends the requests of HTTP messages to the endpoints of their channels by name.
+// Requests are signed every time that they are sent, so that retries aren't rejected for being too old.
+func HTTPDeliverer(endpoints map[string]Endpoint) Deliverer {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		var request Request
+		if err := json.Unmarshal(payload, &request); err != nil {
+			return Permanent(err)
+		}
+		return send(ctx, endpoints, request)
+	}
+}
+
+// send sends a request, or its fallback if the request is rejected
+func send(ctx context.Context, endpoints map[string]Endpoint, request Request) error {
+	endpoint, found := endpoints[request.Endpoint]
+	if !found {
+		return Permanent(fmt.Errorf("endpoint %s is not configured", request.Endpoint))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, endpoint.Method, endpoint.URL+request.Path, bytes.NewReader(request.Body))
+	if err != nil {
+		return Permanent(err)
+	}
+	for key, value := range endpoint.Header {
+		req.Header.Set(key, value)
+	}
+	if endpoint.Signature.TimestampHeader != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(endpoint.Signature.TimestampHeader, timestamp)
+		if endpoint.Signature.Secret != "" {
+			req.Header.Set(endpoint.Signature.Header, endpoint.Signature.Sign(timestamp, request.Body))
+		}
+	}
+
+	err = do(req)
+	var permanent *permanentError
+	if request.Fallback != nil && errors.As(err, &permanent) {
+		log.Printf("Sending the fallback of a rejected request: %v", err)
+		return send(ctx, endpoints, *request.Fallback)
+	}
+	return err
+}
+
+// do sends a request. Rate limits, timeouts and server errors are retried, other client errors are permanent.
+func do(req *http.Request) error {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("received status code %v: %s", resp.StatusCode, bytes.TrimSpace(body))
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests || (resp.StatusCode == http.StatusServiceUnavailable && resp.Header.Get("Retry-After") != ""):
+		return &RetryAfterError{After: upstream.RetryAfter(resp.Header.Get("Retry-After"), defaultRetryAfter), Err: err}
+	case resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode >= 500:
+		return err
+	default:
+		return Permanent(err)
+	}
+}
@@ -0,0 +1,388 @@
+package outbox
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"jetspotter/internal/metrics"
+)
+
+// maxDeadLetters is the number of dead letters that are kept, the oldest ones are removed first
+const maxDeadLetters = 500
+
+// Message is a notification that has to be delivered to a channel
+type Message struct {
+	ID string `json:"id"`
+	// Name of the channel that the message is sent to, for example "Discord 2"
+	Channel string `json:"channel"`
+	// Kind of delivery, which determines how the payload is delivered, for example "http"
+	Kind    string          `json:"kind"`
+	Payload json.RawMessage `json:"payload"`
+	// Number of failed delivery attempts
+	Attempts int       `json:"attempts"`
+	Created  time.Time `json:"created"`
+	// Time at which the delivery is retried
+	NextAttempt time.Time `json:"nextAttempt"`
+	LastError   string    `json:"lastError,omitempty"`
+
+	// Set while the message is being delivered, so that it isn't delivered twice at the same time
+	inFlight bool
+}
+
+// Deliverer delivers the payload of a message. Errors are retried, unless they are wrapped with Permanent.
+type Deliverer func(ctx context.Context, payload json.RawMessage) error
+
+// RetryAfterError is returned by deliverers when the channel asks to retry no earlier than After
+type RetryAfterError struct {
+	After time.Duration
+	Err   error
+}
+
+func (e *RetryAfterError) Error() string {
+	return fmt.Sprintf("%v, retry after %s", e.Err, e.After)
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent marks an error that retrying doesn't fix, the message is moved to the dead letters right away.
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// Outbox stores the messages until they are delivered, retrying them with an exponential backoff.
+// Messages that can't be delivered after the maximum number of attempts are moved to the dead letters.
+// The messages are optionally persisted on disk, so that they survive restarts.
+type Outbox struct {
+	path        string
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+	timeout     time.Duration
+
+	mu          sync.Mutex
+	deliverers  map[string]Deliverer
+	pending     []*Message
+	deadLetters []*Message
+}
+
+// state is what is persisted on disk
+type state struct {
+	Pending     []*Message `json:"pending"`
+	DeadLetters []*Message `json:"deadLetters"`
+}
+
+// New creates an outbox. Failed deliveries are retried after baseDelay, doubling up to maxDelay, until maxAttempts.
+// Each attempt of the background worker is cancelled after the timeout. If path is not empty, the outbox is persisted to that file.
+// The messages of a kind can't be delivered until its deliverer is set with Handle.
+func New(path string, maxAttempts int, baseDelay, maxDelay, timeout time.Duration) *Outbox {
+	o := &Outbox{
+		path:        path,
+		maxAttempts: maxAttempts,
+		baseDelay:   baseDelay,
+		maxDelay:    maxDelay,
+		timeout:     timeout,
+		deliverers:  make(map[string]Deliverer),
+	}
+	return o
+}
+
+// Handle sets how the messages of a kind are delivered.
+func (o *Outbox) Handle(kind string, deliverer Deliverer) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.deliverers[kind] = deliverer
+}
+
+// Send stores a message for the channel and makes the first delivery attempt right away.
+// If it fails, the error is returned and the message is retried in the background by Run.
+func (o *Outbox) Send(ctx context.Context, channel, kind string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	message := &Message{
+		ID:          newID(),
+		Channel:     channel,
+		Kind:        kind,
+		Payload:     data,
+		Created:     now,
+		NextAttempt: now,
+		inFlight:    true,
+	}
+
+	o.mu.Lock()
+	o.pending = append(o.pending, message)
+	o.changed()
+	o.mu.Unlock()
+
+	return o.deliver(ctx, message)
+}
+
+// Run retries the pending messages when they are due, until the context is done.
+func (o *Outbox) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		for _, message := range o.due(time.Now()) {
+			go func(message *Message) {
+				ctx, cancel := context.WithTimeout(ctx, o.timeout)
+				defer cancel()
+				_ = o.deliver(ctx, message)
+			}(message)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// due returns the pending messages of which the next attempt has come, and marks them as in flight
+func (o *Outbox) due(now time.Time) []*Message {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var due []*Message
+	for _, message := range o.pending {
+		if !message.inFlight && !now.Before(message.NextAttempt) {
+			message.inFlight = true
+			due = append(due, message)
+		}
+	}
+	return due
+}
+
+// deliver attempts to deliver a message that is in flight and updates the outbox with the result
+func (o *Outbox) deliver(ctx context.Context, message *Message) error {
+	o.mu.Lock()
+	deliverer := o.deliverers[message.Kind]
+	o.mu.Unlock()
+
+	var err error
+	if deliverer == nil {
+		err = Permanent(fmt.Errorf("no deliverer for %s messages", message.Kind))
+	} else {
+		err = deliverer(ctx, message.Payload)
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	defer o.changed()
+
+	message.inFlight = false
+	if err == nil {
+		o.remove(message)
+		metrics.IncrementNotificationDeliveries(message.Channel, "delivered")
+		return nil
+	}
+
+	message.Attempts++
+	message.LastError = err.Error()
+
+	var permanent *permanentError
+	if errors.As(err, &permanent) || message.Attempts >= o.maxAttempts {
+		o.remove(message)
+		o.deadLetters = append(o.deadLetters, message)
+		if len(o.deadLetters) > maxDeadLetters {
+			o.deadLetters = o.deadLetters[len(o.deadLetters)-maxDeadLetters:]
+		}
+		metrics.IncrementNotificationDeliveries(message.Channel, "dead_letter")
+		log.Printf("Giving up on %s notification %s after %d attempts: %v", message.Channel, message.ID, message.Attempts, err)
+		return err
+	}
+
+	delay := o.backoff(message.Attempts)
+	var retryAfter *RetryAfterError
+	if errors.As(err, &retryAfter) && retryAfter.After > delay {
+		delay = retryAfter.After
+	}
+	message.NextAttempt = time.Now().Add(delay)
+	metrics.IncrementNotificationDeliveries(message.Channel, "retry")
+	log.Printf("Retrying %s notification %s in %s: %v", message.Channel, message.ID, delay.Round(time.Second), err)
+	return err
+}
+
+// backoff returns the delay before the next attempt after a number of failed attempts
+func (o *Outbox) backoff(attempts int) time.Duration {
+	delay := o.baseDelay
+	for i := 1; i < attempts && delay < o.maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > o.maxDelay {
+		return o.maxDelay
+	}
+	return delay
+}
+
+// remove removes a message from the pending messages, the caller must hold the lock.
+func (o *Outbox) remove(message *Message) {
+	for i, m := range o.pending {
+		if m == message {
+			o.pending = append(o.pending[:i], o.pending[i+1:]...)
+			return
+		}
+	}
+}
+
+// Pending returns a copy of the messages that are waiting to be delivered, the oldest first.
+func (o *Outbox) Pending() []Message {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return copyMessages(o.pending)
+}
+
+// DeadLetters returns a copy of the messages that could not be delivered, the oldest first.
+func (o *Outbox) DeadLetters() []Message {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return copyMessages(o.deadLetters)
+}
+
+func copyMessages(messages []*Message) []Message {
+	copies := make([]Message, 0, len(messages))
+	for _, m := range messages {
+		copies = append(copies, *m)
+	}
+	sort.SliceStable(copies, func(i, j int) bool { return copies[i].Created.Before(copies[j].Created) })
+	return copies
+}
+
+// Replay moves the dead letter with the ID back to the pending messages, with its attempts reset, and reports whether it was found.
+// The message is delivered by Run on its next tick.
+func (o *Outbox) Replay(id string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for i, message := range o.deadLetters {
+		if message.ID == id {
+			o.deadLetters = append(o.deadLetters[:i], o.deadLetters[i+1:]...)
+			o.requeue(message)
+			o.changed()
+			return true
+		}
+	}
+	return false
+}
+
+// ReplayAll moves all dead letters back to the pending messages and returns how many there were.
+func (o *Outbox) ReplayAll() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	count := len(o.deadLetters)
+	for _, message := range o.deadLetters {
+		o.requeue(message)
+	}
+	o.deadLetters = nil
+	o.changed()
+	return count
+}
+
+// requeue resets a message and adds it to the pending messages, the caller must hold the lock.
+func (o *Outbox) requeue(message *Message) {
+	message.Attempts = 0
+	message.NextAttempt = time.Now()
+	o.pending = append(o.pending, message)
+}
+
+// changed updates the metrics and persists the outbox after a change, the caller must hold the lock.
+func (o *Outbox) changed() {
+	pending := make(map[string]int)
+	for _, m := range o.pending {
+		pending[m.Channel]++
+	}
+	deadLetters := make(map[string]int)
+	for _, m := range o.deadLetters {
+		deadLetters[m.Channel]++
+	}
+	metrics.SetNotificationOutbox(pending, deadLetters)
+
+	if err := o.save(); err != nil {
+		log.Printf("Error saving notification outbox: %v", err)
+	}
+}
+
+// Load reads the persisted messages from disk. A missing file is not an error.
+// Messages that were in flight when the outbox was saved are retried.
+func (o *Outbox) Load() error {
+	if o.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(o.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read notification outbox: %w", err)
+	}
+
+	var s state
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("failed to parse notification outbox: %w", err)
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.pending, o.deadLetters = s.Pending, s.DeadLetters
+	o.changed()
+	return nil
+}
+
+// save persists the messages to disk, the caller must hold the lock.
+func (o *Outbox) save() error {
+	if o.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(state{Pending: o.pending, DeadLetters: o.deadLetters})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(o.path), 0o755); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that a crash never leaves a truncated outbox behind
+	tmp := o.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, o.path)
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
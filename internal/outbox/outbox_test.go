@@ -0,0 +1,142 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimitedMessageIsRetriedAfterRetryAfter(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After", "120")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	o := New("", 3, time.Second, time.Minute, time.Second)
+	o.Handle(HTTP, HTTPDeliverer(map[string]Endpoint{"Discord": {Method: http.MethodPost, URL: server.URL}}))
+	err := o.Send(context.Background(), "Discord", HTTP, Request{Endpoint: "Discord"})
+	var retryAfter *RetryAfterError
+	if !errors.As(err, &retryAfter) {
+		t.Fatalf("expected a rate limit error, got %v", err)
+	}
+
+	pending := o.Pending()
+	if len(pending) != 1 || pending[0].Attempts != 1 {
+		t.Fatalf("expected the message to be pending after one attempt, got %+v", pending)
+	}
+	// The Retry-After header is longer than the backoff, so it is honoured even though it exceeds the maximum delay
+	if wait := time.Until(pending[0].NextAttempt); wait < 110*time.Second {
+		t.Errorf("expected the retry to wait for the Retry-After period, got %s", wait)
+	}
+
+	if due := o.due(time.Now()); len(due) != 0 {
+		t.Fatalf("expected no messages to be due yet")
+	}
+	for _, message := range o.due(time.Now().Add(2 * time.Minute)) {
+		if err := o.deliver(context.Background(), message); err != nil {
+			t.Fatalf("expected the retry to succeed, got %v", err)
+		}
+	}
+	if len(o.Pending()) != 0 || requests != 2 {
+		t.Errorf("expected the message to be delivered on the second request, got %d requests", requests)
+	}
+}
+
+func TestFailedMessagesMoveToDeadLettersAndCanBeReplayed(t *testing.T) {
+	failing := true
+	o := New("", 2, time.Second, time.Minute, time.Second)
+	o.Handle("test", func(ctx context.Context, payload json.RawMessage) error {
+		if failing {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+	o.Handle("rejected", func(ctx context.Context, payload json.RawMessage) error {
+		return Permanent(errors.New("invalid webhook"))
+	})
+
+	_ = o.Send(context.Background(), "Slack", "test", "message")
+	for _, message := range o.due(time.Now().Add(time.Hour)) {
+		_ = o.deliver(context.Background(), message)
+	}
+	// Permanent errors are not retried at all
+	_ = o.Send(context.Background(), "Gotify", "rejected", "message")
+
+	dead := o.DeadLetters()
+	if len(o.Pending()) != 0 || len(dead) != 2 {
+		t.Fatalf("expected both messages to be dead letters, got %d pending and %d dead", len(o.Pending()), len(dead))
+	}
+	if dead[0].Channel != "Slack" || dead[0].Attempts != 2 || dead[0].LastError != "connection refused" || dead[1].Attempts != 1 {
+		t.Errorf("unexpected dead letters %+v", dead)
+	}
+
+	if o.Replay("unknown") {
+		t.Errorf("expected an unknown dead letter not to be replayed")
+	}
+	failing = false
+	if !o.Replay(dead[0].ID) {
+		t.Fatalf("expected the dead letter to be replayed")
+	}
+	for _, message := range o.due(time.Now()) {
+		if err := o.deliver(context.Background(), message); err != nil {
+			t.Errorf("expected the replayed message to be delivered, got %v", err)
+		}
+	}
+	if len(o.Pending()) != 0 || len(o.DeadLetters()) != 1 {
+		t.Errorf("expected only the rejected message to remain a dead letter")
+	}
+}
+
+func TestBackoffDoublesUpToTheMaximum(t *testing.T) {
+	o := New("", 10, 5*time.Second, time.Minute, time.Second)
+	expected := []time.Duration{5 * time.Second, 10 * time.Second, 20 * time.Second, 40 * time.Second, time.Minute, time.Minute}
+	for i, delay := range expected {
+		if actual := o.backoff(i + 1); actual != delay {
+			t.Errorf("expected a delay of %s after %d attempts, got %s", delay, i+1, actual)
+		}
+	}
+}
+
+func TestOutboxSurvivesRestarts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.json")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	o := New(path, 5, time.Second, time.Minute, time.Second)
+	o.Handle(HTTP, HTTPDeliverer(map[string]Endpoint{"Ntfy": {Method: http.MethodPost, URL: server.URL, Header: map[string]string{"Authorization": "Bearer tk_secret"}}}))
+	if err := o.Send(context.Background(), "Ntfy", HTTP, Request{Endpoint: "Ntfy", Body: []byte(`{"topic":"jets"}`)}); err == nil {
+		t.Fatalf("expected the bad gateway to fail the delivery")
+	}
+
+	restarted := New(path, 5, time.Second, time.Minute, time.Second)
+	if err := restarted.Load(); err != nil {
+		t.Fatal(err)
+	}
+	pending := restarted.Pending()
+	if len(pending) != 1 || pending[0].Channel != "Ntfy" || pending[0].Attempts != 1 {
+		t.Fatalf("expected the pending message to be loaded, got %+v", pending)
+	}
+
+	var request Request
+	if err := json.Unmarshal(pending[0].Payload, &request); err != nil || string(request.Body) != `{"topic":"jets"}` {
+		t.Errorf("expected the request to be persisted, got %+v (%v)", request, err)
+	}
+	if strings.Contains(string(pending[0].Payload), "tk_secret") || strings.Contains(string(pending[0].Payload), server.URL) {
+		t.Errorf("expected the endpoint to be kept out of the outbox, got %s", pending[0].Payload)
+	}
+}
@@ -1,11 +1,14 @@
 package planespotter
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+
+	"jetspotter/internal/upstream"
 )
 
 const userAgent = "jetspotter/1.0 (+https://github.com/vvanouytsel/jetspotter)"
@@ -34,9 +37,9 @@ type ImagesData struct {
 
 // GetImageFromAPI uses the planespotters.net API to retrieve information about an image based on ICAO code.
 func GetImageFromAPI(ICAO, registration string) (image *Image) {
-	image = getImageByICAO(ICAO)
-	if image == nil {
-		image = getImageByRegistration(registration)
+	image, err := LookupImage(context.Background(), ICAO, registration)
+	if err != nil {
+		log.Printf("planespotter: %v", err)
 	}
 
 	if image == nil {
@@ -46,44 +49,50 @@ func GetImageFromAPI(ICAO, registration string) (image *Image) {
 	return image
 }
 
-func getImageByICAO(ICAO string) (image *Image) {
-	return fetchImage(fmt.Sprintf("https://api.planespotters.net/pub/photos/hex/%s", ICAO))
+// LookupImage uses the planespotters.net API to retrieve information about an image based on ICAO code,
+// the registration is used if no image is found for the ICAO code.
+// No image and no error are returned if planespotters.net has no image of the aircraft.
+func LookupImage(ctx context.Context, ICAO, registration string) (*Image, error) {
+	image, err := getImageByICAO(ctx, ICAO)
+	if err != nil || image != nil {
+		return image, err
+	}
+
+	return getImageByRegistration(ctx, registration)
 }
 
-func getImageByRegistration(registration string) (image *Image) {
-	return fetchImage(fmt.Sprintf("https://api.planespotters.net/pub/photos/reg/%s", registration))
+func getImageByICAO(ctx context.Context, ICAO string) (*Image, error) {
+	return fetchImage(ctx, fmt.Sprintf("https://api.planespotters.net/pub/photos/hex/%s", ICAO))
 }
 
-func fetchImage(URL string) *Image {
-	var images ImagesData
-	req, err := http.NewRequest("GET", URL, nil)
-	if err != nil {
-		log.Printf("planespotter: failed to create request for %s: %v", URL, err)
-		return nil
-	}
-	req.Header.Set("User-Agent", userAgent)
+func getImageByRegistration(ctx context.Context, registration string) (*Image, error) {
+	return fetchImage(ctx, fmt.Sprintf("https://api.planespotters.net/pub/photos/reg/%s", registration))
+}
 
-	res, err := http.DefaultClient.Do(req)
+func fetchImage(ctx context.Context, URL string) (*Image, error) {
+	var images ImagesData
+	res, err := upstream.Planespotters.Get(ctx, URL, map[string]string{"User-Agent": userAgent})
 	if err != nil {
-		log.Printf("planespotter: request failed for %s: %v", URL, err)
-		return nil
+		return nil, fmt.Errorf("request failed for %s: %w", URL, err)
 	}
 	defer res.Body.Close()
 
 	body, _ := io.ReadAll(res.Body)
+	if res.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+
 	if res.StatusCode != http.StatusOK {
-		log.Printf("planespotter: unexpected status %d for %s: %s", res.StatusCode, URL, string(body))
-		return nil
+		return nil, fmt.Errorf("unexpected status %d for %s: %s", res.StatusCode, URL, string(body))
 	}
 
 	if err := json.Unmarshal(body, &images); err != nil {
-		log.Printf("planespotter: failed to parse response from %s: %v", URL, err)
-		return nil
+		return nil, fmt.Errorf("failed to parse response from %s: %w", URL, err)
 	}
 
 	if len(images.Images) == 0 {
-		return nil
+		return nil, nil
 	}
 
-	return &images.Images[0]
+	return &images.Images[0], nil
 }
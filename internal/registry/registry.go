@@ -0,0 +1,259 @@
+package registry
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Aircraft contains what the database knows about an aircraft
+type Aircraft struct {
+	// ICAO 24-bit address in lowercase hex
+	ICAO         string
+	Registration string
+	// ICAO type designator, for example A320
+	Type string
+	// Description of the type, for example AIRBUS A-320
+	Description string
+	// Owner or operator of the aircraft
+	Operator string
+	// Year in which the aircraft was built
+	Year string
+	// Military aircraft
+	Military bool
+}
+
+// Database is an offline aircraft database keyed by ICAO 24-bit address.
+// It supports the semicolon separated aircraft.csv of tar1090-db, and comma separated files with a header
+// such as exports of BaseStation.sqb or the OpenSky aircraft database. Files ending in .gz are decompressed.
+type Database struct {
+	path string
+
+	mu       sync.RWMutex
+	aircraft map[string]Aircraft
+	modTime  time.Time
+}
+
+// Load reads the database at path.
+func Load(path string) (*Database, error) {
+	db := &Database{path: path}
+	if err := db.Reload(); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// Lookup returns the aircraft with the given ICAO 24-bit address.
+func (db *Database) Lookup(ICAO string) (Aircraft, bool) {
+	if db == nil {
+		return Aircraft{}, false
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	aircraft, found := db.aircraft[strings.ToLower(strings.TrimPrefix(ICAO, "~"))]
+	return aircraft, found
+}
+
+// Len returns the number of aircraft in the database.
+func (db *Database) Len() int {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return len(db.aircraft)
+}
+
+// Reload reads the file again if it changed since it was last read.
+func (db *Database) Reload() error {
+	info, err := os.Stat(db.path)
+	if err != nil {
+		return err
+	}
+
+	db.mu.RLock()
+	unchanged := info.ModTime().Equal(db.modTime)
+	db.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	aircraft, err := readFile(db.path)
+	if err != nil {
+		return err
+	}
+
+	db.mu.Lock()
+	db.aircraft = aircraft
+	db.modTime = info.ModTime()
+	db.mu.Unlock()
+
+	log.Printf("Loaded %d aircraft from %s", len(aircraft), db.path)
+	return nil
+}
+
+// Watch reloads the database every interval until stop is closed.
+func (db *Database) Watch(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := db.Reload(); err != nil {
+				log.Printf("Error reloading aircraft database: %v", err)
+			}
+		}
+	}
+}
+
+func readFile(path string) (map[string]Aircraft, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	// Detect gzip by its magic number, so that compressed files don't need a .gz extension
+	if magic, err := reader.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress %s: %w", path, err)
+		}
+		defer gz.Close()
+		reader = bufio.NewReader(gz)
+	}
+
+	aircraft, err := parse(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return aircraft, nil
+}
+
+// Column names of files with a header, in lowercase without spaces or underscores
+var columns = map[string][]string{
+	"icao":         {"modes", "icao24", "hex", "icao", "icaohex"},
+	"registration": {"registration", "reg", "r"},
+	"type":         {"icaotypecode", "typecode", "icaotype", "t"},
+	"description":  {"type", "model", "description", "desc"},
+	"manufacturer": {"manufacturer", "manufacturername"},
+	"operator":     {"registeredowners", "operator", "owner", "ownop"},
+	"year":         {"yearbuilt", "built", "year"},
+	"military":     {"military", "mil"},
+}
+
+func parse(reader *bufio.Reader) (map[string]Aircraft, error) {
+	firstLine, err := reader.Peek(4096)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+	if i := bytes.IndexByte(firstLine, '\n'); i >= 0 {
+		firstLine = firstLine[:i]
+	}
+
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.LazyQuotes = true
+	csvReader.ReuseRecord = true
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		csvReader.Comma = ';'
+	}
+
+	// tar1090-db has no header: hex;registration;type;flags;description;year;owner or operator
+	index := map[string]int{"icao": 0, "registration": 1, "type": 2, "flags": 3, "description": 4, "year": 5, "operator": 6}
+
+	aircraft := make(map[string]Aircraft)
+	first := true
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if first {
+			first = false
+			if header := parseHeader(record); header != nil {
+				index = header
+				continue
+			}
+		}
+
+		ac := Aircraft{
+			ICAO:         strings.ToLower(strings.TrimSpace(field(record, index, "icao"))),
+			Registration: field(record, index, "registration"),
+			Type:         field(record, index, "type"),
+			Description:  field(record, index, "description"),
+			Operator:     field(record, index, "operator"),
+			Year:         field(record, index, "year"),
+		}
+		if len(ac.ICAO) != 6 {
+			continue
+		}
+
+		if manufacturer := field(record, index, "manufacturer"); manufacturer != "" && !strings.HasPrefix(strings.ToUpper(ac.Description), strings.ToUpper(manufacturer)) {
+			ac.Description = strings.TrimSpace(manufacturer + " " + ac.Description)
+		}
+
+		// Dates such as 2005-01-01 only keep the year
+		if len(ac.Year) > 4 {
+			ac.Year = ac.Year[:4]
+		}
+		if ac.Year == "0000" {
+			ac.Year = ""
+		}
+
+		// The first flag of tar1090-db marks military aircraft
+		flags := field(record, index, "flags")
+		military := field(record, index, "military")
+		ac.Military = strings.HasPrefix(flags, "1") || military == "1" || strings.EqualFold(military, "true")
+
+		aircraft[ac.ICAO] = ac
+	}
+
+	return aircraft, nil
+}
+
+// parseHeader returns the index of the known columns, or nil if the record isn't a header
+func parseHeader(record []string) map[string]int {
+	names := make(map[string]int)
+	for i, name := range record {
+		name = strings.ToLower(strings.NewReplacer(" ", "", "_", "", "'", "", "\"", "").Replace(name))
+		names[name] = i
+	}
+
+	index := make(map[string]int)
+	for column, aliases := range columns {
+		for _, alias := range aliases {
+			if i, ok := names[alias]; ok {
+				index[column] = i
+				break
+			}
+		}
+	}
+
+	if _, ok := index["icao"]; !ok {
+		return nil
+	}
+	return index
+}
+
+func field(record []string, index map[string]int, column string) string {
+	i, ok := index[column]
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
@@ -0,0 +1,75 @@
+package registry
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadTar1090Database(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "aircraft.csv.gz")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(file)
+	gz.Write([]byte("44c1e5;OO-SNA;A320;00;AIRBUS A-320;2012;Brussels Airlines\n" +
+		"ae1234;12-3456;C17;10;BOEING C-17 Globemaster III;;United States Air Force\n"))
+	gz.Close()
+	file.Close()
+
+	db, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ac, found := db.Lookup("44C1E5")
+	if !found || ac.Registration != "OO-SNA" || ac.Type != "A320" || ac.Operator != "Brussels Airlines" || ac.Year != "2012" || ac.Military {
+		t.Fatalf("unexpected aircraft %+v", ac)
+	}
+
+	ac, found = db.Lookup("~ae1234")
+	if !found || !ac.Military || ac.Description != "BOEING C-17 Globemaster III" {
+		t.Fatalf("expected a military C-17, got %+v", ac)
+	}
+}
+
+func TestLoadDatabaseWithHeader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "basestation.csv")
+	os.WriteFile(path, []byte("ModeS,Registration,ICAOTypeCode,Manufacturer,Type,RegisteredOwners,YearBuilt\n"+
+		"484F6D,PH-BXA,B738,Boeing,737-8K2,KLM Royal Dutch Airlines,1998-12-01\n"), 0o644)
+
+	db, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ac, found := db.Lookup("484f6d")
+	if !found || ac.Registration != "PH-BXA" || ac.Type != "B738" || ac.Description != "Boeing 737-8K2" || ac.Year != "1998" {
+		t.Fatalf("unexpected aircraft %+v", ac)
+	}
+}
+
+func TestReloadWhenChanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "aircraft.csv")
+	os.WriteFile(path, []byte("44c1e5;OO-SNA;A320;00;;;\n"), 0o644)
+
+	db, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	os.WriteFile(path, []byte("44c1e5;OO-SNB;A320;00;;;\n484f6d;PH-BXA;B738;00;;;\n"), 0o644)
+	os.Chtimes(path, time.Now(), time.Now().Add(time.Minute))
+
+	if err := db.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	ac, _ := db.Lookup("44c1e5")
+	if db.Len() != 2 || ac.Registration != "OO-SNB" {
+		t.Fatalf("expected the changed database to be loaded, got %d aircraft and %+v", db.Len(), ac)
+	}
+}
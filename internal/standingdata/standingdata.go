@@ -0,0 +1,222 @@
+package standingdata
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Route is a scheduled flight of an airline
+type Route struct {
+	Callsign    string
+	AirlineCode string
+	// Airports from origin to destination, including the intermediate stops
+	AirportCodes []string
+}
+
+// Airline is an operator of scheduled flights
+type Airline struct {
+	Code string
+	Name string
+	ICAO string
+	IATA string
+}
+
+// Airport is an airport that is used by routes
+type Airport struct {
+	Code         string
+	Name         string
+	ICAO         string
+	IATA         string
+	Location     string
+	CountryISO   string
+	Latitude     float64
+	Longitude    float64
+	AltitudeFeet int
+}
+
+// Database contains the routes, airlines and airports of the Virtual Radar Server standing data.
+// See https://github.com/vradarserver/standing-data for the format of the CSV files.
+type Database struct {
+	routes   map[string]Route
+	airlines map[string]Airline
+	// Airports are indexed by all of their codes
+	airports     map[string]Airport
+	airportCount int
+}
+
+// Load reads every CSV file in the directory and its subdirectories.
+// The kind of data in a file is detected from its header, files that aren't standing data are skipped.
+func Load(directory string) (*Database, error) {
+	db := &Database{
+		routes:   make(map[string]Route),
+		airlines: make(map[string]Airline),
+		airports: make(map[string]Airport),
+	}
+
+	err := filepath.WalkDir(directory, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(path), ".csv") {
+			return nil
+		}
+		if err := db.loadFile(path); err != nil {
+			return fmt.Errorf("failed to load %s: %w", path, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+func (db *Database) loadFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))] = i
+	}
+
+	var add func(record []string)
+	switch {
+	case has(columns, "Callsign", "AirportCodes"):
+		add = func(record []string) {
+			route := Route{
+				Callsign:    strings.ToUpper(field(record, columns, "Callsign")),
+				AirlineCode: field(record, columns, "AirlineCode"),
+			}
+			for _, code := range strings.Split(field(record, columns, "AirportCodes"), "-") {
+				if code = strings.TrimSpace(code); code != "" {
+					route.AirportCodes = append(route.AirportCodes, code)
+				}
+			}
+			if route.Callsign != "" && len(route.AirportCodes) >= 2 {
+				db.routes[route.Callsign] = route
+			}
+		}
+	case has(columns, "Code", "Name", "Latitude", "Longitude"):
+		add = func(record []string) {
+			airport := Airport{
+				Code:       field(record, columns, "Code"),
+				Name:       field(record, columns, "Name"),
+				ICAO:       field(record, columns, "ICAO"),
+				IATA:       field(record, columns, "IATA"),
+				Location:   field(record, columns, "Location"),
+				CountryISO: field(record, columns, "CountryISO2"),
+			}
+			airport.Latitude, _ = strconv.ParseFloat(field(record, columns, "Latitude"), 64)
+			airport.Longitude, _ = strconv.ParseFloat(field(record, columns, "Longitude"), 64)
+			airport.AltitudeFeet, _ = strconv.Atoi(field(record, columns, "AltitudeFeet"))
+			db.airportCount++
+			for _, code := range []string{airport.Code, airport.ICAO, airport.IATA} {
+				if code != "" {
+					db.airports[code] = airport
+				}
+			}
+		}
+	case has(columns, "Code", "Name", "ICAO", "IATA"):
+		add = func(record []string) {
+			airline := Airline{
+				Code: field(record, columns, "Code"),
+				Name: field(record, columns, "Name"),
+				ICAO: field(record, columns, "ICAO"),
+				IATA: field(record, columns, "IATA"),
+			}
+			if airline.Code != "" {
+				db.airlines[airline.Code] = airline
+			}
+		}
+	default:
+		return nil
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		add(record)
+	}
+}
+
+// Route returns the route that is flown with the callsign.
+func (db *Database) Route(callsign string) (Route, bool) {
+	route, found := db.routes[strings.ToUpper(callsign)]
+	return route, found
+}
+
+// Airline returns the airline with the given code.
+func (db *Database) Airline(code string) (Airline, bool) {
+	airline, found := db.airlines[code]
+	return airline, found
+}
+
+// Airport returns the airport with the given ICAO, IATA or standing data code.
+func (db *Database) Airport(code string) (Airport, bool) {
+	airport, found := db.airports[code]
+	return airport, found
+}
+
+// Airports returns every airport in the database once.
+func (db *Database) Airports() []Airport {
+	seen := make(map[string]bool)
+	airports := make([]Airport, 0, db.airportCount)
+	for _, airport := range db.airports {
+		if seen[airport.Code] {
+			continue
+		}
+		seen[airport.Code] = true
+		airports = append(airports, airport)
+	}
+	return airports
+}
+
+// Len returns the number of routes, airlines and airports in the database.
+func (db *Database) Len() (routes, airlines, airports int) {
+	return len(db.routes), len(db.airlines), db.airportCount
+}
+
+func has(columns map[string]int, names ...string) bool {
+	for _, name := range names {
+		if _, ok := columns[name]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func field(record []string, columns map[string]int, name string) string {
+	i, ok := columns[name]
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
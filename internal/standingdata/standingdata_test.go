@@ -0,0 +1,33 @@
+package standingdata
+
+import "testing"
+
+func TestLoad(t *testing.T) {
+	db, err := Load("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	routes, airlines, airports := db.Len()
+	if routes != 2 || airlines != 1 || airports != 4 {
+		t.Fatalf("expected 2 routes, 1 airline and 4 airports, got %d, %d and %d", routes, airlines, airports)
+	}
+
+	route, found := db.Route("baw15")
+	if !found || len(route.AirportCodes) != 3 || route.AirportCodes[1] != "WSSS" {
+		t.Fatalf("expected the route via WSSS, got %+v", route)
+	}
+
+	airport, found := db.Airport("LHR")
+	if !found || airport.ICAO != "EGLL" || airport.Latitude != 51.4706 || airport.AltitudeFeet != 83 {
+		t.Fatalf("expected Heathrow by its IATA code, got %+v", airport)
+	}
+
+	if airline, found := db.Airline("BAW"); !found || airline.Name != "British Airways" {
+		t.Fatalf("expected British Airways, got %+v", airline)
+	}
+
+	if airports := db.Airports(); len(airports) != 4 {
+		t.Fatalf("expected every airport once, got %d", len(airports))
+	}
+}
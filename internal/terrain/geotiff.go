@@ -0,0 +1,345 @@
+package terrain
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// TIFF tags that are needed to read a digital elevation model
+const (
+	tagImageWidth      = 256
+	tagImageLength     = 257
+	tagBitsPerSample   = 258
+	tagCompression     = 259
+	tagStripOffsets    = 273
+	tagSamplesPerPixel = 277
+	tagRowsPerStrip    = 278
+	tagStripByteCounts = 279
+	tagPredictor       = 317
+	tagTileWidth       = 322
+	tagTileLength      = 323
+	tagTileOffsets     = 324
+	tagTileByteCounts  = 325
+	tagSampleFormat    = 339
+	tagModelPixelScale = 33550
+	tagModelTiepoint   = 33922
+	tagGeoKeyDirectory = 34735
+	tagGDALNoData      = 42113
+)
+
+// GeoTIFF keys of the georeferencing
+const (
+	keyModelType  = 1024
+	keyRasterType = 1025
+
+	modelTypeGeographic = 2
+	rasterPixelIsPoint  = 2
+)
+
+// Byte sizes of the TIFF field types, by type
+var fieldSizes = map[uint16]int{1: 1, 2: 1, 3: 2, 4: 4, 6: 1, 8: 2, 9: 4, 11: 4, 12: 8}
+
+type tiff struct {
+	order binary.ByteOrder
+	data  []byte
+	tags  map[uint16]field
+}
+
+type field struct {
+	kind  uint16
+	count int
+	value []byte
+}
+
+// LoadGeoTIFF loads the first band of a GeoTIFF with the elevations in meters, like the ones of Copernicus or the
+// SRTM tiles that GDAL exports. Only geographic coordinates and uncompressed or deflate compressed data are supported.
+func LoadGeoTIFF(path string) (*Grid, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := parseTIFF(data)
+	if err != nil {
+		return nil, err
+	}
+	return t.grid()
+}
+
+func parseTIFF(data []byte) (*tiff, error) {
+	if len(data) < 8 {
+		return nil, errors.New("not a TIFF file")
+	}
+
+	t := &tiff{data: data, tags: make(map[uint16]field)}
+	switch string(data[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return nil, errors.New("not a TIFF file")
+	}
+	if magic := t.order.Uint16(data[2:]); magic != 42 {
+		return nil, fmt.Errorf("unsupported TIFF version %d, BigTIFF isn't supported", magic)
+	}
+
+	// Only the first image is read, the others are usually overviews
+	offset := int(t.order.Uint32(data[4:]))
+	if offset+2 > len(data) {
+		return nil, errors.New("truncated TIFF file")
+	}
+	entries := int(t.order.Uint16(data[offset:]))
+	for i := 0; i < entries; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(data) {
+			return nil, errors.New("truncated TIFF file")
+		}
+
+		tag := t.order.Uint16(data[entry:])
+		kind := t.order.Uint16(data[entry+2:])
+		count := int(t.order.Uint32(data[entry+4:]))
+		size, known := fieldSizes[kind]
+		if !known {
+			continue
+		}
+
+		// Values that don't fit in the entry are stored elsewhere
+		value := data[entry+8 : entry+12]
+		if length := size * count; length > 4 {
+			start := int(t.order.Uint32(value))
+			if start+length > len(data) || start < 0 {
+				return nil, fmt.Errorf("truncated TIFF tag %d", tag)
+			}
+			value = data[start : start+length]
+		}
+		t.tags[tag] = field{kind: kind, count: count, value: value}
+	}
+
+	return t, nil
+}
+
+// numbers returns the values of a numeric tag
+func (t *tiff) numbers(tag uint16) []float64 {
+	f, found := t.tags[tag]
+	if !found {
+		return nil
+	}
+
+	values := make([]float64, f.count)
+	for i := range values {
+		switch f.kind {
+		case 1:
+			values[i] = float64(f.value[i])
+		case 6:
+			values[i] = float64(int8(f.value[i]))
+		case 3:
+			values[i] = float64(t.order.Uint16(f.value[i*2:]))
+		case 8:
+			values[i] = float64(int16(t.order.Uint16(f.value[i*2:])))
+		case 4:
+			values[i] = float64(t.order.Uint32(f.value[i*4:]))
+		case 9:
+			values[i] = float64(int32(t.order.Uint32(f.value[i*4:])))
+		case 11:
+			values[i] = float64(math.Float32frombits(t.order.Uint32(f.value[i*4:])))
+		case 12:
+			values[i] = math.Float64frombits(t.order.Uint64(f.value[i*8:]))
+		}
+	}
+	return values
+}
+
+// number returns the first value of a numeric tag, or the fallback if it isn't set
+func (t *tiff) number(tag uint16, fallback int) int {
+	if values := t.numbers(tag); len(values) > 0 {
+		return int(values[0])
+	}
+	return fallback
+}
+
+// geoKey returns the value of a key of the GeoTIFF key directory, or 0 if it isn't set
+func (t *tiff) geoKey(key int) int {
+	directory := t.numbers(tagGeoKeyDirectory)
+	// The header is followed by entries of the key, location, count and value
+	for i := 4; i+3 < len(directory); i += 4 {
+		if int(directory[i]) == key && directory[i+1] == 0 {
+			return int(directory[i+3])
+		}
+	}
+	return 0
+}
+
+func (t *tiff) grid() (*Grid, error) {
+	width, height := t.number(tagImageWidth, 0), t.number(tagImageLength, 0)
+	if width <= 0 || height <= 0 {
+		return nil, errors.New("missing image size")
+	}
+	if samples := t.number(tagSamplesPerPixel, 1); samples != 1 {
+		return nil, fmt.Errorf("expected a single band, got %d", samples)
+	}
+	if model := t.geoKey(keyModelType); model != 0 && model != modelTypeGeographic {
+		return nil, errors.New("only geographic coordinates are supported, reproject the file to EPSG:4326")
+	}
+
+	scale, tiepoint := t.numbers(tagModelPixelScale), t.numbers(tagModelTiepoint)
+	if len(scale) < 2 || len(tiepoint) < 6 || scale[0] <= 0 || scale[1] <= 0 {
+		return nil, errors.New("missing georeferencing")
+	}
+
+	// The tie point refers to the corner of a pixel, unless the pixels are points
+	centerOffset := 0.5
+	if t.geoKey(keyRasterType) == rasterPixelIsPoint {
+		centerOffset = 0
+	}
+
+	grid := &Grid{
+		West:          tiepoint[3] + (centerOffset-tiepoint[0])*scale[0],
+		North:         tiepoint[4] - (centerOffset-tiepoint[1])*scale[1],
+		LongitudeStep: scale[0],
+		LatitudeStep:  scale[1],
+		Rows:          height,
+		Columns:       width,
+		Samples:       make([]int16, width*height),
+	}
+
+	if err := t.readSamples(grid); err != nil {
+		return nil, err
+	}
+	return grid, nil
+}
+
+// readSamples decodes the strips or tiles of the image into the samples of the grid
+func (t *tiff) readSamples(grid *Grid) error {
+	bits := t.number(tagBitsPerSample, 1)
+	format := t.number(tagSampleFormat, 1)
+	compression := t.number(tagCompression, 1)
+	predictor := t.number(tagPredictor, 1)
+
+	decode, err := sampleDecoder(t.order, bits, format)
+	if err != nil {
+		return err
+	}
+	if compression != 1 && compression != 8 && compression != 32946 {
+		return fmt.Errorf("unsupported compression %d, only uncompressed and deflate are supported", compression)
+	}
+	if predictor != 1 && (predictor != 2 || format == 3) {
+		return fmt.Errorf("unsupported predictor %d", predictor)
+	}
+
+	noData, hasNoData := math.NaN(), false
+	if f, found := t.tags[tagGDALNoData]; found {
+		value, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimRight(string(f.value), "\x00")), 64)
+		noData, hasNoData = value, err == nil
+	}
+
+	// Strips are tiles that span the width of the image
+	blockWidth, blockHeight := t.number(tagTileWidth, 0), t.number(tagTileLength, 0)
+	offsets, counts := t.numbers(tagTileOffsets), t.numbers(tagTileByteCounts)
+	if blockWidth == 0 {
+		blockWidth, blockHeight = grid.Columns, t.number(tagRowsPerStrip, grid.Rows)
+		offsets, counts = t.numbers(tagStripOffsets), t.numbers(tagStripByteCounts)
+	}
+	if blockHeight <= 0 || len(offsets) == 0 || len(offsets) != len(counts) {
+		return errors.New("missing image data")
+	}
+
+	bytesPerSample := bits / 8
+	blocksAcross := (grid.Columns + blockWidth - 1) / blockWidth
+	for i := range offsets {
+		start, length := int(offsets[i]), int(counts[i])
+		if start < 0 || start+length > len(t.data) {
+			return errors.New("truncated image data")
+		}
+		block := t.data[start : start+length]
+		if compression != 1 {
+			if block, err = inflate(block); err != nil {
+				return err
+			}
+		}
+		if len(block) < blockWidth*bytesPerSample {
+			return errors.New("truncated image data")
+		}
+
+		top, left := (i/blocksAcross)*blockHeight, (i%blocksAcross)*blockWidth
+		for y := 0; y < blockHeight && top+y < grid.Rows; y++ {
+			var previous float64
+			for x := 0; x < blockWidth; x++ {
+				at := (y*blockWidth + x) * bytesPerSample
+				if at+bytesPerSample > len(block) {
+					break
+				}
+				value := decode(block[at:])
+				// The horizontal predictor stores the difference with the previous sample of the row
+				if predictor == 2 {
+					value = wrapInteger(previous+value, bits, format)
+					previous = value
+				}
+				if left+x >= grid.Columns {
+					continue
+				}
+
+				sample := int16(void)
+				if !math.IsNaN(value) && (!hasNoData || value != noData) {
+					sample = int16(math.Max(math.MinInt16+1, math.Min(math.MaxInt16, math.Round(value))))
+				}
+				grid.Samples[(top+y)*grid.Columns+left+x] = sample
+			}
+		}
+	}
+
+	return nil
+}
+
+// sampleDecoder returns a function that decodes a sample of the bits and sample format
+func sampleDecoder(order binary.ByteOrder, bits, format int) (func([]byte) float64, error) {
+	switch {
+	case bits == 8 && format == 1:
+		return func(b []byte) float64 { return float64(b[0]) }, nil
+	case bits == 8 && format == 2:
+		return func(b []byte) float64 { return float64(int8(b[0])) }, nil
+	case bits == 16 && format == 1:
+		return func(b []byte) float64 { return float64(order.Uint16(b)) }, nil
+	case bits == 16 && format == 2:
+		return func(b []byte) float64 { return float64(int16(order.Uint16(b))) }, nil
+	case bits == 32 && format == 1:
+		return func(b []byte) float64 { return float64(order.Uint32(b)) }, nil
+	case bits == 32 && format == 2:
+		return func(b []byte) float64 { return float64(int32(order.Uint32(b))) }, nil
+	case bits == 32 && format == 3:
+		return func(b []byte) float64 { return float64(math.Float32frombits(order.Uint32(b))) }, nil
+	case bits == 64 && format == 3:
+		return func(b []byte) float64 { return math.Float64frombits(order.Uint64(b)) }, nil
+	}
+	return nil, fmt.Errorf("unsupported samples of %d bits with format %d", bits, format)
+}
+
+// wrapInteger wraps the sum of the horizontal predictor around like the integer type of the samples would
+func wrapInteger(value float64, bits, format int) float64 {
+	modulus := math.Ldexp(1, bits)
+	value = math.Mod(value, modulus)
+	if value < 0 {
+		value += modulus
+	}
+	if format == 2 && value >= modulus/2 {
+		value -= modulus
+	}
+	return value
+}
+
+func inflate(data []byte) ([]byte, error) {
+	reader, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress image data: %w", err)
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
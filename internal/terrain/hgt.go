@@ -0,0 +1,72 @@
+package terrain
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// LoadHGT loads an SRTM tile, for example N51E005.hgt. The name of the tile is the south-west corner that it covers,
+// its size tells whether it has a resolution of 1 or 3 arc seconds.
+func LoadHGT(path string) (*Grid, error) {
+	south, west, err := parseTileName(filepath.Base(path))
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	size := int(math.Sqrt(float64(len(data) / 2)))
+	if size < 2 || size*size*2 != len(data) {
+		return nil, fmt.Errorf("unexpected size of %d bytes", len(data))
+	}
+
+	// Samples are big-endian signed integers, the tiles overlap their neighbours by one row and column
+	samples := make([]int16, size*size)
+	for i := range samples {
+		samples[i] = int16(binary.BigEndian.Uint16(data[i*2:]))
+	}
+
+	step := 1 / float64(size-1)
+	return &Grid{
+		North:         float64(south + 1),
+		West:          float64(west),
+		LatitudeStep:  step,
+		LongitudeStep: step,
+		Rows:          size,
+		Columns:       size,
+		Samples:       samples,
+	}, nil
+}
+
+// parseTileName parses the south-west corner of an SRTM tile name like N51E005.hgt or S23W043.hgt
+func parseTileName(name string) (south, west int, err error) {
+	name = strings.ToUpper(strings.TrimSuffix(name, filepath.Ext(name)))
+	if len(name) != 7 || (name[0] != 'N' && name[0] != 'S') || (name[3] != 'E' && name[3] != 'W') {
+		return 0, 0, fmt.Errorf("invalid SRTM tile name %q", name)
+	}
+
+	south, err = strconv.Atoi(name[1:3])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid SRTM tile name %q", name)
+	}
+	west, err = strconv.Atoi(name[4:7])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid SRTM tile name %q", name)
+	}
+
+	if name[0] == 'S' {
+		south = -south
+	}
+	if name[3] == 'W' {
+		west = -west
+	}
+	return south, west, nil
+}
@@ -0,0 +1,125 @@
+package terrain
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// void is the value of samples without elevation data, like the voids of SRTM over water and in steep terrain
+const void = math.MinInt16
+
+// Grid is a raster of elevations in meters, the first row is the northernmost one
+type Grid struct {
+	// Latitude and longitude of the center of the first sample
+	North, West float64
+	// Distance between the centers of the samples in degrees
+	LatitudeStep, LongitudeStep float64
+	Rows, Columns               int
+	Samples                     []int16
+}
+
+// sample returns the elevation of a sample, false if it is outside of the grid or has no data
+func (g *Grid) sample(row, column int) (float64, bool) {
+	if row < 0 || row >= g.Rows || column < 0 || column >= g.Columns {
+		return 0, false
+	}
+	value := g.Samples[row*g.Columns+column]
+	return float64(value), value != void
+}
+
+// Elevation returns the elevation in meters at the position, interpolated between the four surrounding samples.
+// Samples without data are left out of the interpolation.
+func (g *Grid) Elevation(lat, lon float64) (float64, bool) {
+	y := (g.North - lat) / g.LatitudeStep
+	x := (lon - g.West) / g.LongitudeStep
+	// Positions up to half a sample outside of the grid are still covered by its outer samples
+	if y < -0.5 || x < -0.5 || y > float64(g.Rows)-0.5 || x > float64(g.Columns)-0.5 {
+		return 0, false
+	}
+
+	row, column := int(math.Floor(y)), int(math.Floor(x))
+	dy, dx := y-float64(row), x-float64(column)
+
+	var sum, weights float64
+	for _, corner := range []struct {
+		row, column int
+		weight      float64
+	}{
+		{row, column, (1 - dy) * (1 - dx)},
+		{row, column + 1, (1 - dy) * dx},
+		{row + 1, column, dy * (1 - dx)},
+		{row + 1, column + 1, dy * dx},
+	} {
+		if elevation, ok := g.sample(corner.row, corner.column); ok && corner.weight > 0 {
+			sum += elevation * corner.weight
+			weights += corner.weight
+		}
+	}
+	if weights == 0 {
+		return 0, false
+	}
+	return sum / weights, true
+}
+
+// Model is the terrain elevation of the loaded tiles
+type Model struct {
+	grids []*Grid
+}
+
+// Add adds a grid to the model, grids that are added first take precedence where they overlap.
+func (m *Model) Add(grid *Grid) {
+	m.grids = append(m.grids, grid)
+}
+
+// Len returns the number of grids in the model.
+func (m *Model) Len() int {
+	return len(m.grids)
+}
+
+// Elevation returns the elevation of the terrain in meters at the position, false if no tile covers it.
+func (m *Model) Elevation(lat, lon float64) (float64, bool) {
+	if m == nil {
+		return 0, false
+	}
+	for _, grid := range m.grids {
+		if elevation, ok := grid.Elevation(lat, lon); ok {
+			return elevation, true
+		}
+	}
+	return 0, false
+}
+
+// Load loads the SRTM .hgt tiles and GeoTIFF files of a directory, other files are ignored.
+func Load(directory string) (*Model, error) {
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	model := &Model{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(directory, entry.Name())
+
+		var grid *Grid
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".hgt":
+			grid, err = LoadHGT(path)
+		case ".tif", ".tiff":
+			grid, err = LoadGeoTIFF(path)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", path, err)
+		}
+		model.Add(grid)
+	}
+
+	return model, nil
+}
@@ -0,0 +1,201 @@
+package terrain
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeHGT writes an SRTM tile of 3 arc seconds in which the elevation rises by a meter per sample towards the east
+func writeHGT(t *testing.T, directory, name string) {
+	const size = 1201
+	data := make([]byte, size*size*2)
+	for row := 0; row < size; row++ {
+		for column := 0; column < size; column++ {
+			binary.BigEndian.PutUint16(data[(row*size+column)*2:], uint16(int16(column)))
+		}
+	}
+	// A void in the middle of the tile
+	binary.BigEndian.PutUint16(data[(600*size+600)*2:], 0x8000)
+
+	if err := os.WriteFile(filepath.Join(directory, name), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type geoTIFFEntry struct {
+	tag, kind uint16
+	values    []any
+}
+
+// writeGeoTIFF writes a little-endian GeoTIFF with a single strip of 16-bit samples
+func writeGeoTIFF(t *testing.T, path string, width, height int, samples []int16, deflate bool, extra ...geoTIFFEntry) {
+	var strip bytes.Buffer
+	for row := 0; row < height; row++ {
+		var previous int16
+		for column := 0; column < width; column++ {
+			value := samples[row*width+column]
+			if deflate {
+				// Horizontal differencing, which GDAL uses together with deflate
+				value, previous = value-previous, value
+			}
+			binary.Write(&strip, binary.LittleEndian, value)
+		}
+	}
+	data := strip.Bytes()
+	compression, predictor := 1, 1
+	if deflate {
+		var compressed bytes.Buffer
+		writer := zlib.NewWriter(&compressed)
+		writer.Write(data)
+		writer.Close()
+		data, compression, predictor = compressed.Bytes(), 8, 2
+	}
+
+	entries := append([]geoTIFFEntry{
+		{tagImageWidth, 3, []any{uint16(width)}},
+		{tagImageLength, 3, []any{uint16(height)}},
+		{tagBitsPerSample, 3, []any{uint16(16)}},
+		{tagCompression, 3, []any{uint16(compression)}},
+		{tagStripOffsets, 4, []any{uint32(0)}},
+		{tagSamplesPerPixel, 3, []any{uint16(1)}},
+		{tagRowsPerStrip, 3, []any{uint16(height)}},
+		{tagStripByteCounts, 4, []any{uint32(len(data))}},
+		{tagPredictor, 3, []any{uint16(predictor)}},
+		{tagSampleFormat, 3, []any{uint16(2)}},
+	}, extra...)
+
+	// The header and the directory are followed by the values that don't fit in the entries and the strip
+	var values bytes.Buffer
+	valuesOffset := 8 + 2 + len(entries)*12 + 4
+	var directory bytes.Buffer
+	binary.Write(&directory, binary.LittleEndian, uint16(len(entries)))
+	stripOffsetEntry := 0
+	for i, entry := range entries {
+		var value bytes.Buffer
+		for _, v := range entry.values {
+			binary.Write(&value, binary.LittleEndian, v)
+		}
+		binary.Write(&directory, binary.LittleEndian, entry.tag)
+		binary.Write(&directory, binary.LittleEndian, entry.kind)
+		binary.Write(&directory, binary.LittleEndian, uint32(value.Len()/fieldSizes[entry.kind]))
+		if entry.tag == tagStripOffsets {
+			stripOffsetEntry = i
+		}
+		if value.Len() > 4 {
+			binary.Write(&directory, binary.LittleEndian, uint32(valuesOffset+values.Len()))
+			values.Write(value.Bytes())
+		} else {
+			directory.Write(append(value.Bytes(), make([]byte, 4-value.Len())...))
+		}
+	}
+	binary.Write(&directory, binary.LittleEndian, uint32(0))
+
+	file := append([]byte{'I', 'I', 42, 0, 8, 0, 0, 0}, directory.Bytes()...)
+	file = append(file, values.Bytes()...)
+	binary.LittleEndian.PutUint32(file[8+2+stripOffsetEntry*12+8:], uint32(len(file)))
+	file = append(file, data...)
+
+	if err := os.WriteFile(path, file, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// geoTIFFEntries returns the georeferencing of a grid with its top left corner at 51N 5E and the GDAL no data value
+func geoTIFFEntries(step float64, noData string) []geoTIFFEntry {
+	return []geoTIFFEntry{
+		{tagModelPixelScale, 12, []any{step, step, 0.0}},
+		{tagModelTiepoint, 12, []any{0.0, 0.0, 0.0, 5.0, 51.0, 0.0}},
+		{tagGeoKeyDirectory, 3, []any{uint16(1), uint16(1), uint16(0), uint16(2),
+			uint16(keyModelType), uint16(0), uint16(1), uint16(modelTypeGeographic),
+			uint16(keyRasterType), uint16(0), uint16(1), uint16(1)}},
+		{tagGDALNoData, 2, []any{[]byte(noData + "\x00")}},
+	}
+}
+
+func TestLoadHGT(t *testing.T) {
+	directory := t.TempDir()
+	writeHGT(t, directory, "N51E005.hgt")
+
+	model, err := Load(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if model.Len() != 1 {
+		t.Fatalf("expected 1 tile, got %d", model.Len())
+	}
+
+	tests := []struct {
+		lat, lon, expected float64
+	}{
+		{51.5, 5.0, 0},
+		{51.5, 6.0, 1200},
+		// Halfway between the samples of 3 arc seconds
+		{51.5, 5.25 + 1.5/3600, 300.5},
+		// Next to the void, only the samples with data are used
+		{51.5 - 0.5/1200, 5.5, 600},
+	}
+	for _, test := range tests {
+		elevation, ok := model.Elevation(test.lat, test.lon)
+		if !ok || math.Abs(elevation-test.expected) > 0.01 {
+			t.Errorf("expected %.2fm at %.4f, %.4f, got %.2fm", test.expected, test.lat, test.lon, elevation)
+		}
+	}
+
+	if _, ok := model.Elevation(52.5, 5.5); ok {
+		t.Error("expected no elevation outside of the tile")
+	}
+}
+
+func TestLoadGeoTIFF(t *testing.T) {
+	for _, deflate := range []bool{false, true} {
+		// 4 by 3 pixels of 0.25 degrees, the bottom right pixel has no data
+		samples := []int16{
+			100, 110, 120, 130,
+			200, 210, 220, 230,
+			300, 310, 320, -9999,
+		}
+		path := filepath.Join(t.TempDir(), "dem.tif")
+		writeGeoTIFF(t, path, 4, 3, samples, deflate, geoTIFFEntries(0.25, "-9999")...)
+
+		grid, err := LoadGeoTIFF(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The tie point is the corner of the top left pixel, so its center is an eighth of a degree further
+		tests := []struct {
+			lat, lon, expected float64
+		}{
+			{50.875, 5.125, 100},
+			{50.625, 5.375, 210},
+			{50.75, 5.25, 155},
+			{50.375, 5.625, 320},
+		}
+		for _, test := range tests {
+			elevation, ok := grid.Elevation(test.lat, test.lon)
+			if !ok || math.Abs(elevation-test.expected) > 0.01 {
+				t.Errorf("expected %.0fm at %.3f, %.3f with deflate %v, got %.2fm", test.expected, test.lat, test.lon, deflate, elevation)
+			}
+		}
+
+		if _, ok := grid.Elevation(50.375, 5.875); ok {
+			t.Error("expected no elevation for a pixel without data")
+		}
+	}
+}
+
+func TestProjectedGeoTIFFIsRejected(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lambert.tif")
+	entries := geoTIFFEntries(25, "")
+	entries[2].values[7] = uint16(1)
+	writeGeoTIFF(t, path, 2, 2, []int16{1, 2, 3, 4}, false, entries...)
+
+	if _, err := LoadGeoTIFF(path); err == nil {
+		t.Error("expected an error for projected coordinates")
+	}
+}
@@ -0,0 +1,199 @@
+package thumbnail
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache stores thumbnails of aircraft on disk. Once the thumbnails exceed the size limit of the cache,
+// the least recently used thumbnails are removed.
+type Cache struct {
+	dir      string
+	maxBytes int64
+
+	mu    sync.Mutex
+	files map[string]file
+	size  int64
+}
+
+// file is a single thumbnail in the cache, named after its key and the extension of its content type
+type file struct {
+	name string
+	size int64
+	used time.Time
+}
+
+// extensions of the content types that are accepted as thumbnails
+var extensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// New creates the cache in the directory and indexes the thumbnails that are already in it.
+func New(dir string, maxBytes int64) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Cache{dir: dir, maxBytes: maxBytes, files: make(map[string]file)}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		key := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		c.files[key] = file{name: entry.Name(), size: info.Size(), used: info.ModTime()}
+		c.size += info.Size()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c, c.evict()
+}
+
+// Get returns the name of the file of the thumbnail with the key and marks it as recently used.
+func (c *Cache) Get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	f, found := c.files[key]
+	if !found {
+		return "", false
+	}
+
+	f.used = time.Now()
+	c.files[key] = f
+	// The modification time is used to restore the order of use after a restart
+	os.Chtimes(filepath.Join(c.dir, f.name), f.used, f.used)
+	return f.name, true
+}
+
+// Store writes the thumbnail with the key to disk and returns the name of its file.
+// Only images are accepted, the file extension is based on the content of the image.
+func (c *Cache) Store(key string, data []byte) (string, error) {
+	if !validKey(key) {
+		return "", fmt.Errorf("invalid thumbnail key %q", key)
+	}
+
+	extension, ok := extensions[http.DetectContentType(data)]
+	if !ok {
+		return "", fmt.Errorf("thumbnail %s is not an image", key)
+	}
+
+	if int64(len(data)) > c.maxBytes {
+		return "", fmt.Errorf("thumbnail %s of %d bytes exceeds the size of the cache", key, len(data))
+	}
+
+	name := key + extension
+	temp, err := os.CreateTemp(c.dir, ".thumbnail-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(temp.Name())
+
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		return "", err
+	}
+	if err := temp.Close(); err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := os.Rename(temp.Name(), filepath.Join(c.dir, name)); err != nil {
+		return "", err
+	}
+
+	if previous, found := c.files[key]; found {
+		c.size -= previous.size
+		if previous.name != name {
+			os.Remove(filepath.Join(c.dir, previous.name))
+		}
+	}
+
+	c.files[key] = file{name: name, size: int64(len(data)), used: time.Now()}
+	c.size += int64(len(data))
+	return name, c.evict()
+}
+
+// Path returns the path on disk of the file with the name, if it is in the cache.
+func (c *Cache) Path(name string) (string, bool) {
+	key := strings.TrimSuffix(name, filepath.Ext(name))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	f, found := c.files[key]
+	if !found || f.name != name {
+		return "", false
+	}
+	return filepath.Join(c.dir, name), true
+}
+
+// Size returns the total size of the thumbnails in bytes.
+func (c *Cache) Size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.size
+}
+
+// evict removes the least recently used thumbnails until the cache is within its size limit.
+// The caller must hold the lock.
+func (c *Cache) evict() error {
+	if c.size <= c.maxBytes {
+		return nil
+	}
+
+	keys := make([]string, 0, len(c.files))
+	for key := range c.files {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return c.files[keys[i]].used.Before(c.files[keys[j]].used)
+	})
+
+	for _, key := range keys {
+		if c.size <= c.maxBytes {
+			break
+		}
+
+		f := c.files[key]
+		if err := os.Remove(filepath.Join(c.dir, f.name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		delete(c.files, key)
+		c.size -= f.size
+	}
+
+	return nil
+}
+
+// validKey checks that the key can safely be used as the name of a file
+func validKey(key string) bool {
+	if key == "" {
+		return false
+	}
+
+	for _, c := range key {
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') && c != '-' && c != '_' {
+			return false
+		}
+	}
+	return true
+}
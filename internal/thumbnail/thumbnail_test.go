@@ -0,0 +1,81 @@
+package thumbnail
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// jpeg returns data of the given size that is detected as a JPEG image
+func jpeg(size int) []byte {
+	return append([]byte("\xff\xd8\xff\xe0"), bytes.Repeat([]byte{0}, size-4)...)
+}
+
+func TestStoreAndGet(t *testing.T) {
+	cache, err := New(t.TempDir(), 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name, err := cache.Store("44c1e5", jpeg(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "44c1e5.jpg" {
+		t.Errorf("expected the thumbnail to be stored as 44c1e5.jpg, got %s", name)
+	}
+
+	if name, found := cache.Get("44c1e5"); !found || name != "44c1e5.jpg" {
+		t.Errorf("expected to find 44c1e5.jpg, got %q", name)
+	}
+
+	if _, found := cache.Path("44c1e5.png"); found {
+		t.Error("expected no path for a file that isn't in the cache")
+	}
+
+	if _, err := cache.Store("../44c1e5", jpeg(100)); err == nil {
+		t.Error("expected an error for a key that isn't a valid file name")
+	}
+
+	if _, err := cache.Store("44c1e6", []byte("<html></html>")); err == nil {
+		t.Error("expected an error for content that isn't an image")
+	}
+}
+
+func TestEvictsLeastRecentlyUsed(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := New(dir, 250)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cache.Store("a", jpeg(100))
+	cache.Store("b", jpeg(100))
+	// Make sure that a is used after b
+	time.Sleep(10 * time.Millisecond)
+	cache.Get("a")
+	cache.Store("c", jpeg(100))
+
+	if _, found := cache.Get("b"); found {
+		t.Error("expected the least recently used thumbnail to be evicted")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "b.jpg")); !os.IsNotExist(err) {
+		t.Error("expected the evicted thumbnail to be removed from disk")
+	}
+	if cache.Size() != 200 {
+		t.Errorf("expected a size of 200 bytes, got %d", cache.Size())
+	}
+
+	// The thumbnails are indexed again after a restart
+	cache, err = New(dir, 250)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, found := cache.Get(key); !found {
+			t.Errorf("expected %s to be found after a restart", key)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package upstream
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"jetspotter/internal/configuration"
+)
+
+// defaultBackoff is how long an upstream is paused after a rate limit response without a Retry-After header
+const defaultBackoff = 30 * time.Second
+
+// Upstream is an external API that jetspotter talks to.
+// Requests are rate limited with a token bucket and every request gets a timeout.
+// When the upstream responds with a rate limit, no requests are sent until the Retry-After period has passed.
+type Upstream struct {
+	Name string
+
+	client  *http.Client
+	timeout time.Duration
+
+	mu           sync.Mutex
+	rate         float64
+	capacity     float64
+	tokens       float64
+	last         time.Time
+	blockedUntil time.Time
+}
+
+// Known upstreams
+var (
+	ADSB          = New("adsb", 0, 10*time.Second)
+	Planespotters = New("planespotters", 30, 10*time.Second)
+	ADSBDB        = New("adsbdb", 60, 10*time.Second)
+	OpenMeteo     = New("open-meteo", 10, 10*time.Second)
+	// aviationweather.gov asks clients to stay below 100 requests per minute
+	AviationWeather = New("aviationweather", 10, 10*time.Second)
+	// Images and thumbnails that are downloaded from the URLs returned by the image providers
+	Images = New("images", 0, 10*time.Second)
+)
+
+// New creates an upstream that allows requestsPerMinute requests, 0 disables the rate limit.
+// Every request is cancelled after timeout.
+func New(name string, requestsPerMinute int, timeout time.Duration) *Upstream {
+	u := &Upstream{
+		Name:    name,
+		client:  &http.Client{},
+		timeout: timeout,
+	}
+	u.setRate(requestsPerMinute)
+	return u
+}
+
+// Configure applies the rate limits and timeout of the configuration to the known upstreams.
+func Configure(config configuration.Config) {
+	timeout := time.Duration(config.UpstreamTimeoutSeconds) * time.Second
+	for u, requestsPerMinute := range map[*Upstream]int{
+		ADSB:            0,
+		Planespotters:   config.PlanespottersRequestsPerMinute,
+		ADSBDB:          config.ADSBDBRequestsPerMinute,
+		OpenMeteo:       config.OpenMeteoRequestsPerMinute,
+		AviationWeather: config.AviationWeatherRequestsPerMinute,
+		Images:          0,
+	} {
+		u.mu.Lock()
+		u.timeout = timeout
+		u.setRate(requestsPerMinute)
+		u.mu.Unlock()
+	}
+}
+
+// setRate configures the token bucket, the caller must hold the lock.
+func (u *Upstream) setRate(requestsPerMinute int) {
+	u.rate = float64(requestsPerMinute) / 60
+	// Allow bursts of up to a tenth of a minute worth of requests
+	u.capacity = float64(max(1, requestsPerMinute/10))
+	u.tokens = u.capacity
+	u.last = time.Now()
+}
+
+// Wait blocks until a request can be sent to the upstream or the context is done.
+func (u *Upstream) Wait(ctx context.Context) error {
+	for {
+		delay := u.reserve()
+		if delay <= 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("waiting for %s rate limit: %w", u.Name, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// reserve takes a token from the bucket, if no token is available it returns how long to wait for one.
+func (u *Upstream) reserve() time.Duration {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	now := time.Now()
+	if now.Before(u.blockedUntil) {
+		return u.blockedUntil.Sub(now)
+	}
+
+	if u.rate <= 0 {
+		return 0
+	}
+
+	u.tokens = min(u.capacity, u.tokens+now.Sub(u.last).Seconds()*u.rate)
+	u.last = now
+	if u.tokens >= 1 {
+		u.tokens--
+		return 0
+	}
+
+	return time.Duration((1 - u.tokens) / u.rate * float64(time.Second))
+}
+
+// Backoff pauses all requests to the upstream for the given duration.
+func (u *Upstream) Backoff(d time.Duration) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	until := time.Now().Add(d)
+	if until.After(u.blockedUntil) {
+		u.blockedUntil = until
+		log.Printf("%s: rate limited, pausing requests for %s", u.Name, d.Round(time.Second))
+	}
+}
+
+// Do sends the request once the rate limit allows it. The request is cancelled when the context is done
+// or when the timeout of the upstream is reached, which includes reading the body of the response.
+// A rate limit response pauses the upstream for the period in its Retry-After header.
+func (u *Upstream) Do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	if err := u.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	u.mu.Lock()
+	timeout := u.timeout
+	u.mu.Unlock()
+
+	cancel := context.CancelFunc(func() {})
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+
+	res, err := u.client.Do(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	if res.StatusCode == http.StatusTooManyRequests || (res.StatusCode == http.StatusServiceUnavailable && res.Header.Get("Retry-After") != "") {
+		u.Backoff(RetryAfter(res.Header.Get("Retry-After"), defaultBackoff))
+	}
+
+	res.Body = &cancelOnClose{ReadCloser: res.Body, cancel: cancel}
+	return res, nil
+}
+
+// Get sends a GET request to the URL, see Do.
+func (u *Upstream) Get(ctx context.Context, URL string, headers map[string]string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	return u.Do(ctx, req)
+}
+
+// RetryAfter parses the value of a Retry-After header, which is either a number of seconds or an HTTP date.
+// The fallback is returned if the value is empty or invalid.
+func RetryAfter(value string, fallback time.Duration) time.Duration {
+	if value == "" {
+		return fallback
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if d := time.Until(date); d > 0 {
+			return d
+		}
+		return 0
+	}
+
+	return fallback
+}
+
+// cancelOnClose cancels the context of a request once its response body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
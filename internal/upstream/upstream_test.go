@@ -0,0 +1,68 @@
+package upstream
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	if d := RetryAfter("120", time.Second); d != 120*time.Second {
+		t.Fatalf("expected 120s, got %s", d)
+	}
+
+	if d := RetryAfter("", time.Second); d != time.Second {
+		t.Fatalf("expected fallback of 1s, got %s", d)
+	}
+
+	if d := RetryAfter("soon", time.Second); d != time.Second {
+		t.Fatalf("expected fallback of 1s for an invalid value, got %s", d)
+	}
+
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if d := RetryAfter(date, time.Second); d < 55*time.Second || d > time.Minute {
+		t.Fatalf("expected about a minute for an HTTP date, got %s", d)
+	}
+}
+
+func TestWaitIsRateLimited(t *testing.T) {
+	u := New("test", 6, time.Second)
+
+	// The bucket holds a single token, the second request has to wait for a new one
+	if err := u.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := u.Wait(ctx); err == nil {
+		t.Fatal("expected the second request to be rate limited")
+	}
+}
+
+func TestTooManyRequestsPausesUpstream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	u := New("test", 0, time.Second)
+	res, err := u.Get(context.Background(), server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected status 429, got %d", res.StatusCode)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := u.Get(ctx, server.URL, nil); err == nil {
+		t.Fatal("expected requests to be paused after a 429 response")
+	}
+}
@@ -0,0 +1,37 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/jftuga/geodist"
+)
+
+// File provides the weather forecast from a JSON file, it returns the same forecast for every location.
+// The file is read on every call so that it can be updated while jetspotter is running.
+type File struct {
+	Path string
+}
+
+// NewFile returns a provider that reads the forecast from the JSON file at path.
+func NewFile(path string) *File {
+	return &File{Path: path}
+}
+
+// Forecast reads the forecast from the file.
+func (f *File) Forecast(ctx context.Context, location geodist.Coord) (*Forecast, error) {
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var forecast Forecast
+	err = json.Unmarshal(data, &forecast)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse weather file %s: %w", f.Path, err)
+	}
+
+	return &forecast, nil
+}
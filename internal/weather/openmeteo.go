@@ -0,0 +1,147 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"jetspotter/internal/upstream"
+
+	"github.com/jftuga/geodist"
+)
+
+const (
+	weatherBaseURL = "https://api.open-meteo.com/v1/forecast"
+)
+
+// Pressure levels in hPa of the temperature and humidity aloft, from about 18000ft up to 39000ft
+var pressureLevels = []int{500, 400, 300, 250, 200}
+
+// openMeteoResponse represents the hourly forecast returned by the open-meteo.com API
+type openMeteoResponse struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Hourly    struct {
+		Time           []int64    `json:"time"`
+		CloudCoverLow  []*int     `json:"cloud_cover_low"`
+		CloudCoverMid  []*int     `json:"cloud_cover_mid"`
+		CloudCoverHigh []*int     `json:"cloud_cover_high"`
+		Visibility     []*float64 `json:"visibility"`
+		Precipitation  []*float64 `json:"precipitation"`
+		Temperature    []*float64 `json:"temperature_2m"`
+		DewPoint       []*float64 `json:"dew_point_2m"`
+	} `json:"hourly"`
+}
+
+// OpenMeteo provides the weather forecast of the open-meteo.com API
+type OpenMeteo struct {
+	BaseURL string
+}
+
+// NewOpenMeteo returns a provider that uses the public open-meteo.com API.
+func NewOpenMeteo() *OpenMeteo {
+	return &OpenMeteo{BaseURL: weatherBaseURL}
+}
+
+// Forecast gets the hourly forecast of today and tomorrow in UTC for the location.
+func (o *OpenMeteo) Forecast(ctx context.Context, location geodist.Coord) (*Forecast, error) {
+	query := url.Values{}
+	query.Set("latitude", fmt.Sprintf("%.6f", location.Lat))
+	query.Set("longitude", fmt.Sprintf("%.6f", location.Lon))
+	variables := []string{"cloud_cover_low", "cloud_cover_mid", "cloud_cover_high", "visibility", "precipitation", "temperature_2m", "dew_point_2m"}
+	for _, pressure := range pressureLevels {
+		variables = append(variables, fmt.Sprintf("temperature_%dhPa", pressure), fmt.Sprintf("relative_humidity_%dhPa", pressure))
+	}
+	query.Set("hourly", strings.Join(variables, ","))
+	query.Set("timezone", "GMT")
+	query.Set("timeformat", "unixtime")
+	query.Set("forecast_days", "2")
+
+	response, err := upstream.OpenMeteo.Get(ctx, o.BaseURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != 200 {
+		return nil, fmt.Errorf("Received status code %v", response.StatusCode)
+	}
+
+	var data openMeteoResponse
+	err = json.Unmarshal(body, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	// The names of the pressure level variables contain the pressure, so they are decoded separately
+	var levelData struct {
+		Hourly map[string]json.RawMessage `json:"hourly"`
+	}
+	err = json.Unmarshal(body, &levelData)
+	if err != nil {
+		return nil, err
+	}
+	levelValues := func(name string) []*float64 {
+		var values []*float64
+		json.Unmarshal(levelData.Hourly[name], &values)
+		return values
+	}
+	temperatures := make([][]*float64, len(pressureLevels))
+	humidities := make([][]*float64, len(pressureLevels))
+	for i, pressure := range pressureLevels {
+		temperatures[i] = levelValues(fmt.Sprintf("temperature_%dhPa", pressure))
+		humidities[i] = levelValues(fmt.Sprintf("relative_humidity_%dhPa", pressure))
+	}
+
+	forecast := &Forecast{
+		Latitude:  data.Latitude,
+		Longitude: data.Longitude,
+	}
+
+	hourly := data.Hourly
+	for i, timestamp := range hourly.Time {
+		conditions := Conditions{
+			Time:                     time.Unix(timestamp, 0).UTC(),
+			CloudCoverLow:            valueAt(hourly.CloudCoverLow, i),
+			CloudCoverMid:            valueAt(hourly.CloudCoverMid, i),
+			CloudCoverHigh:           valueAt(hourly.CloudCoverHigh, i),
+			VisibilityMeters:         valueAt(hourly.Visibility, i),
+			PrecipitationMillimeters: valueAt(hourly.Precipitation, i),
+			TemperatureCelsius:       valueAt(hourly.Temperature, i),
+			DewPointCelsius:          valueAt(hourly.DewPoint, i),
+		}
+		if i < len(hourly.Temperature) && i < len(hourly.DewPoint) && hourly.Temperature[i] != nil && hourly.DewPoint[i] != nil {
+			conditions.CloudBaseFeet = estimateCloudBase(conditions.TemperatureCelsius, conditions.DewPointCelsius)
+		}
+		for level, pressure := range pressureLevels {
+			temperature, humidity := temperatures[level], humidities[level]
+			if i < len(temperature) && i < len(humidity) && temperature[i] != nil && humidity[i] != nil {
+				conditions.Levels = append(conditions.Levels, PressureLevel{
+					PressureHectopascals: float64(pressure),
+					TemperatureCelsius:   *temperature[i],
+					RelativeHumidity:     *humidity[i],
+				})
+			}
+		}
+		forecast.Hours = append(forecast.Hours, conditions)
+	}
+
+	return forecast, nil
+}
+
+// valueAt returns the value at index i, or the zero value if it is missing
+func valueAt[T any](values []*T, i int) (value T) {
+	if i < len(values) && values[i] != nil {
+		return *values[i]
+	}
+	return value
+}
@@ -1,77 +1,236 @@
 package weather
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
+	"math"
+	"sync"
+	"time"
+
+	"jetspotter/internal/metrics"
 
 	"github.com/jftuga/geodist"
 )
 
-// HourlyUnits represents the cloud cover units per hour
-type HourlyUnits struct {
-	Time           string `json:"time"`
-	CloudcoverLow  string `json:"cloudcover_low"`
-	CloudcoverMid  string `json:"cloudcover_mid"`
-	CloudcoverHigh string `json:"cloudcover_high"`
+// Conditions represents the weather during a single forecast hour
+type Conditions struct {
+	// Start of the forecast hour in UTC, the zero time means that the conditions apply to every hour
+	Time time.Time `json:"time"`
+	// Percentage of cloud coverage from 0m up to 3000m
+	CloudCoverLow int `json:"cloudCoverLow"`
+	// Percentage of cloud coverage from 3000m up to 8000m
+	CloudCoverMid int `json:"cloudCoverMid"`
+	// Percentage of cloud coverage above 8000m
+	CloudCoverHigh int `json:"cloudCoverHigh"`
+	// Visibility in meters
+	VisibilityMeters float64 `json:"visibilityMeters"`
+	// Estimated height of the cloud base above the ground in feet, 0 if unknown
+	CloudBaseFeet float64 `json:"cloudBaseFeet"`
+	// Precipitation during the hour in millimeters
+	PrecipitationMillimeters float64 `json:"precipitationMillimeters"`
+	// Temperature 2m above the ground in degrees Celsius
+	TemperatureCelsius float64 `json:"temperatureCelsius"`
+	// Dew point 2m above the ground in degrees Celsius
+	DewPointCelsius float64 `json:"dewPointCelsius"`
+	// Temperature and humidity at the pressure levels where jets cruise, from high to low pressure
+	Levels []PressureLevel `json:"levels,omitempty"`
 }
 
-// HourlyData represents the cloud cover data per hour
-type HourlyData struct {
-	Time           []string `json:"time"`
-	CloudcoverLow  []int    `json:"cloudcover_low"`
-	CloudcoverMid  []int    `json:"cloudcover_mid"`
-	CloudcoverHigh []int    `json:"cloudcover_high"`
+// PressureLevel is the temperature and humidity at a pressure level of the atmosphere
+type PressureLevel struct {
+	// Pressure of the level in hPa, for example 250 hPa is at about 34000ft
+	PressureHectopascals float64 `json:"pressureHectopascals"`
+	// Temperature in degrees Celsius
+	TemperatureCelsius float64 `json:"temperatureCelsius"`
+	// Relative humidity with respect to water in percent
+	RelativeHumidity float64 `json:"relativeHumidity"`
 }
 
-// Data represents the data of the weather
-type Data struct {
-	Latitude         float64     `json:"latitude"`
-	Longitude        float64     `json:"longitude"`
-	GenerationTimeMs float64     `json:"generationtime_ms"`
-	UtcOffsetSeconds int         `json:"utc_offset_seconds"`
-	Timezone         string      `json:"timezone"`
-	TimezoneAbbrev   string      `json:"timezone_abbreviation"`
-	Elevation        float64     `json:"elevation"`
-	HourlyUnits      HourlyUnits `json:"hourly_units"`
-	Hourly           HourlyData  `json:"hourly"`
+// At returns the temperature and humidity at the pressure, interpolated between the levels around it.
+// False if the pressure isn't between two levels.
+func (c Conditions) At(pressureHectopascals float64) (PressureLevel, bool) {
+	for i := 1; i < len(c.Levels); i++ {
+		high, low := c.Levels[i-1], c.Levels[i]
+		if pressureHectopascals > high.PressureHectopascals || pressureHectopascals < low.PressureHectopascals {
+			continue
+		}
+
+		// Temperature and humidity change about linearly with the logarithm of the pressure, like the altitude does
+		fraction := math.Log(high.PressureHectopascals/pressureHectopascals) / math.Log(high.PressureHectopascals/low.PressureHectopascals)
+		return PressureLevel{
+			PressureHectopascals: pressureHectopascals,
+			TemperatureCelsius:   high.TemperatureCelsius + (low.TemperatureCelsius-high.TemperatureCelsius)*fraction,
+			RelativeHumidity:     high.RelativeHumidity + (low.RelativeHumidity-high.RelativeHumidity)*fraction,
+		}, true
+	}
+	return PressureLevel{}, false
 }
 
-const (
-	weatherBaseURL = "https://api.open-meteo.com/v1/forecast?"
-)
+// Forecast represents the hourly weather forecast for a location
+type Forecast struct {
+	Latitude  float64      `json:"latitude"`
+	Longitude float64      `json:"longitude"`
+	Hours     []Conditions `json:"hours"`
+}
 
-// GetCloudForecast gets the cloud forecast for every hour of the current day based on a specified location
-func GetCloudForecast(location geodist.Coord) (weather *Data, err error) {
+// At returns the conditions of the forecast hour that contains the given time.
+func (f *Forecast) At(t time.Time) (Conditions, bool) {
+	hour := t.UTC().Truncate(time.Hour)
+	for _, conditions := range f.Hours {
+		if conditions.Time.IsZero() || conditions.Time.UTC().Equal(hour) {
+			return conditions, true
+		}
+	}
+	return Conditions{}, false
+}
 
-	weatherCloudURL := weatherBaseURL + fmt.Sprintf("latitude=%.6f&longitude=%.6f&hourly=cloudcover_low,cloudcover_mid,cloudcover_high&windspeed_unit=kn&timezone=GMT&forecast_days=1",
-		location.Lat, location.Lon)
+// Provider provides the weather forecast for a location
+type Provider interface {
+	Forecast(ctx context.Context, location geodist.Coord) (*Forecast, error)
+}
 
-	request, err := http.NewRequest(http.MethodGet, weatherCloudURL, nil)
-	if err != nil {
-		return nil, err
+// GridCell returns the center of the grid cell of the given size in degrees that contains the location.
+// A size of 0 returns the location itself.
+func GridCell(location geodist.Coord, degrees float64) geodist.Coord {
+	if degrees <= 0 {
+		return location
 	}
+	return geodist.Coord{
+		Lat: (math.Floor(location.Lat/degrees) + 0.5) * degrees,
+		Lon: (math.Floor(location.Lon/degrees) + 0.5) * degrees,
+	}
+}
 
-	response, err := http.DefaultClient.Do(request)
-	if err != nil {
-		return nil, err
+// ErrPending is returned while the forecast of a grid cell is being looked up for the first time
+var ErrPending = errors.New("weather forecast is being looked up")
+
+// fetchTimeout is how long a lookup may take, including the wait for the rate limit of the provider
+const fetchTimeout = time.Minute
+
+// Cached is a provider that looks up the weather per grid cell in the background and refreshes the forecast
+// when the forecast hour changes. Until the new forecast is in, the previous one is used, it covers the next hours too.
+type Cached struct {
+	provider    Provider
+	gridDegrees float64
+	negativeTTL time.Duration
+	now         func() time.Time
+
+	mu     sync.Mutex
+	cells  map[string]*cachedCell
+	purged time.Time
+}
+
+// cachedCell is the forecast of a grid cell
+type cachedCell struct {
+	forecast *Forecast
+	// Forecast hour in which the forecast was looked up
+	hour time.Time
+	// Error of the last lookup, it isn't looked up again until retryAfter
+	err        error
+	retryAfter time.Time
+	fetching   bool
+	// Last time the forecast was used, cells that aren't used anymore are removed
+	used time.Time
+}
+
+// NewCached wraps the provider so that it is called at most once per grid cell and forecast hour.
+// Failed lookups are retried after negativeTTL.
+func NewCached(provider Provider, gridDegrees float64, negativeTTL time.Duration) *Cached {
+	return &Cached{
+		provider:    provider,
+		gridDegrees: gridDegrees,
+		negativeTTL: negativeTTL,
+		now:         time.Now,
+		cells:       make(map[string]*cachedCell),
 	}
+}
 
-	defer response.Body.Close()
-	body, err := io.ReadAll(response.Body)
-	if err != nil {
-		return nil, err
+// Forecast returns the forecast of the grid cell that contains the location. It never waits for the provider,
+// ErrPending is returned until the forecast of a new grid cell has been looked up.
+func (c *Cached) Forecast(ctx context.Context, location geodist.Coord) (*Forecast, error) {
+	location = GridCell(location, c.gridDegrees)
+	key := fmt.Sprintf("%.4f,%.4f", location.Lat, location.Lon)
+	now := c.now()
+	hour := now.UTC().Truncate(time.Hour)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.purge(now, hour)
+
+	cell, found := c.cells[key]
+	if !found {
+		cell = &cachedCell{}
+		c.cells[key] = cell
+		metrics.SetCacheEntries("weather", len(c.cells))
 	}
+	cell.used = now
 
-	if response.StatusCode != 200 {
-		return nil, fmt.Errorf("Received status code %v", response.StatusCode)
+	outdated := cell.forecast == nil || cell.hour.Before(hour)
+	if outdated && !cell.fetching && !now.Before(cell.retryAfter) {
+		cell.fetching = true
+		go c.fetch(cell, location, hour)
 	}
 
-	err = json.Unmarshal(body, &weather)
+	switch {
+	case cell.forecast != nil:
+		metrics.IncrementCacheRequests("weather", "hit")
+		return cell.forecast, nil
+	case cell.err != nil:
+		metrics.IncrementCacheRequests("weather", "negative_hit")
+		return nil, cell.err
+	default:
+		metrics.IncrementCacheRequests("weather", "miss")
+		return nil, ErrPending
+	}
+}
+
+// fetch looks up the forecast of a grid cell, a failed lookup keeps the previous forecast
+func (c *Cached) fetch(cell *cachedCell, location geodist.Coord, hour time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+	forecast, err := c.provider.Forecast(ctx, location)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cell.fetching = false
+	cell.err = err
 	if err != nil {
-		return nil, err
+		cell.retryAfter = c.now().Add(c.negativeTTL)
+		return
 	}
+	cell.forecast = forecast
+	cell.hour = hour
+}
+
+// purge removes the grid cells that haven't been used for an hour, once per forecast hour.
+// The caller must hold the lock.
+func (c *Cached) purge(now, hour time.Time) {
+	if !c.purged.Before(hour) {
+		return
+	}
+	c.purged = hour
+	for key, cell := range c.cells {
+		if now.Sub(cell.used) > time.Hour && !cell.fetching {
+			delete(c.cells, key)
+		}
+	}
+	metrics.SetCacheEntries("weather", len(c.cells))
+}
+
+// Len returns the number of grid cells that are cached
+func (c *Cached) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.cells)
+}
 
-	return weather, nil
+// estimateCloudBase estimates the height of the cloud base above the ground in feet from the spread between
+// the temperature and the dew point, the base of cumulus clouds rises about 400 feet per degree Celsius of spread.
+func estimateCloudBase(temperature, dewPoint float64) float64 {
+	spread := temperature - dewPoint
+	if spread < 0 {
+		spread = 0
+	}
+	return math.Round(spread * 400)
 }
@@ -0,0 +1,220 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jftuga/geodist"
+)
+
+type countingProvider struct {
+	mu        sync.Mutex
+	calls     int
+	locations []geodist.Coord
+	err       error
+}
+
+func (c *countingProvider) Forecast(ctx context.Context, location geodist.Coord) (*Forecast, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+	c.locations = append(c.locations, location)
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &Forecast{Latitude: location.Lat, Longitude: location.Lon}, nil
+}
+
+func (c *countingProvider) Calls() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls
+}
+
+// waitForLookups waits until the lookups in the background are done
+func waitForLookups(t *testing.T, cached *Cached) {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		cached.mu.Lock()
+		fetching := false
+		for _, cell := range cached.cells {
+			fetching = fetching || cell.fetching
+		}
+		cached.mu.Unlock()
+		if !fetching {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("expected the lookups to be done")
+}
+
+func TestForecastAtUsesUTCHour(t *testing.T) {
+	forecast := Forecast{Hours: []Conditions{
+		{Time: time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC), CloudCoverLow: 9},
+		{Time: time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC), CloudCoverLow: 10},
+	}}
+
+	// 12:30 in UTC+2 is 10:30 UTC
+	local := time.Date(2024, 6, 1, 12, 30, 0, 0, time.FixedZone("CEST", 2*3600))
+	conditions, found := forecast.At(local)
+	if !found || conditions.CloudCoverLow != 10 {
+		t.Fatalf("expected the conditions of 10:00 UTC, got %+v", conditions)
+	}
+
+	if _, found := forecast.At(local.Add(6 * time.Hour)); found {
+		t.Fatal("expected no conditions outside of the forecast")
+	}
+}
+
+func TestGridCell(t *testing.T) {
+	cell := GridCell(geodist.Coord{Lat: 51.17, Lon: 5.46}, 0.5)
+	if cell.Lat != 51.25 || cell.Lon != 5.25 {
+		t.Fatalf("expected grid cell 51.25,5.25, got %v,%v", cell.Lat, cell.Lon)
+	}
+
+	location := geodist.Coord{Lat: 51.17, Lon: 5.46}
+	if GridCell(location, 0) != location {
+		t.Fatal("expected the location itself without a grid")
+	}
+}
+
+func TestCachedLooksUpOncePerCellAndHour(t *testing.T) {
+	provider := &countingProvider{}
+	cached := NewCached(provider, 0.5, time.Hour)
+	now := time.Date(2024, 6, 1, 10, 5, 0, 0, time.UTC)
+	cached.now = func() time.Time { return now }
+
+	// The first lookup of a grid cell doesn't wait for the provider
+	ctx := context.Background()
+	if _, err := cached.Forecast(ctx, geodist.Coord{Lat: 51.1, Lon: 5.1}); !errors.Is(err, ErrPending) {
+		t.Fatalf("expected the forecast to be pending, got %v", err)
+	}
+	waitForLookups(t, cached)
+	if forecast, err := cached.Forecast(ctx, geodist.Coord{Lat: 51.2, Lon: 5.2}); err != nil || forecast.Latitude != 51.25 {
+		t.Fatalf("expected the forecast of the grid cell, got %v", err)
+	}
+	if provider.Calls() != 1 {
+		t.Fatalf("expected a single lookup for the same grid cell, got %d", provider.Calls())
+	}
+
+	cached.Forecast(ctx, geodist.Coord{Lat: 51.6, Lon: 5.2})
+	waitForLookups(t, cached)
+	if provider.Calls() != 2 {
+		t.Fatalf("expected a lookup for another grid cell, got %d", provider.Calls())
+	}
+
+	// The previous forecast is used while the next forecast hour is looked up
+	now = now.Add(time.Hour)
+	if forecast, err := cached.Forecast(ctx, geodist.Coord{Lat: 51.1, Lon: 5.1}); err != nil || forecast == nil {
+		t.Fatalf("expected the previous forecast, got %v", err)
+	}
+	waitForLookups(t, cached)
+	if provider.Calls() != 3 {
+		t.Fatalf("expected a new lookup in the next forecast hour, got %d", provider.Calls())
+	}
+
+	if provider.locations[0].Lat != 51.25 || provider.locations[0].Lon != 5.25 {
+		t.Fatalf("expected the center of the grid cell to be looked up, got %v", provider.locations[0])
+	}
+}
+
+func TestCachedRemembersErrorsAndRemovesUnusedCells(t *testing.T) {
+	provider := &countingProvider{err: errors.New("unavailable")}
+	cached := NewCached(provider, 0.5, 30*time.Minute)
+	now := time.Date(2024, 6, 1, 10, 5, 0, 0, time.UTC)
+	cached.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	cached.Forecast(ctx, geodist.Coord{Lat: 51.1, Lon: 5.1})
+	waitForLookups(t, cached)
+	if _, err := cached.Forecast(ctx, geodist.Coord{Lat: 51.1, Lon: 5.1}); err == nil || errors.Is(err, ErrPending) {
+		t.Fatalf("expected the error of the lookup, got %v", err)
+	}
+	waitForLookups(t, cached)
+	if provider.Calls() != 1 {
+		t.Fatalf("expected the failed lookup not to be retried right away, got %d lookups", provider.Calls())
+	}
+
+	now = now.Add(31 * time.Minute)
+	cached.Forecast(ctx, geodist.Coord{Lat: 51.1, Lon: 5.1})
+	waitForLookups(t, cached)
+	if provider.Calls() != 2 {
+		t.Fatalf("expected the lookup to be retried, got %d lookups", provider.Calls())
+	}
+
+	// Aircraft move on, the grid cells they left are removed
+	now = now.Add(2 * time.Hour)
+	cached.Forecast(ctx, geodist.Coord{Lat: 52.1, Lon: 5.1})
+	waitForLookups(t, cached)
+	if cached.Len() != 1 {
+		t.Fatalf("expected only the grid cell in use to be kept, got %d", cached.Len())
+	}
+}
+
+func TestOpenMeteo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("timezone") != "GMT" {
+			t.Errorf("expected forecast in GMT, got %s", r.URL.Query().Get("timezone"))
+		}
+		w.Write([]byte(`{"latitude":51.2,"longitude":5.5,"hourly":{
+			"time":[1717236000,1717239600],
+			"cloud_cover_low":[10,20],"cloud_cover_mid":[30,40],"cloud_cover_high":[50,null],
+			"visibility":[24140,8000],"precipitation":[0,1.2],
+			"temperature_2m":[15,12],"dew_point_2m":[10,12],
+			"temperature_300hPa":[-44,-45],"relative_humidity_300hPa":[60,null],
+			"temperature_250hPa":[-52,-53],"relative_humidity_250hPa":[80,70]}}`))
+	}))
+	defer server.Close()
+
+	provider := &OpenMeteo{BaseURL: server.URL}
+	forecast, err := provider.Forecast(context.Background(), geodist.Coord{Lat: 51.2, Lon: 5.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conditions, found := forecast.At(time.Date(2024, 6, 1, 11, 30, 0, 0, time.UTC))
+	if !found {
+		t.Fatal("expected conditions for 11:00 UTC")
+	}
+
+	if conditions.CloudCoverLow != 20 || conditions.CloudCoverHigh != 0 || conditions.VisibilityMeters != 8000 ||
+		conditions.PrecipitationMillimeters != 1.2 || conditions.CloudBaseFeet != 0 {
+		t.Fatalf("unexpected conditions %+v", conditions)
+	}
+
+	// Levels without humidity are left out
+	if len(conditions.Levels) != 1 || conditions.Levels[0].PressureHectopascals != 250 {
+		t.Fatalf("expected only the 250 hPa level, got %+v", conditions.Levels)
+	}
+
+	conditions, _ = forecast.At(time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC))
+	if conditions.CloudBaseFeet != 2000 {
+		t.Fatalf("expected a cloud base of 2000ft, got %v", conditions.CloudBaseFeet)
+	}
+
+	level, found := conditions.At(275)
+	if !found || level.TemperatureCelsius > -47 || level.TemperatureCelsius < -49 || level.RelativeHumidity < 69 || level.RelativeHumidity > 71 {
+		t.Fatalf("expected the conditions between 300 and 250 hPa to be interpolated, got %+v", level)
+	}
+	if _, found := conditions.At(700); found {
+		t.Fatal("expected no conditions below the lowest level")
+	}
+}
+
+func TestFile(t *testing.T) {
+	forecast, err := NewFile("testdata/forecast.json").Forecast(context.Background(), geodist.Coord{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conditions, found := forecast.At(time.Now())
+	if !found || conditions.CloudCoverMid != 50 || conditions.VisibilityMeters != 9000 {
+		t.Fatalf("expected the conditions of the file to apply to every hour, got %+v", conditions)
+	}
+}
@@ -35,10 +35,14 @@ type Config struct {
 	SecureCookies bool        // Enable secure cookies (for HTTPS)
 }
 
+// apiTimeout is how long the proxies wait for the backend API, including reading the response
+const apiTimeout = 10 * time.Second
+
 // Server represents the web frontend server
 type Server struct {
 	config           Config
 	engine           *gin.Engine
+	client           *http.Client
 	isDataReady      bool
 	auth             *auth.BasicAuth
 	jetspotterConfig *configuration.Config // Add jetspotter configuration
@@ -79,6 +83,7 @@ func NewServer(config Config) *Server {
 	server := &Server{
 		config:           config,
 		engine:           engine,
+		client:           &http.Client{Timeout: apiTimeout},
 		isDataReady:      false,
 		auth:             auth.NewBasicAuth(),
 		jetspotterConfig: &jetspotterConfig,
@@ -149,6 +154,9 @@ func (s *Server) setupRoutes() {
 	s.engine.GET("/", s.handleIndex)
 	s.engine.GET("/api/aircraft", s.handleAPIProxy)
 	s.engine.GET("/api/version", s.handleVersion)
+	s.engine.GET("/api/airspaces", s.handleAirspacesProxy)
+	s.engine.GET("/api/weather/aloft", s.handleAloftProxy)
+	s.engine.GET("/images/:source/:name", s.handleImageProxy)
 
 	// Protected routes using auth middleware
 	protected := s.engine.Group("/")
@@ -340,10 +348,19 @@ func (s *Server) handleConfig(c *gin.Context) {
 	})
 }
 
+// getAPI sends a GET request for the path to the backend API, which is cancelled when the client goes away
+func (s *Server) getAPI(c *gin.Context, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(c.Request.Context(), http.MethodGet, s.config.APIEndpoint+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.Do(req)
+}
+
 // handleAPIProxy proxies requests to the backend API
 func (s *Server) handleAPIProxy(c *gin.Context) {
 	// Forward the request to the actual API
-	resp, err := http.Get(s.config.APIEndpoint + "/api/aircraft")
+	resp, err := s.getAPI(c, "/api/aircraft")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch data from API"})
 		return
@@ -364,7 +381,7 @@ func (s *Server) handleAPIProxy(c *gin.Context) {
 // handleAPIConfigProxy proxies requests to the backend API for configuration
 func (s *Server) handleAPIConfigProxy(c *gin.Context) {
 	// Create a new request to forward to the API
-	req, err := http.NewRequest("GET", s.config.APIEndpoint+"/api/config", nil)
+	req, err := http.NewRequestWithContext(c.Request.Context(), "GET", s.config.APIEndpoint+"/api/config", nil)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request for config API"})
 		return
@@ -375,8 +392,7 @@ func (s *Server) handleAPIConfigProxy(c *gin.Context) {
 	req.SetBasicAuth(s.auth.Username, s.auth.Password)
 
 	// Execute the request
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := s.client.Do(req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch config data from API"})
 		return
@@ -401,6 +417,44 @@ func (s *Server) handleAPIConfigProxy(c *gin.Context) {
 	c.JSON(http.StatusOK, config)
 }
 
+// handleAirspacesProxy proxies requests for the airspaces to the backend API
+func (s *Server) handleAirspacesProxy(c *gin.Context) {
+	resp, err := s.getAPI(c, "/api/airspaces")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch airspaces from API"})
+		return
+	}
+	defer resp.Body.Close()
+
+	c.DataFromReader(resp.StatusCode, resp.ContentLength, resp.Header.Get("Content-Type"), resp.Body, nil)
+}
+
+// handleAloftProxy proxies requests for the winds and temperatures aloft to the backend API
+func (s *Server) handleAloftProxy(c *gin.Context) {
+	resp, err := s.getAPI(c, "/api/weather/aloft")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch winds aloft from API"})
+		return
+	}
+	defer resp.Body.Close()
+
+	c.DataFromReader(resp.StatusCode, resp.ContentLength, resp.Header.Get("Content-Type"), resp.Body, nil)
+}
+
+// handleImageProxy proxies requests for cached thumbnails and your own photos to the backend API
+func (s *Server) handleImageProxy(c *gin.Context) {
+	resp, err := s.getAPI(c, c.Request.URL.EscapedPath())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch image from API"})
+		return
+	}
+	defer resp.Body.Close()
+
+	c.DataFromReader(resp.StatusCode, resp.ContentLength, resp.Header.Get("Content-Type"), resp.Body, map[string]string{
+		"Cache-Control": resp.Header.Get("Cache-Control"),
+	})
+}
+
 // handleVersion serves the application version information
 func (s *Server) handleVersion(c *gin.Context) {
 	c.JSON(http.StatusOK, version.GetFullVersionInfo())
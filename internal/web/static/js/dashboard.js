@@ -16,6 +16,7 @@ let countdownInterval = null;
 let isLoading = true; // New variable to track loading state
 let appVersion = 'dev'; // Variable to store app version
 let configCoordinates = { latitude: null, longitude: null }; // Store coordinates for map link
+let airspaces = []; // Airspaces as GeoJSON features, drawn on the airspace map
 
 // DOM elements
 document.addEventListener('DOMContentLoaded', () => {
@@ -133,6 +134,13 @@ document.addEventListener('DOMContentLoaded', () => {
 
     // Fetch version information
     fetchVersionInfo();
+
+    // Fetch the airspaces once, they don't change while running
+    fetchAirspaces();
+
+    // The winds aloft change slowly, they are refreshed together with the aircraft
+    fetchAloft();
+    setInterval(fetchAloft, REFRESH_PERIOD * 1000);
     
     // Start fetching data
     fetchData();
@@ -572,6 +580,91 @@ function renderAircraftGrid() {
             gridElement.appendChild(card);
         });
     }
+
+    renderAirspaceMap(filteredAircraft);
+}
+
+// Fetch the airspaces as GeoJSON, the map stays hidden if there are none
+async function fetchAirspaces() {
+    try {
+        const response = await fetch('/api/airspaces');
+        if (!response.ok) {
+            throw new Error(`HTTP error! Status: ${response.status}`);
+        }
+        const collection = await response.json();
+        airspaces = collection.features || [];
+        renderAirspaceMap(getFilteredAndSortedAircraft());
+    } catch (error) {
+        console.error('Error fetching airspaces:', error);
+    }
+}
+
+// Draw the airspaces and the aircraft on a simple equirectangular projection fitted to the airspaces
+function renderAirspaceMap(aircraftList) {
+    const container = document.getElementById('airspaceMap');
+    const svg = document.getElementById('airspaceSvg');
+    if (!container || !svg) return;
+
+    if (airspaces.length === 0) {
+        container.hidden = true;
+        return;
+    }
+    container.hidden = false;
+
+    let minLon = Infinity, maxLon = -Infinity, minLat = Infinity, maxLat = -Infinity;
+    airspaces.forEach(feature => {
+        feature.geometry.coordinates[0].forEach(([lon, lat]) => {
+            minLon = Math.min(minLon, lon);
+            maxLon = Math.max(maxLon, lon);
+            minLat = Math.min(minLat, lat);
+            maxLat = Math.max(maxLat, lat);
+        });
+    });
+
+    // Degrees of longitude get shorter away from the equator
+    const width = 800, height = 400, margin = 10;
+    const cosLat = Math.cos(((minLat + maxLat) / 2) * Math.PI / 180);
+    const spanX = Math.max((maxLon - minLon) * cosLat, 1e-6);
+    const spanY = Math.max(maxLat - minLat, 1e-6);
+    const scale = Math.min((width - 2 * margin) / spanX, (height - 2 * margin) / spanY);
+    const offsetX = (width - spanX * scale) / 2;
+    const offsetY = (height - spanY * scale) / 2;
+    const project = (lon, lat) => [
+        offsetX + (lon - minLon) * cosLat * scale,
+        offsetY + (maxLat - lat) * scale,
+    ];
+
+    const occupied = new Set();
+    aircraftList.forEach(aircraft => (aircraft.Airspaces || []).forEach(name => occupied.add(name)));
+
+    let content = '';
+    airspaces.forEach(feature => {
+        const properties = feature.properties;
+        const points = feature.geometry.coordinates[0].map(([lon, lat]) => project(lon, lat).map(v => v.toFixed(1)).join(',')).join(' ');
+        const classes = ['airspace'];
+        if (['R', 'P', 'Q'].includes(properties.class)) classes.push('restricted');
+        if (occupied.has(properties.name)) classes.push('occupied');
+        content += `<polygon class="${classes.join(' ')}" points="${points}"><title>${escapeHTML(`${properties.name} (${properties.lower} - ${properties.upper})`)}</title></polygon>`;
+    });
+
+    aircraftList.forEach(aircraft => {
+        const lat = aircraft.EstimatedLatitude || aircraft.Latitude;
+        const lon = aircraft.EstimatedLongitude || aircraft.Longitude;
+        if (!lat && !lon) return;
+        const [x, y] = project(lon, lat);
+        if (x < 0 || x > width || y < 0 || y > height) return;
+        const label = aircraft.Callsign || aircraft.Registration || aircraft.ICAO;
+        content += `<circle class="aircraft-dot${aircraft.Military ? ' military' : ''}" data-icao="${aircraft.ICAO}" cx="${x.toFixed(1)}" cy="${y.toFixed(1)}" r="4"><title>${label}</title></circle>`;
+        content += `<text x="${(x + 6).toFixed(1)}" y="${(y + 3).toFixed(1)}">${label}</text>`;
+    });
+
+    svg.innerHTML = content;
+    svg.querySelectorAll('.aircraft-dot').forEach(dot => {
+        dot.addEventListener('click', () => {
+            const aircraft = aircraftList.find(ac => ac.ICAO === dot.dataset.icao);
+            if (aircraft) showFlightDetails(aircraft);
+        });
+    });
 }
 
 // Create an aircraft card element
@@ -584,16 +677,20 @@ function createAircraftCard(aircraft) {
     }
     
     // Set the callsign
-    card.querySelector('.aircraft-callsign').textContent = aircraft.Callsign || 'Unknown';
+    const callsignElement = card.querySelector('.aircraft-callsign');
+    callsignElement.textContent = aircraft.Callsign || 'Unknown';
+    if (aircraft.SpokenCallsign) {
+        callsignElement.title = aircraft.SpokenCallsign;
+    }
     
     // Set country info
     const countryName = aircraft.Country || 'Unknown';
     card.querySelector('.aircraft-country-info').textContent = countryName;
     
-    // Add a flag emoji in the header only if country is known
+    // Add a flag emoji in the header only if the country is known
     const flagElement = card.querySelector('.aircraft-country-flag');
-    if (countryName !== 'Unknown') {
-        flagElement.textContent = getCountryFlagEmoji(countryName);
+    if (aircraft.CountryFlag) {
+        flagElement.textContent = aircraft.CountryFlag;
         flagElement.style.display = 'inline-block';
     } else {
         // Don't show any flag for unknown countries
@@ -628,6 +725,21 @@ function createAircraftCard(aircraft) {
         groundBadge.style.display = 'none';
     }
     
+    // Mark positions from multilateration, and positions that are too old or too inaccurate to notify about
+    const positionBadge = card.querySelector('.aircraft-position-badge');
+    if (aircraft.PositionStale || aircraft.PositionInaccurate) {
+        positionBadge.textContent = aircraft.PositionStale ? 'STALE' : 'INACCURATE';
+        positionBadge.title = aircraft.PositionStale ? `Position reported ${Math.round(aircraft.PositionAge)}s ago` : `Position accurate to ${Math.round(aircraft.PositionAccuracy)} m`;
+        positionBadge.style.display = 'block';
+        card.classList.add('is-position-flagged');
+    } else if (aircraft.Source === 'MLAT') {
+        positionBadge.textContent = 'MLAT';
+        positionBadge.title = 'Position is determined by multilateration';
+        positionBadge.style.display = 'block';
+    } else {
+        positionBadge.style.display = 'none';
+    }
+    
     // Get the image container
     const imageContainer = card.querySelector('.aircraft-image');
     // Clear the container first
@@ -651,8 +763,10 @@ function createAircraftCard(aircraft) {
     
     // Create the image element
     const imgElement = document.createElement('img');
-    if (aircraft.ImageThumbnailURL) {
-        imgElement.src = aircraft.ImageThumbnailURL;
+    // Thumbnails served by jetspotter itself keep working when the image provider is down
+    const thumbnailURL = aircraft.ImageThumbnailPath || aircraft.ImageThumbnailURL;
+    if (thumbnailURL) {
+        imgElement.src = thumbnailURL;
         imgElement.alt = `${aircraft.Type || 'Aircraft'} - ${aircraft.Registration || ''}`;
         
         // Add photographer information as tooltip if available
@@ -715,6 +829,9 @@ function createAircraftCard(aircraft) {
     } else {
         distanceElement.textContent = aircraft.Distance || 'Unknown';
         distanceElement.classList.remove('value-na');
+        if (aircraft.Place) {
+            distanceElement.title = aircraft.Place;
+        }
     }
     
     // Fix: Use aircraft.Heading instead of the undefined 'heading' variable
@@ -816,161 +933,6 @@ function createAircraftCard(aircraft) {
     return card;
 }
 
-// Get emoji flag based on country name
-function getCountryFlagEmoji(countryName) {
-    // Map country names to two-letter ISO country codes for emoji flags
-    const countryToISOCode = {
-        'United States': 'US',
-        'United Kingdom': 'GB',
-        'Canada': 'CA',
-        'Mexico': 'MX',
-        'France': 'FR',
-        'Germany': 'DE',
-        'Italy': 'IT',
-        'Spain': 'ES',
-        'Portugal': 'PT',
-        'Ireland': 'IE',
-        'Belgium': 'BE',
-        'Netherlands': 'NL',
-        'Sweden': 'SE',
-        'Denmark': 'DK',
-        'Finland': 'FI',
-        'Norway': 'NO',
-        'Romania': 'RO',
-        'Poland': 'PL',
-        'Czech Republic': 'CZ',
-        'Hungary': 'HU',
-        'Serbia': 'RS',
-        'Greece': 'GR',
-        'Malta': 'MT',
-        'Japan': 'JP',
-        'China': 'CN',
-        'India': 'IN',
-        'Thailand': 'TH',
-        'Indonesia': 'ID',
-        'Malaysia': 'MY',
-        'Singapore': 'SG',
-        'Australia': 'AU',
-        'New Zealand': 'NZ',
-        'Argentina': 'AR',
-        'Brazil': 'BR',
-        'Chile': 'CL',
-        'Colombia': 'CO',
-        'Israel': 'IL',
-        'Turkey': 'TR',
-        'Egypt': 'EG',
-        'South Africa': 'ZA',
-        'Ethiopia': 'ET',
-        'Nigeria': 'NG',
-        'Algeria': 'DZ',
-        'Morocco': 'MA',
-        'Saudi Arabia': 'SA',
-        'United Arab Emirates': 'AE',
-        'Qatar': 'QA',
-        'Bahrain': 'BH',
-        'Iran': 'IR',
-        'Iraq': 'IQ',
-        'Kuwait': 'KW',
-        // Adding more countries from the Wikipedia list of aircraft registration prefixes
-        'Tunisia': 'TN',
-        'Libya': 'LY',
-        'Jordan': 'JO',
-        'Lebanon': 'LB',
-        'Syria': 'SY',
-        'Yemen': 'YE',
-        'Oman': 'OM',
-        'Afghanistan': 'AF',
-        'Pakistan': 'PK',
-        'Bangladesh': 'BD',
-        'Nepal': 'NP',
-        'Sri Lanka': 'LK',
-        'Myanmar': 'MM',
-        'Vietnam': 'VN',
-        'Philippines': 'PH',
-        'Taiwan': 'TW',
-        'South Korea': 'KR',
-        'North Korea': 'KP',
-        'Mongolia': 'MN',
-        'Kazakhstan': 'KZ',
-        'Uzbekistan': 'UZ',
-        'Turkmenistan': 'TM',
-        'Tajikistan': 'TJ',
-        'Kyrgyzstan': 'KG',
-        'Azerbaijan': 'AZ',
-        'Armenia': 'AM',
-        'Georgia': 'GE',
-        'Ukraine': 'UA',
-        'Belarus': 'BY',
-        'Russia': 'RU',
-        'Moldova': 'MD',
-        'San Marino': 'SM',
-        'Bulgaria': 'BG',
-        'Slovakia': 'SK',
-        'Austria': 'AT',
-        'Switzerland': 'CH',
-        'Luxembourg': 'LU',
-        'Latvia': 'LV',
-        'Lithuania': 'LT',
-        'Estonia': 'EE',
-        'Iceland': 'IS',
-        'Croatia': 'HR',
-        'Slovenia': 'SI',
-        'Bosnia and Herzegovina': 'BA',
-        'North Macedonia': 'MK',
-        'Albania': 'AL',
-        'Montenegro': 'ME',
-        'Cyprus': 'CY',
-        'Peru': 'PE',
-        'Ecuador': 'EC',
-        'Bolivia': 'BO',
-        'Paraguay': 'PY',
-        'Uruguay': 'UY',
-        'Venezuela': 'VE',
-        'Panama': 'PA',
-        'Costa Rica': 'CR',
-        'Guatemala': 'GT',
-        'El Salvador': 'SV',
-        'Honduras': 'HN',
-        'Nicaragua': 'NI',
-        'Belize': 'BZ',
-        'Jamaica': 'JM',
-        'Cuba': 'CU',
-        'Dominican Republic': 'DO',
-        'Kenya': 'KE',
-        'Tanzania': 'TZ',
-        'Uganda': 'UG',
-        'Rwanda': 'RW',
-        'Ghana': 'GH',
-        'Senegal': 'SN',
-        'Ivory Coast': 'CI',
-        'Cameroon': 'CM',
-        'Zimbabwe': 'ZW',
-        'Mozambique': 'MZ',
-        'Angola': 'AO',
-        'Mauritius': 'MU',
-        'Seychelles': 'SC',
-        'Madagascar': 'MG',
-        'Fiji': 'FJ',
-        'Papua New Guinea': 'PG',
-        'Solomon Islands': 'SB'
-    };
-    
-    // Extract country code from "Unknown (X)" format
-    if (countryName.startsWith('Unknown (') && countryName.endsWith(')')) {
-        return '';  // Return empty string instead of generic flag
-    }
-    
-    const countryCode = countryToISOCode[countryName];
-    if (!countryCode) return '';  // Return empty string for unknown countries
-    
-    // Convert ISO country code to country flag emoji
-    // Regional Indicator Symbols are Unicode characters U+1F1E6 to U+1F1FF
-    // which represent
-    const offset = 127397; // Offset to convert ASCII letter to Regional Indicator Symbol
-    const codePoints = [...countryCode].map(char => char.charCodeAt(0) + offset);
-    return String.fromCodePoint(...codePoints);
-}
-
 // Add notification icons based on aircraft notification status
 function addNotificationIcons(container, aircraft) {
     // Always hide the container - this removes notification icons from the aircraft cards
@@ -1178,6 +1140,116 @@ function updateMapLink() {
     }
 }
 
+// Fetch the winds and temperatures aloft, the chart stays hidden until there are reports
+async function fetchAloft() {
+    try {
+        const response = await fetch('/api/weather/aloft');
+        if (!response.ok) {
+            throw new Error(`HTTP error! Status: ${response.status}`);
+        }
+        renderAloftChart(await response.json());
+    } catch (error) {
+        console.error('Error fetching winds aloft:', error);
+    }
+}
+
+// Draw the temperature by altitude as a line and the wind of each altitude band as an arrow in the direction it blows to
+function renderAloftChart(profile) {
+    const container = document.getElementById('aloftChart');
+    const svg = document.getElementById('aloftSvg');
+    if (!container || !svg) return;
+
+    const levels = (profile && profile.levels) || [];
+    if (levels.length === 0) {
+        container.hidden = true;
+        return;
+    }
+    container.hidden = false;
+    document.getElementById('aloftWindow').textContent = `(last ${profile.windowMinutes} minutes, bands of ${profile.bandFeet.toLocaleString()} ft)`;
+
+    const width = 800, height = 320, left = 60, right = 200, top = 10, bottom = 30;
+    const maxAltitude = Math.max(...levels.map(level => level.upperFeet));
+    const temperatures = levels.filter(level => level.temperature !== null).map(level => level.temperature);
+    const minTemperature = Math.floor((Math.min(0, ...temperatures) - 5) / 10) * 10;
+    const maxTemperature = Math.ceil((Math.max(10, ...temperatures) + 5) / 10) * 10;
+
+    const y = altitude => top + (1 - altitude / maxAltitude) * (height - top - bottom);
+    const x = temperature => left + (temperature - minTemperature) / (maxTemperature - minTemperature) * (width - left - right);
+
+    let content = `<line class="axis" x1="${left}" y1="${top}" x2="${left}" y2="${height - bottom}"></line>`;
+    content += `<line class="axis" x1="${left}" y1="${height - bottom}" x2="${width - right}" y2="${height - bottom}"></line>`;
+    for (let temperature = minTemperature; temperature <= maxTemperature; temperature += 10) {
+        content += `<text x="${x(temperature).toFixed(1)}" y="${height - bottom + 15}" text-anchor="middle">${temperature}°C</text>`;
+    }
+    const step = Math.max(5000, Math.ceil(maxAltitude / 6 / 5000) * 5000);
+    for (let altitude = 0; altitude <= maxAltitude; altitude += step) {
+        content += `<text x="${left - 5}" y="${(y(altitude) + 4).toFixed(1)}" text-anchor="end">${altitude.toLocaleString()} ft</text>`;
+    }
+
+    const points = levels
+        .filter(level => level.temperature !== null)
+        .map(level => [x(level.temperature), y((level.lowerFeet + level.upperFeet) / 2), level]);
+    if (points.length > 1) {
+        content += `<polyline class="temperature-line" points="${points.map(([px, py]) => `${px.toFixed(1)},${py.toFixed(1)}`).join(' ')}"></polyline>`;
+    }
+    points.forEach(([px, py, level]) => {
+        content += `<circle class="temperature-point" cx="${px.toFixed(1)}" cy="${py.toFixed(1)}" r="3"><title>${level.temperature}°C from ${level.temperatureReports} reports</title></circle>`;
+    });
+
+    levels.filter(level => level.windDirection !== null).forEach(level => {
+        const cx = width - right + 30;
+        const cy = y((level.lowerFeet + level.upperFeet) / 2);
+        // The wind direction is where it blows from, the arrow points to where it blows to
+        const radians = (level.windDirection + 180) * Math.PI / 180;
+        const length = 10;
+        const dx = Math.sin(radians) * length, dy = -Math.cos(radians) * length;
+        content += `<g><title>${level.windReports} reports</title>`;
+        content += `<line class="wind-arrow" x1="${(cx - dx).toFixed(1)}" y1="${(cy - dy).toFixed(1)}" x2="${(cx + dx).toFixed(1)}" y2="${(cy + dy).toFixed(1)}"></line>`;
+        content += `<circle class="wind-head" cx="${(cx + dx).toFixed(1)}" cy="${(cy + dy).toFixed(1)}" r="2"></circle>`;
+        content += `<text x="${cx + 20}" y="${(cy + 4).toFixed(1)}">${String(level.windDirection).padStart(3, '0')}° / ${level.windSpeed} kt</text></g>`;
+    });
+
+    svg.innerHTML = content;
+}
+
+// Escape text from external sources, such as place names, airspace files and weather reports, before it is added as HTML
+function escapeHTML(text) {
+    return String(text)
+        .replace(/&/g, '&amp;')
+        .replace(/</g, '&lt;')
+        .replace(/>/g, '&gt;')
+        .replace(/"/g, '&quot;')
+        .replace(/'/g, '&#39;');
+}
+
+// Format the air data, wind, temperatures and autopilot settings that the aircraft reports, fields that are null are left out
+function formatTelemetry(aircraft) {
+    const known = value => value !== null && value !== undefined;
+    const lines = [];
+
+    const speeds = [];
+    if (known(aircraft.IndicatedAirSpeed)) speeds.push(`IAS ${aircraft.IndicatedAirSpeed} kt`);
+    if (known(aircraft.TrueAirSpeed)) speeds.push(`TAS ${aircraft.TrueAirSpeed} kt`);
+    if (known(aircraft.Mach)) speeds.push(`Mach ${aircraft.Mach.toFixed(2)}`);
+    if (known(aircraft.VerticalRate)) speeds.push(`${aircraft.VerticalRate > 0 ? '+' : ''}${aircraft.VerticalRate} ft/min`);
+    if (speeds.length) lines.push(speeds.join(' • '));
+
+    const weather = [];
+    if (known(aircraft.WindDirection) && known(aircraft.WindSpeed)) weather.push(`Wind ${String(aircraft.WindDirection).padStart(3, '0')}°/${aircraft.WindSpeed} kt`);
+    if (known(aircraft.OutsideAirTemperature)) weather.push(`OAT ${aircraft.OutsideAirTemperature}°C`);
+    if (known(aircraft.TotalAirTemperature)) weather.push(`TAT ${aircraft.TotalAirTemperature}°C`);
+    if (weather.length) lines.push(weather.join(' • '));
+
+    const autopilot = [];
+    if (known(aircraft.SelectedAltitude)) autopilot.push(`Selected ${aircraft.SelectedAltitude.toLocaleString()} ft`);
+    if (known(aircraft.FMSAltitude)) autopilot.push(`FMS ${aircraft.FMSAltitude.toLocaleString()} ft`);
+    if (known(aircraft.SelectedHeading)) autopilot.push(`Heading ${Math.round(aircraft.SelectedHeading)}°`);
+    if (known(aircraft.QNH)) autopilot.push(`QNH ${Math.round(aircraft.QNH)} hPa`);
+    if (autopilot.length) lines.push(autopilot.join(' • '));
+
+    return lines;
+}
+
 // Parse and clean airport name, handling placeholder values
 function cleanAirportName(name) {
     // Check for placeholder values and other invalid patterns
@@ -1187,22 +1259,6 @@ function cleanAirportName(name) {
     return name;
 }
 
-// Generate consistent flight progress percentage based on ICAO or callsign
-function getConsistentFlightProgress(aircraft) {
-    // Use ICAO or callsign as a seed for consistent position
-    const seed = aircraft.ICAO || aircraft.Callsign || '';
-    
-    // Create a simple hash from the seed string
-    let hash = 0;
-    for (let i = 0; i < seed.length; i++) {
-        hash = ((hash << 5) - hash) + seed.charCodeAt(i);
-        hash = hash & hash; // Convert to 32bit integer
-    }
-    
-    // Generate a value between 10 and 90 (avoid extremes)
-    return Math.abs(hash % 81) + 10; 
-}
-
 // Flight details popup handler
 function showFlightDetails(aircraft) {
     // Create overlay
@@ -1250,10 +1306,10 @@ function showFlightDetails(aircraft) {
                 originIcaoCode = origin.icao_code || '';
                 
                 // Get coordinates if available
-                if (origin.longitude_deg !== undefined && origin.latitude_deg !== undefined) {
+                if (origin.longitude !== undefined && origin.latitude !== undefined) {
                     originCoordinates = {
-                        lat: origin.latitude_deg,
-                        lng: origin.longitude_deg
+                        lat: origin.latitude,
+                        lng: origin.longitude
                     };
                 }
             }
@@ -1292,10 +1348,10 @@ function showFlightDetails(aircraft) {
                 destinationIcaoCode = destination.icao_code || '';
                 
                 // Get coordinates if available
-                if (destination.longitude_deg !== undefined && destination.latitude_deg !== undefined) {
+                if (destination.longitude !== undefined && destination.latitude !== undefined) {
                     destinationCoordinates = {
-                        lat: destination.latitude_deg,
-                        lng: destination.longitude_deg
+                        lat: destination.latitude,
+                        lng: destination.longitude
                     };
                 }
             }
@@ -1305,6 +1361,11 @@ function showFlightDetails(aircraft) {
         }
     }
     
+    // Intermediate stops of the route, if any
+    const stopsText = Array.isArray(aircraft.Stops) && aircraft.Stops.length > 0
+        ? aircraft.Stops.map(stop => stop.iata_code || stop.icao_code || cleanAirportName(stop.name)).join(', ')
+        : '';
+
     // Parse airline data
     let airlineDisplay = 'Unknown';
     let airlineDetails = '';
@@ -1344,29 +1405,22 @@ function showFlightDetails(aircraft) {
     const destinationFlag = createFlagEmoji(destinationCountryCode);
     const airlineFlag = createFlagEmoji(airlineCountryCode);
 
-    // Always position the flight progress animation in the middle (50%)
-    const flightProgressPercent = 50;
-    
-    // Estimate distance between airports if we have coordinates
+    // Progress along the route, distances and arrival time are calculated by the server
+    const flightProgressPercent = Math.min(100, Math.max(0, aircraft.RouteProgress || 0));
+
     let flightDistanceText = '';
-    if (originCoordinates && destinationCoordinates) {
-        // Using Haversine formula for a rough distance calculation
-        const R = 6371; // Radius of the Earth in km
-        const dLat = (destinationCoordinates.lat - originCoordinates.lat) * Math.PI / 180;
-        const dLon = (destinationCoordinates.lng - originCoordinates.lng) * Math.PI / 180;
-        const a = 
-            Math.sin(dLat/2) * Math.sin(dLat/2) +
-            Math.cos(originCoordinates.lat * Math.PI / 180) * Math.cos(destinationCoordinates.lat * Math.PI / 180) * 
-            Math.sin(dLon/2) * Math.sin(dLon/2);
-        const c = 2 * Math.atan2(Math.sqrt(a), Math.sqrt(1-a));
-        const distance = Math.round(R * c);
-        
-        flightDistanceText = `${distance.toLocaleString()} km`;
+    if (aircraft.DistanceFlown || aircraft.DistanceRemaining) {
+        flightDistanceText = `${aircraft.DistanceFlown.toLocaleString()} km flown • ${aircraft.DistanceRemaining.toLocaleString()} km to go`;
     }
-    
-    // Determine flight time estimate (very rough approximation)
-    const flightTimeText = flightDistanceText ? `~${Math.max(1, Math.round(parseInt(flightDistanceText) / 800))}h ${Math.round(Math.random() * 59)}m` : '';
-    
+
+    let flightTimeText = '';
+    if (aircraft.ETASeconds > 0) {
+        const eta = new Date(Date.now() + aircraft.ETASeconds * 1000);
+        const hours = Math.floor(aircraft.ETASeconds / 3600);
+        const minutes = Math.floor((aircraft.ETASeconds % 3600) / 60);
+        flightTimeText = `ETA ${eta.toLocaleTimeString([], { hour: '2-digit', minute: '2-digit' })} (${hours > 0 ? `${hours}h ` : ''}${minutes}m)`;
+    }
+
     // Flight number/callsign
     const flightNumberDisplay = aircraft.Callsign || 'Unknown';
     
@@ -1404,7 +1458,7 @@ function showFlightDetails(aircraft) {
         <div class="flight-details-header">
             <div class="flight-details-title">
                 ${planeIcon}
-                <span class="callsign">${aircraft.Callsign}</span>
+                <span class="callsign"${aircraft.SpokenCallsign ? ` title="${aircraft.SpokenCallsign}"` : ''}>${aircraft.Callsign}</span>
                 <span class="aircraft-type">${aircraft.Type || 'Unknown Aircraft'}</span>
             </div>
             <button class="flight-details-close" aria-label="Close">&times;</button>
@@ -1450,13 +1504,14 @@ function showFlightDetails(aircraft) {
                     ` : ''}
                     ${flightTimeText && flightDistanceText ? ' • ' : ''}
                     ${flightTimeText ? `
-                        <span title="Estimated flight time">
+                        <span title="Estimated time of arrival">
                             ${timeIcon} 
                             ${flightTimeText}
                         </span>
                     ` : ''}
                 </div>
             ` : ''}
+            ${stopsText ? `<div class="flight-info-badge">Via ${stopsText}</div>` : ''}
         </div>
         <div class="flight-details-content">
             <div class="flight-details-section flight-details-airline">
@@ -1485,7 +1540,15 @@ function showFlightDetails(aircraft) {
             <div class="flight-details-section flight-details-distance">
                 <div class="flight-details-label">Current Position</div>
                 <div class="flight-details-value">${aircraft.Distance || '?'} km from you</div>
-                <div class="flight-details-subvalue">Altitude: ${Math.round(aircraft.Altitude).toLocaleString() || '?'} ft • Speed: ${aircraft.Speed || '?'} knots</div>
+                ${aircraft.Place ? `<div class="flight-details-subvalue">${escapeHTML(aircraft.Place)}</div>` : ''}
+                ${aircraft.Airspaces && aircraft.Airspaces.length ? `<div class="flight-details-subvalue">In ${escapeHTML(aircraft.Airspaces.join(', '))}</div>` : ''}
+                <div class="flight-details-subvalue">Altitude: ${Math.round(aircraft.Altitude).toLocaleString() || '?'} ft${aircraft.HeightAboveGround != null && !aircraft.OnGround ? ` (${Math.round(aircraft.HeightAboveGround).toLocaleString()} ft AGL)` : ''} • Speed: ${aircraft.Speed || '?'} knots</div>
+                ${aircraft.PositionAge !== undefined ? `<div class="flight-details-subvalue">Position estimated from ${aircraft.Source ? `a ${escapeHTML(aircraft.Source)} report` : 'a report'} ${Math.round(aircraft.PositionAge)}s ago${aircraft.PositionAccuracy ? ` • ±${Math.round(aircraft.PositionAccuracy).toLocaleString()} m` : ''}</div>` : ''}
+                ${aircraft.PositionStale || aircraft.PositionInaccurate ? `<div class="flight-details-subvalue">Position is ${aircraft.PositionStale ? 'stale' : 'inaccurate'}, not used for notifications</div>` : ''}
+                ${aircraft.CPASeconds > 0 ? `<div class="flight-details-subvalue">Closest approach: ${aircraft.CPADistance} km in ${Math.floor(aircraft.CPASeconds / 60)}m ${aircraft.CPASeconds % 60}s</div>` : ''}
+                ${aircraft.Conditions ? `<div class="flight-details-subvalue">${escapeHTML(aircraft.ConditionsSource)}: ${escapeHTML(aircraft.Conditions)}${aircraft.Runway ? ` • Runway ${escapeHTML(aircraft.Runway)}` : ''}</div>` : ''}
+                ${aircraft.ContrailLikely ? `<div class="flight-details-subvalue">${aircraft.ContrailPersistent ? 'Persistent contrail likely' : 'Contrail likely'}</div>` : ''}
+                ${formatTelemetry(aircraft).map(line => `<div class="flight-details-subvalue">${line}</div>`).join('')}
             </div>
         </div>
         <div class="flight-details-actions">